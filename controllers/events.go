@@ -0,0 +1,159 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events recorded on services and namespaces.
+const (
+	// EventReasonRegistered means that the object has been created in the
+	// service registry.
+	EventReasonRegistered string = "Registered"
+	// EventReasonUpdated means that the object, or any of its endpoints,
+	// has been updated in the service registry.
+	EventReasonUpdated string = "Updated"
+	// EventReasonDeregistered means that the object has been removed from
+	// the service registry.
+	EventReasonDeregistered string = "Deregistered"
+	// EventReasonSkippedNotOwned means that the object, or any of its
+	// endpoints, exists in the service registry but is not owned by the
+	// operator, and therefore has been left untouched.
+	EventReasonSkippedNotOwned string = "SkippedNotOwned"
+	// EventReasonRegistryError means that the service registry returned an
+	// error while reflecting the object.
+	EventReasonRegistryError string = "RegistryError"
+	// EventReasonUnsupportedMetadata means that some metadata of the object
+	// are not supported by the service registry and have been skipped.
+	EventReasonUnsupportedMetadata string = "UnsupportedMetadata"
+)
+
+// eventRecorder records events on Kubernetes objects. Events are not
+// recorded if the recorder is nil.
+type eventRecorder struct {
+	record.EventRecorder
+}
+
+func (e eventRecorder) normal(obj runtime.Object, reason, msg string) {
+	if e.EventRecorder != nil {
+		e.Event(obj, corev1.EventTypeNormal, reason, msg)
+	}
+}
+
+func (e eventRecorder) warning(obj runtime.Object, reason, msg string) {
+	if e.EventRecorder != nil {
+		e.Event(obj, corev1.EventTypeWarning, reason, msg)
+	}
+}
+
+// registryError records an error returned by the service registry, either
+// as SkippedNotOwned or as RegistryError.
+func (e eventRecorder) registryError(obj runtime.Object, err error) {
+	if isNotOwnedErr(err) {
+		e.warning(obj, EventReasonSkippedNotOwned, err.Error())
+		return
+	}
+
+	e.warning(obj, EventReasonRegistryError, err.Error())
+}
+
+// unsupportedMetadata records the metadata keys of the object that have
+// been skipped, if any.
+func (e eventRecorder) unsupportedMetadata(obj runtime.Object, keys []string) {
+	if len(keys) > 0 {
+		e.warning(obj, EventReasonUnsupportedMetadata, fmt.Sprintf("metadata not supported by the service registry have been skipped: %s", strings.Join(keys, ", ")))
+	}
+}
+
+// syncResult records the events about the namespace and the service after
+// they have been synced with the service registry.
+func (e eventRecorder) syncResult(ns *corev1.Namespace, serv *corev1.Service, fixes []sr.DriftFix, endpErrs map[string]error, err error) {
+	created, updated, deleted := 0, 0, 0
+	servCreated, servUpdated := false, false
+	for _, fix := range fixes {
+		switch {
+		case fix.ServName == "" && fix.Action == sr.DriftCreated:
+			e.normal(ns, EventReasonRegistered, "namespace registered in service registry")
+		case fix.ServName == "" && fix.Action == sr.DriftUpdated:
+			e.normal(ns, EventReasonUpdated, "namespace updated in service registry")
+		case fix.EndpName == "" && fix.Action == sr.DriftCreated:
+			servCreated = true
+		case fix.EndpName == "":
+			servUpdated = true
+		case fix.Action == sr.DriftCreated:
+			created++
+		case fix.Action == sr.DriftUpdated:
+			updated++
+		case fix.Action == sr.DriftDeleted:
+			deleted++
+		}
+	}
+
+	switch {
+	case servCreated:
+		e.normal(serv, EventReasonRegistered, fmt.Sprintf("service registered in service registry with %d endpoints", created))
+	case servUpdated || created+updated+deleted > 0:
+		e.normal(serv, EventReasonUpdated, fmt.Sprintf("service updated in service registry: %d endpoints created, %d updated, %d deleted", created, updated, deleted))
+	}
+
+	if err != nil {
+		e.registryError(serv, err)
+		return
+	}
+
+	notOwned := []string{}
+	for endpName, endpErr := range endpErrs {
+		if isNotOwnedErr(endpErr) {
+			notOwned = append(notOwned, endpName)
+			continue
+		}
+
+		e.warning(serv, EventReasonRegistryError, fmt.Sprintf("could not reflect endpoint %s: %s", endpName, endpErr))
+	}
+
+	if len(notOwned) > 0 {
+		sort.Strings(notOwned)
+		e.warning(serv, EventReasonSkippedNotOwned, fmt.Sprintf("endpoints not owned by the operator have been left untouched: %s", strings.Join(notOwned, ", ")))
+	}
+}
+
+func isNotOwnedErr(err error) bool {
+	for _, notOwnedErr := range []error{
+		sr.ErrNsNotOwnedByOp,
+		sr.ErrNsNotOwnedServs,
+		sr.ErrServNotOwnedByOp,
+		sr.ErrServNotOwnedEndps,
+		sr.ErrEndpNotOwnedByOp,
+	} {
+		if errors.Is(err, notOwnedErr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,129 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// recordedEvents returns the events recorded so far by the fake recorder.
+func recordedEvents(rec *record.FakeRecorder) []string {
+	events := []string{}
+	for {
+		select {
+		case event := <-rec.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestSyncResultEvents(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	serv := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "serv", Namespace: "ns"}}
+
+	cases := []struct {
+		id        string
+		fixes     []sr.DriftFix
+		endpErrs  map[string]error
+		err       error
+		expEvents []string
+	}{
+		{
+			id: "registered",
+			fixes: []sr.DriftFix{
+				{Action: sr.DriftCreated, NsName: "ns"},
+				{Action: sr.DriftCreated, NsName: "ns", ServName: "serv"},
+				{Action: sr.DriftCreated, NsName: "ns", ServName: "serv", EndpName: "one"},
+				{Action: sr.DriftCreated, NsName: "ns", ServName: "serv", EndpName: "two"},
+			},
+			expEvents: []string{
+				"Normal Registered namespace registered in service registry",
+				"Normal Registered service registered in service registry with 2 endpoints",
+			},
+		},
+		{
+			id: "updated",
+			fixes: []sr.DriftFix{
+				{Action: sr.DriftUpdated, NsName: "ns", ServName: "serv"},
+				{Action: sr.DriftUpdated, NsName: "ns", ServName: "serv", EndpName: "one"},
+				{Action: sr.DriftDeleted, NsName: "ns", ServName: "serv", EndpName: "two"},
+			},
+			expEvents: []string{
+				"Normal Updated service updated in service registry: 0 endpoints created, 1 updated, 1 deleted",
+			},
+		},
+		{
+			id:        "nothing-changed",
+			fixes:     []sr.DriftFix{},
+			expEvents: []string{},
+		},
+		{
+			id:    "skipped-not-owned-endpoints",
+			fixes: []sr.DriftFix{},
+			endpErrs: map[string]error{
+				"two": sr.ErrEndpNotOwnedByOp,
+				"one": sr.ErrEndpNotOwnedByOp,
+			},
+			expEvents: []string{
+				"Warning SkippedNotOwned endpoints not owned by the operator have been left untouched: one, two",
+			},
+		},
+		{
+			id:  "skipped-not-owned-service",
+			err: sr.ErrServNotOwnedByOp,
+			expEvents: []string{
+				"Warning SkippedNotOwned " + sr.ErrServNotOwnedByOp.Error(),
+			},
+		},
+		{
+			id:  "registry-error",
+			err: sr.ErrTimeOutExpired,
+			expEvents: []string{
+				"Warning RegistryError " + sr.ErrTimeOutExpired.Error(),
+			},
+		},
+		{
+			id:       "endpoint-error",
+			fixes:    []sr.DriftFix{},
+			endpErrs: map[string]error{"one": sr.ErrTimeOutExpired},
+			expEvents: []string{
+				fmt.Sprintf("Warning RegistryError could not reflect endpoint one: %s", sr.ErrTimeOutExpired),
+			},
+		},
+	}
+
+	a := assert.New(t)
+	for _, currCase := range cases {
+		rec := record.NewFakeRecorder(10)
+		eventRecorder{rec}.syncResult(ns, serv, currCase.fixes, currCase.endpErrs, currCase.err)
+		a.Equal(currCase.expEvents, recordedEvents(rec), "case %s failed", currCase.id)
+	}
+
+	// No recorder, no events and no panics
+	eventRecorder{}.syncResult(ns, serv, nil, nil, sr.ErrTimeOutExpired)
+}
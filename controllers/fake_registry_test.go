@@ -0,0 +1,160 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"path"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+)
+
+// fakeRegistry is an in-memory service registry, where objects are stored
+// with their full path as key, e.g. ns/serv/endp.
+type fakeRegistry struct {
+	ns    map[string]*sr.Namespace
+	servs map[string]*sr.Service
+	endps map[string]*sr.Endpoint
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		ns:    map[string]*sr.Namespace{},
+		servs: map[string]*sr.Service{},
+		endps: map[string]*sr.Endpoint{},
+	}
+}
+
+func newFakeBroker(f *fakeRegistry) *sr.Broker {
+	b, _ := sr.NewBroker(f, sr.MetadataPair{})
+	return b
+}
+
+func (f *fakeRegistry) GetNs(_ context.Context, name string) (*sr.Namespace, error) {
+	if ns, exists := f.ns[name]; exists {
+		return ns, nil
+	}
+	return nil, sr.ErrNotFound
+}
+
+func (f *fakeRegistry) ListNs(_ context.Context) ([]*sr.Namespace, error) {
+	list := []*sr.Namespace{}
+	for _, ns := range f.ns {
+		list = append(list, ns)
+	}
+	return list, nil
+}
+
+func (f *fakeRegistry) CreateNs(_ context.Context, ns *sr.Namespace) (*sr.Namespace, error) {
+	f.ns[ns.Name] = ns
+	return ns, nil
+}
+
+func (f *fakeRegistry) UpdateNs(_ context.Context, ns *sr.Namespace) (*sr.Namespace, error) {
+	f.ns[ns.Name] = ns
+	return ns, nil
+}
+
+func (f *fakeRegistry) DeleteNs(_ context.Context, name string) error {
+	for _, serv := range f.servs {
+		if serv.NsName == name {
+			f.DeleteServ(context.Background(), name, serv.Name)
+		}
+	}
+	delete(f.ns, name)
+	return nil
+}
+
+func (f *fakeRegistry) GetServ(_ context.Context, nsName, servName string) (*sr.Service, error) {
+	if serv, exists := f.servs[path.Join(nsName, servName)]; exists {
+		return serv, nil
+	}
+	return nil, sr.ErrNotFound
+}
+
+func (f *fakeRegistry) ListServ(_ context.Context, nsName string) ([]*sr.Service, error) {
+	list := []*sr.Service{}
+	for _, serv := range f.servs {
+		if serv.NsName == nsName {
+			list = append(list, serv)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeRegistry) CreateServ(_ context.Context, serv *sr.Service) (*sr.Service, error) {
+	f.servs[path.Join(serv.NsName, serv.Name)] = serv
+	return serv, nil
+}
+
+func (f *fakeRegistry) UpdateServ(_ context.Context, serv *sr.Service) (*sr.Service, error) {
+	f.servs[path.Join(serv.NsName, serv.Name)] = serv
+	return serv, nil
+}
+
+func (f *fakeRegistry) DeleteServ(_ context.Context, nsName, servName string) error {
+	for key, endp := range f.endps {
+		if endp.NsName == nsName && endp.ServName == servName {
+			delete(f.endps, key)
+		}
+	}
+	delete(f.servs, path.Join(nsName, servName))
+	return nil
+}
+
+func (f *fakeRegistry) GetEndp(_ context.Context, nsName, servName, endpName string) (*sr.Endpoint, error) {
+	if endp, exists := f.endps[path.Join(nsName, servName, endpName)]; exists {
+		return endp, nil
+	}
+	return nil, sr.ErrNotFound
+}
+
+func (f *fakeRegistry) ListEndp(_ context.Context, nsName, servName string) ([]*sr.Endpoint, error) {
+	list := []*sr.Endpoint{}
+	for _, endp := range f.endps {
+		if endp.NsName == nsName && endp.ServName == servName {
+			list = append(list, endp)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeRegistry) CreateEndp(_ context.Context, endp *sr.Endpoint) (*sr.Endpoint, error) {
+	f.endps[path.Join(endp.NsName, endp.ServName, endp.Name)] = endp
+	return endp, nil
+}
+
+func (f *fakeRegistry) UpdateEndp(_ context.Context, endp *sr.Endpoint) (*sr.Endpoint, error) {
+	f.endps[path.Join(endp.NsName, endp.ServName, endp.Name)] = endp
+	return endp, nil
+}
+
+func (f *fakeRegistry) DeleteEndp(_ context.Context, nsName, servName, endpName string) error {
+	delete(f.endps, path.Join(nsName, servName, endpName))
+	return nil
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	newMeta := make(map[string]string, len(metadata))
+	for key, val := range metadata {
+		newMeta[key] = val
+	}
+
+	return newMeta
+}
@@ -0,0 +1,65 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// deregisterFinalizer is set on services and namespaces that are
+	// registered, so that they are removed from the service registry
+	// before they are deleted from Kubernetes.
+	deregisterFinalizer string = "operator.cnwan.io/deregister"
+)
+
+// addFinalizer adds the deregister finalizer to the object, unless it
+// already has it or it is being deleted, as no finalizers can be added at
+// that point.
+func addFinalizer(ctx context.Context, cli client.Client, obj controllerutil.Object) error {
+	if obj.GetDeletionTimestamp() != nil || controllerutil.ContainsFinalizer(obj, deregisterFinalizer) {
+		return nil
+	}
+
+	orig := obj.DeepCopyObject()
+	controllerutil.AddFinalizer(obj, deregisterFinalizer)
+	return cli.Patch(ctx, obj, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+}
+
+// removeFinalizer removes the deregister finalizer from the object, if it
+// has it.
+func removeFinalizer(ctx context.Context, cli client.Client, obj controllerutil.Object) error {
+	if !controllerutil.ContainsFinalizer(obj, deregisterFinalizer) {
+		return nil
+	}
+
+	orig := obj.DeepCopyObject()
+	controllerutil.RemoveFinalizer(obj, deregisterFinalizer)
+	return cli.Patch(ctx, obj, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{}))
+}
+
+// deletionStarted returns whether the update marks the object as being
+// deleted, i.e. it is waiting for its finalizers to be removed.
+func deletionStarted(e event.UpdateEvent) bool {
+	return e.MetaOld.GetDeletionTimestamp() == nil && e.MetaNew.GetDeletionTimestamp() != nil
+}
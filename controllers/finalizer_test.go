@@ -0,0 +1,145 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestServiceFinalizer(t *testing.T) {
+	owned := map[string]string{"owner": "cnwan-operator"}
+	key := types.NamespacedName{Namespace: "ns", Name: "serv"}
+	// Finalizers are patched with optimistic locking
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{watchLabel: "enabled"}, ResourceVersion: "1"}}
+	newServ := func() *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Annotations: map[string]string{"cnwan.io/profile": "video"}, ResourceVersion: "1"},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: 80}},
+			},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "10.10.10.10"}}},
+			},
+		}
+	}
+	newReconciler := func(f *fakeRegistry, serv *corev1.Service) *ServiceReconciler {
+		return &ServiceReconciler{
+			Log:                zap.New(zap.UseDevMode(true)),
+			Client:             fake.NewFakeClientWithScheme(scheme.Scheme, ns.DeepCopy(), serv),
+			ServRegBroker:      newFakeBroker(f),
+			AllowedAnnotations: []string{"cnwan.io/*"},
+			UseFinalizer:       true,
+			Recorder:           record.NewFakeRecorder(10),
+			retries:            newRetryTracker(),
+		}
+	}
+
+	a := assert.New(t)
+
+	// The finalizer is added when the service is registered
+	f := newFakeRegistry()
+	r := newReconciler(f, newServ())
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+	a.NoError(err)
+	a.Contains(f.servs, "ns/serv")
+
+	var serv corev1.Service
+	a.NoError(r.Get(context.Background(), key, &serv))
+	a.True(controllerutil.ContainsFinalizer(&serv, deregisterFinalizer))
+	var regNs corev1.Namespace
+	a.NoError(r.Get(context.Background(), types.NamespacedName{Name: "ns"}, &regNs))
+	a.True(controllerutil.ContainsFinalizer(&regNs, deregisterFinalizer))
+
+	// The service is deregistered before the finalizer is removed
+	now := metav1.Now()
+	deleting := newServ()
+	deleting.DeletionTimestamp = &now
+	deleting.Finalizers = []string{deregisterFinalizer}
+	f = newFakeRegistry()
+	f.ns["ns"] = &sr.Namespace{Name: "ns", Metadata: copyMetadata(owned)}
+	f.servs["ns/serv"] = &sr.Service{Name: "serv", NsName: "ns", Metadata: copyMetadata(owned)}
+	r = newReconciler(f, deleting)
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+	a.NoError(err)
+	a.NotContains(f.servs, "ns/serv")
+	var deleted corev1.Service
+	a.NoError(r.Get(context.Background(), key, &deleted))
+	a.Empty(deleted.Finalizers)
+	a.Equal([]string{"Normal Deregistered service removed from service registry"}, recordedEvents(r.Recorder.(*record.FakeRecorder)))
+
+	// Errors that would occur again don't prevent the deletion
+	f = newFakeRegistry()
+	f.ns["ns"] = &sr.Namespace{Name: "ns", Metadata: copyMetadata(owned)}
+	f.servs["ns/serv"] = &sr.Service{Name: "serv", NsName: "ns", Metadata: map[string]string{"owner": "someone-else"}}
+	r = newReconciler(f, deleting.DeepCopy())
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+	a.NoError(err)
+	a.Contains(f.servs, "ns/serv")
+	deleted = corev1.Service{}
+	a.NoError(r.Get(context.Background(), key, &deleted))
+	a.Empty(deleted.Finalizers)
+	a.Equal([]string{"Warning SkippedNotOwned " + sr.ErrServNotOwnedByOp.Error()}, recordedEvents(r.Recorder.(*record.FakeRecorder)))
+}
+
+func TestNamespaceFinalizer(t *testing.T) {
+	owned := map[string]string{"owner": "cnwan-operator"}
+	now := metav1.Now()
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              "ns",
+		Labels:            map[string]string{watchLabel: "enabled"},
+		DeletionTimestamp: &now,
+		Finalizers:        []string{deregisterFinalizer},
+		ResourceVersion:   "1",
+	}}
+	f := newFakeRegistry()
+	f.ns["ns"] = &sr.Namespace{Name: "ns", Metadata: copyMetadata(owned)}
+	f.servs["ns/serv"] = &sr.Service{Name: "serv", NsName: "ns", Metadata: copyMetadata(owned)}
+	r := &NamespaceReconciler{
+		Log:           zap.New(zap.UseDevMode(true)),
+		Client:        fake.NewFakeClientWithScheme(scheme.Scheme, ns),
+		ServRegBroker: newFakeBroker(f),
+		nsLastConf:    map[string]bool{"ns": true},
+		retries:       newRetryTracker(),
+	}
+
+	a := assert.New(t)
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "ns"}})
+	a.NoError(err)
+	a.Empty(f.ns)
+	a.Empty(f.servs)
+	a.NotContains(r.nsLastConf, "ns")
+
+	var regNs corev1.Namespace
+	a.NoError(r.Get(context.Background(), types.NamespacedName{Name: "ns"}, &regNs))
+	a.Empty(regNs.Finalizers)
+}
@@ -0,0 +1,109 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/go-logr/logr"
+)
+
+// GarbageCollector removes from the service registry, once, the objects
+// owned by the operator whose Kubernetes services have been deleted or have
+// stopped being watched while the operator was not running, as the events
+// about them will never be received.
+//
+// It is meant to be added to a manager, so that it starts only after the
+// caches have been synced.
+type GarbageCollector struct {
+	// Reconciler is the service reconciler that is used to know which
+	// namespaces are watched.
+	Reconciler *ServiceReconciler
+	Log        logr.Logger
+}
+
+// Start removes the orphaned objects and returns.
+func (g *GarbageCollector) Start(stop <-chan struct{}) error {
+	ctx, canc := context.WithCancel(context.Background())
+	defer canc()
+	go func() {
+		select {
+		case <-stop:
+			canc()
+		case <-ctx.Done():
+		}
+	}()
+
+	l := g.Log.WithName("GarbageCollector")
+	l.V(1).Info("going to remove orphaned objects from the service registry")
+
+	removed, err := g.Reconciler.collectGarbage(ctx)
+	if err != nil {
+		// This is not fatal: the operator can still work.
+		l.Error(err, "error while removing orphaned objects from the service registry")
+	}
+
+	for _, fix := range removed {
+		l.Info("removed orphaned object from service registry", "fix", fix.String())
+	}
+	l.Info("garbage collection completed", "removed", len(removed))
+
+	return nil
+}
+
+// NeedLeaderElection returns true, as only one instance of the operator
+// must write to the service registry.
+func (g *GarbageCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// collectGarbage removes the services owned by the operator that don't
+// have a matching Kubernetes service that should be registered, and the owned
+// namespaces that have been deleted or are not watched anymore.
+func (r *ServiceReconciler) collectGarbage(ctx context.Context) ([]sr.DriftFix, error) {
+	if r.ServRegBroker == nil {
+		return nil, fmt.Errorf("%s", "service registry broker is nil")
+	}
+
+	// Load the service registry first: this way, a service that is
+	// registered while we're loading Kubernetes will be in Kubernetes too
+	// and will not be removed.
+	owned, err := r.listOwned(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, servList, err := r.listWatched(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the services that are watched are kept: the ones that don't
+	// exist anymore and the ones that are not watched anymore are removed,
+	// as the reconciler only removes the unwatched services that it knows
+	// may have been registered.
+	keep := map[string]map[string]bool{}
+	for _, serv := range servList {
+		keepServ(keep, serv.Namespace, serv.Name)
+	}
+
+	return r.removeOrphans(ctx, owned, keep, namespaces), nil
+}
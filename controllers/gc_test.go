@@ -0,0 +1,81 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	owned := map[string]string{"owner": "cnwan-operator"}
+	f := newFakeRegistry()
+	r := &ServiceReconciler{
+		Log: zap.New(zap.UseDevMode(true)),
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "watched", Labels: map[string]string{watchLabel: "enabled"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "not-watched"}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "watched"}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "not-watched"}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "opted-in", Namespace: "not-watched", Annotations: map[string]string{registerAnnotation: "enabled"}}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "opted-out", Namespace: "watched", Annotations: map[string]string{registerAnnotation: "disabled"}}},
+		),
+		ServRegBroker: newFakeBroker(f),
+	}
+
+	f.ns["watched"] = &sr.Namespace{Name: "watched", Metadata: copyMetadata(owned)}
+	f.servs["watched/existing"] = &sr.Service{Name: "existing", NsName: "watched", Metadata: copyMetadata(owned)}
+	f.servs["watched/deleted"] = &sr.Service{Name: "deleted", NsName: "watched", Metadata: copyMetadata(owned)}
+	f.endps["watched/deleted/endp"] = &sr.Endpoint{Name: "endp", NsName: "watched", ServName: "deleted", Metadata: copyMetadata(owned)}
+	f.servs["watched/not-owned"] = &sr.Service{Name: "not-owned", NsName: "watched", Metadata: map[string]string{}}
+	f.ns["not-watched"] = &sr.Namespace{Name: "not-watched", Metadata: copyMetadata(owned)}
+	f.servs["not-watched/existing"] = &sr.Service{Name: "existing", NsName: "not-watched", Metadata: copyMetadata(owned)}
+	f.servs["not-watched/opted-in"] = &sr.Service{Name: "opted-in", NsName: "not-watched", Metadata: copyMetadata(owned)}
+	f.servs["watched/opted-out"] = &sr.Service{Name: "opted-out", NsName: "watched", Metadata: copyMetadata(owned)}
+	f.ns["opting-in"] = &sr.Namespace{Name: "opting-in", Metadata: copyMetadata(owned)}
+	f.ns["shared"] = &sr.Namespace{Name: "shared", Metadata: copyMetadata(owned)}
+	f.servs["shared/not-owned"] = &sr.Service{Name: "not-owned", NsName: "shared", Metadata: map[string]string{}}
+
+	a := assert.New(t)
+	removed, err := r.collectGarbage(context.Background())
+	a.NoError(err)
+	a.ElementsMatch([]sr.DriftFix{
+		{Action: sr.DriftDeleted, NsName: "watched", ServName: "deleted"},
+		{Action: sr.DriftDeleted, NsName: "watched", ServName: "opted-out"},
+		{Action: sr.DriftDeleted, NsName: "not-watched", ServName: "existing"},
+		{Action: sr.DriftDeleted, NsName: "opting-in"},
+	}, removed)
+
+	a.Contains(f.servs, "watched/existing")
+	a.Contains(f.servs, "watched/not-owned")
+	a.NotContains(f.endps, "watched/deleted/endp")
+	a.Contains(f.ns, "watched")
+	a.Contains(f.ns, "not-watched")
+	a.Contains(f.servs, "not-watched/opted-in")
+	a.Contains(f.ns, "shared")
+	a.Contains(f.servs, "shared/not-owned")
+}
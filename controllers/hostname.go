@@ -0,0 +1,45 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry/convert"
+)
+
+// HostnameResolver resolves hostnames to their IP addresses.
+// *net.Resolver implements it.
+type HostnameResolver = convert.HostnameResolver
+
+// HostnameOptions contains options about how load balancer ingresses that
+// have a hostname but no IP, e.g. the ones of AWS load balancers, must be
+// registered.
+type HostnameOptions struct {
+	// Resolve specifies whether the hostname must be resolved and its
+	// addresses registered. If false, the hostname itself is registered as
+	// the address of the endpoints.
+	Resolve bool
+	// Resolver is used to resolve hostnames. If nil, net.DefaultResolver
+	// is used.
+	Resolver HostnameResolver
+	// RefreshInterval is how often services with hostnames are reconciled
+	// again to reflect changes in their DNS records.
+	RefreshInterval time.Duration
+}
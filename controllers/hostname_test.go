@@ -0,0 +1,49 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNeedsHostnameRefresh(t *testing.T) {
+	serv := &corev1.Service{
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{Hostname: "lb.elb.amazonaws.com"}}},
+		},
+	}
+
+	a := assert.New(t)
+	r := &ServiceReconciler{}
+	a.False(r.needsHostnameRefresh(serv))
+
+	r.LoadBalancerHostname = &HostnameOptions{RefreshInterval: time.Minute}
+	a.False(r.needsHostnameRefresh(serv))
+
+	r.LoadBalancerHostname.Resolve = true
+	a.True(r.needsHostnameRefresh(serv))
+
+	serv.Status.LoadBalancer.Ingress[0].IP = "1.1.1.1"
+	a.False(r.needsHostnameRefresh(serv))
+}
@@ -21,15 +21,22 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"strings"
 	"sync"
 
 	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 const (
@@ -42,18 +49,35 @@ type NamespaceReconciler struct {
 	Log                      logr.Logger
 	Scheme                   *runtime.Scheme
 	WatchNamespacesByDefault bool
-	AllowedAnnotations       []string
-	nsLastConf               map[string]bool
-	lock                     sync.Mutex
-	ServRegBroker            *sr.Broker
+	// NamespaceSelector, if not nil, selects the namespaces to watch among
+	// those that don't have the watch label, instead of
+	// WatchNamespacesByDefault.
+	NamespaceSelector labels.Selector
+	// ServiceSelector, if not nil, must be matched by services in watched
+	// namespaces to be registered, unless they have the register
+	// annotation.
+	ServiceSelector labels.Selector
+	nsLastConf      map[string]bool
+	lock            sync.Mutex
+	ServRegBroker   *sr.Broker
+	// Recorder records events on namespaces and services about their
+	// removal from the service registry. If nil, no events are recorded.
+	Recorder record.EventRecorder
+	// MaxConcurrentReconciles is the maximum number of namespaces that can
+	// be reconciled at the same time. Defaults to 1.
+	MaxConcurrentReconciles int
+
+	retries *retryTracker
 }
 
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=namespaces/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile checks the changes in a service and reflects those changes in the service registry
 func (r *NamespaceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	ctx := context.Background()
+	ctx, canc := context.WithTimeout(context.Background(), reconcileTimeout)
+	defer canc()
 	l := r.Log.WithValues("namespace", req.NamespacedName)
 
 	// Get the namespace
@@ -81,102 +105,184 @@ func (r *NamespaceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, nil
 	}
 
+	if !deleted && ns.DeletionTimestamp != nil && controllerutil.ContainsFinalizer(&ns, deregisterFinalizer) {
+		return r.deregister(ctx, l, req.NamespacedName, &ns)
+	}
+
 	if deleted {
 		// If this namespace was deleted, there is no point in loading
 		// services: you won't find anything there.
 		// So let's save ourselves some computation and just go straight to
 		// business then.
-		if err := r.ServRegBroker.RemoveNs(ns.Name, true); err != nil {
-			l.Error(err, "error while deleting service")
+		if err := r.ServRegBroker.RemoveNs(ctx, ns.Name, true); err != nil {
+			return r.retries.handleResult(l, req.NamespacedName, fmt.Errorf("could not delete namespace: %w", err))
 		}
 
 		r.lock.Lock()
 		defer r.lock.Unlock()
 
 		delete(r.nsLastConf, ns.Name)
-		return ctrl.Result{}, nil
+		return r.retries.handleResult(l, req.NamespacedName, nil)
 	}
 
-	change, nsIsWatched := func() (bool, bool) {
-		var currentlyWatched bool
-		switch strings.ToLower(ns.Labels[watchLabel]) {
-		case "enabled":
-			currentlyWatched = true
-		case "disabled":
-			currentlyWatched = false
-		default:
-			currentlyWatched = r.WatchNamespacesByDefault
-		}
-
+	currentlyWatched := r.selection().isNsWatched(&ns)
+	previouslyWatched := func() bool {
 		r.lock.Lock()
 		defer r.lock.Unlock()
+
 		previouslyWatched, existed := r.nsLastConf[ns.Name]
 		if !existed {
-			previouslyWatched = r.WatchNamespacesByDefault
+			previouslyWatched = r.selection().defaultWatched()
 		}
 
-		changed := currentlyWatched != previouslyWatched
-		r.nsLastConf[ns.Name] = currentlyWatched
-		return changed, currentlyWatched
+		return previouslyWatched
 	}()
-	if !change {
+	if currentlyWatched == previouslyWatched {
 		return ctrl.Result{}, nil
 	}
 
+	// Services of a namespace that is now watched are reconciled by the
+	// service controller, which also watches namespaces.
+	var removeErr error
+	if !currentlyWatched {
+		removeErr = r.removeUnwatched(ctx, &ns)
+		if sr.IsRetryable(removeErr) {
+			// Don't save the new configuration, so that the next attempt
+			// will try to remove the namespace again.
+			return r.retries.handleResult(l, req.NamespacedName, removeErr)
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.nsLastConf[ns.Name] = currentlyWatched
+	return r.retries.handleResult(l, req.NamespacedName, removeErr)
+}
+
+// removeUnwatched removes a namespace that is not watched anymore from the
+// service registry. If some of its services have opted in with their
+// register annotation, only the other services are removed and the
+// namespace is left there.
+func (r *NamespaceReconciler) removeUnwatched(ctx context.Context, ns *corev1.Namespace) error {
 	var servList corev1.ServiceList
-	if err := r.List(ctx, &servList, &client.ListOptions{Namespace: ns.Name}); err != nil {
-		l.Error(err, "error while getting services")
-		return ctrl.Result{}, err
+	if err := r.List(ctx, &servList, client.InNamespace(ns.Name)); err != nil {
+		return fmt.Errorf("could not list services: %w", err)
 	}
 
-	// First, check the services
-	for _, serv := range servList.Items {
-		if !nsIsWatched {
-			if err := r.ServRegBroker.RemoveServ(serv.Namespace, serv.Name, true); err != nil {
-				l.Error(err, "error while deleting service")
-			}
-		} else {
-			// Get the data in our simpler format
-			// Note: as of now, we are not copying any annotations from a namespace
-			serv.Annotations = filterAnnotations(serv.Annotations, r.AllowedAnnotations)
-			nsData, servData, endpList, err := r.ServRegBroker.Reg.ExtractData(&ns, &serv)
-			if err != nil {
-				l.WithValues("serv-name", servData.Name).Error(err, "error while extracting data from the namespace and service")
-				return ctrl.Result{}, nil
-			}
-			nsData.Metadata = map[string]string{}
+	servs := map[string]*corev1.Service{}
+	optedIn := map[string]bool{}
+	for i := range servList.Items {
+		servs[servList.Items[i].Name] = &servList.Items[i]
+		if r.selection().isServWatched(&servList.Items[i], ns) {
+			optedIn[servList.Items[i].Name] = true
+		}
+	}
 
-			if _, err := r.ServRegBroker.ManageNs(nsData); err != nil {
-				l.WithValues("ns-name", nsData.Name).Error(err, "error while processing namespace change")
-				return ctrl.Result{}, nil
-			}
-			if len(servData.Metadata) > 0 && len(endpList) > 0 {
-				if _, err := r.ServRegBroker.ManageServ(servData); err != nil {
-					l.WithValues("serv-name", servData.Name).Error(err, "error while updating service")
-					continue
-				}
-				if _, err := r.ServRegBroker.ManageServEndps(servData.NsName, servData.Name, endpList); err != nil {
-					l.WithValues("serv-name", servData.Name).Error(err, "an error occurred while processing service's endpoints")
-					continue
-				}
-			}
+	if len(optedIn) == 0 {
+		_, getErr := r.ServRegBroker.Reg.GetNs(ctx, ns.Name)
+		if err := r.ServRegBroker.RemoveNs(ctx, ns.Name, true); err != nil {
+			r.events().registryError(ns, err)
+			return fmt.Errorf("could not delete namespace: %w", err)
+		}
+
+		if getErr == nil {
+			r.events().normal(ns, EventReasonDeregistered, "namespace removed from service registry")
 		}
+		if err := removeFinalizer(ctx, r, ns); err != nil {
+			return fmt.Errorf("could not remove finalizer from namespace: %w", err)
+		}
+		return nil
 	}
 
-	if !nsIsWatched {
-		if err := r.ServRegBroker.RemoveNs(ns.Name, true); err != nil {
-			l.Error(err, "error while deleting service")
+	regServs, err := r.ServRegBroker.ListOwnedServ(ctx, ns.Name)
+	if err != nil {
+		if err == sr.ErrNotFound {
+			return nil
 		}
+
+		return fmt.Errorf("could not list services from service registry: %w", err)
 	}
 
-	return ctrl.Result{}, nil
+	for _, regServ := range regServs {
+		if optedIn[regServ.Name] {
+			continue
+		}
+
+		serv, exists := servs[regServ.Name]
+		if err := r.ServRegBroker.RemoveServ(ctx, ns.Name, regServ.Name, true); err != nil {
+			if exists {
+				r.events().registryError(serv, err)
+			}
+			return fmt.Errorf("could not delete service %s: %w", regServ.Name, err)
+		}
+
+		if exists {
+			r.events().normal(serv, EventReasonDeregistered, "service removed from service registry as its namespace is not watched anymore")
+		}
+	}
+
+	return nil
+}
+
+// deregister removes a namespace that is being deleted from the service
+// registry and then removes its finalizer, so that Kubernetes can delete it.
+//
+// Just like services, the finalizer is also removed in case of errors that
+// would occur again if retried.
+func (r *NamespaceReconciler) deregister(ctx context.Context, l logr.Logger, key types.NamespacedName, ns *corev1.Namespace) (ctrl.Result, error) {
+	err := r.ServRegBroker.RemoveNs(ctx, ns.Name, true)
+	switch {
+	case err == nil:
+		r.events().normal(ns, EventReasonDeregistered, "namespace removed from service registry")
+	case sr.IsRetryable(err):
+		r.events().registryError(ns, err)
+		return r.retries.handleResult(l, key, fmt.Errorf("could not delete namespace: %w", err))
+	default:
+		r.events().registryError(ns, err)
+	}
+
+	if err := removeFinalizer(ctx, r, ns); err != nil {
+		l.Error(err, "could not remove finalizer from namespace")
+		return ctrl.Result{}, err
+	}
+
+	r.lock.Lock()
+	delete(r.nsLastConf, ns.Name)
+	r.lock.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("could not delete namespace: %w", err)
+	}
+	return r.retries.handleResult(l, key, err)
+}
+
+func (r *NamespaceReconciler) events() eventRecorder {
+	return eventRecorder{r.Recorder}
+}
+
+func (r *NamespaceReconciler) selection() selection {
+	return selection{
+		watchByDefault: r.WatchNamespacesByDefault,
+		nsSelector:     r.NamespaceSelector,
+		servSelector:   r.ServiceSelector,
+	}
 }
 
 // SetupWithManager ...
 func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.nsLastConf = map[string]bool{}
+	r.retries = newRetryTracker()
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Namespace{}).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+			RateLimiter:             newRetryRateLimiter(),
+		}).
+		For(&corev1.Namespace{}, builder.WithPredicates(predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return nsWatchChanged(e, r.selection()) || deletionStarted(e)
+			},
+		})).
 		Complete(r)
 }
@@ -0,0 +1,83 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestRemoveUnwatched(t *testing.T) {
+	owned := map[string]string{"owner": "cnwan-operator"}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{watchLabel: "disabled"}}}
+	var rec *record.FakeRecorder
+	prepare := func(servs ...*corev1.Service) (*NamespaceReconciler, *fakeRegistry) {
+		rec = record.NewFakeRecorder(10)
+		f := newFakeRegistry()
+		f.ns["ns"] = &sr.Namespace{Name: "ns", Metadata: copyMetadata(owned)}
+		f.servs["ns/one"] = &sr.Service{Name: "one", NsName: "ns", Metadata: copyMetadata(owned)}
+		f.servs["ns/two"] = &sr.Service{Name: "two", NsName: "ns", Metadata: copyMetadata(owned)}
+
+		objs := []runtime.Object{ns}
+		for _, serv := range servs {
+			objs = append(objs, serv)
+		}
+
+		return &NamespaceReconciler{
+			Log:           zap.New(zap.UseDevMode(true)),
+			Client:        fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+			ServRegBroker: newFakeBroker(f),
+			Recorder:      rec,
+		}, f
+	}
+	newServ := func(name, register string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "ns",
+			Annotations: map[string]string{registerAnnotation: register},
+		}}
+	}
+
+	a := assert.New(t)
+
+	// No service opted in: the whole namespace is removed
+	r, f := prepare(newServ("one", ""), newServ("two", "disabled"))
+	a.NoError(r.removeUnwatched(context.Background(), ns))
+	a.Empty(f.ns)
+	a.Empty(f.servs)
+	a.Equal([]string{"Normal Deregistered namespace removed from service registry"}, recordedEvents(rec))
+
+	// Opted in services are left there
+	r, f = prepare(newServ("one", "enabled"), newServ("two", ""))
+	a.NoError(r.removeUnwatched(context.Background(), ns))
+	a.Contains(f.ns, "ns")
+	a.Contains(f.servs, "ns/one")
+	a.NotContains(f.servs, "ns/two")
+	a.Equal([]string{"Normal Deregistered service removed from service registry as its namespace is not watched anymore"}, recordedEvents(rec))
+}
@@ -0,0 +1,103 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry/convert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// NodePortOptions contains options about how services of type NodePort
+// must be registered.
+type NodePortOptions struct {
+	// NodeSelector selects the nodes whose addresses will be registered.
+	NodeSelector labels.Selector
+	// AddressTypes are the types of node addresses to register, in order of
+	// preference: the first type that a node has is the one that is used.
+	AddressTypes []corev1.NodeAddressType
+}
+
+// mapNodeToServices returns a reconcile request for each NodePort service
+// in the cluster, because a change in a node affects all of them.
+func (r *ServiceReconciler) mapNodeToServices(handler.MapObject) []reconcile.Request {
+	var servList corev1.ServiceList
+	if err := r.List(context.Background(), &servList); err != nil {
+		r.Log.Error(err, "error while getting services after a node change")
+		return nil
+	}
+
+	reqs := []reconcile.Request{}
+	for _, serv := range servList.Items {
+		if serv.Spec.Type == corev1.ServiceTypeNodePort {
+			reqs = append(reqs, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: serv.Namespace, Name: serv.Name},
+			})
+		}
+	}
+
+	return reqs
+}
+
+// nodePredicate only lets through node events that change the addresses
+// that are registered.
+func (r *ServiceReconciler) nodePredicate() predicate.Predicate {
+	selected := func(node *corev1.Node) bool {
+		return r.NodePort.NodeSelector.Matches(labels.Set(node.Labels)) && convert.IsNodeReady(node)
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			node, ok := e.Object.(*corev1.Node)
+			return ok && selected(node)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			node, ok := e.Object.(*corev1.Node)
+			return ok && selected(node)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return false
+			}
+
+			if selected(oldNode) != selected(newNode) {
+				return true
+			}
+
+			return selected(newNode) &&
+				!reflect.DeepEqual(convert.NodeAddresses(oldNode, r.NodePort.AddressTypes), convert.NodeAddresses(newNode, r.NodePort.AddressTypes))
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
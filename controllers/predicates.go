@@ -0,0 +1,181 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// servicePredicate only lets through service updates that change data that
+// is registered, i.e. allowed annotations and labels, ports and addresses, or that
+// change whether the service should be registered.
+// Creations and deletions are always let through.
+//
+// Services that may stop being watched because of the update or the
+// deletion are recorded, so that Reconcile knows it has to remove them from
+// the service registry.
+func (r *ServiceReconciler) servicePredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldServ, ok := e.ObjectOld.(*corev1.Service)
+			if !ok {
+				return false
+			}
+			newServ, ok := e.ObjectNew.(*corev1.Service)
+			if !ok {
+				return false
+			}
+
+			if r.mayStopWatching(oldServ, newServ) {
+				r.unwatched.add(types.NamespacedName{Namespace: newServ.Namespace, Name: newServ.Name})
+			}
+			return r.serviceChanged(oldServ, newServ)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			if serv, ok := e.Object.(*corev1.Service); ok && r.selection().mayBeWatched(serv) {
+				r.unwatched.add(types.NamespacedName{Namespace: serv.Namespace, Name: serv.Name})
+			}
+			return true
+		},
+	}
+}
+
+// mayStopWatching returns whether the service may have been watched before
+// the update and the update changes whether it is watched or deletes it.
+func (r *ServiceReconciler) mayStopWatching(oldServ, newServ *corev1.Service) bool {
+	if !r.selection().mayBeWatched(oldServ) {
+		return false
+	}
+
+	return (oldServ.DeletionTimestamp == nil && newServ.DeletionTimestamp != nil) ||
+		oldServ.Annotations[registerAnnotation] != newServ.Annotations[registerAnnotation] ||
+		r.selection().servSelected(oldServ) != r.selection().servSelected(newServ)
+}
+
+func (r *ServiceReconciler) serviceChanged(oldServ, newServ *corev1.Service) bool {
+	switch {
+	case oldServ.DeletionTimestamp == nil && newServ.DeletionTimestamp != nil:
+		return true
+	case oldServ.Spec.Type != newServ.Spec.Type:
+		return true
+	case !reflect.DeepEqual(oldServ.Spec.Ports, newServ.Spec.Ports):
+		return true
+	case !reflect.DeepEqual(oldServ.Spec.ExternalIPs, newServ.Spec.ExternalIPs):
+		return true
+	case !reflect.DeepEqual(oldServ.Status.LoadBalancer.Ingress, newServ.Status.LoadBalancer.Ingress):
+		return true
+	case oldServ.Annotations[registerAnnotation] != newServ.Annotations[registerAnnotation]:
+		return true
+	case r.selection().servSelected(oldServ) != r.selection().servSelected(newServ):
+		return true
+	case !reflect.DeepEqual(filterAnnotations(oldServ.Labels, r.AllowedLabels, nil), filterAnnotations(newServ.Labels, r.AllowedLabels, nil)):
+		return true
+	}
+
+	return !reflect.DeepEqual(
+		filterAnnotations(oldServ.Annotations, r.allowedServAnnotations(), r.DeniedAnnotations),
+		filterAnnotations(newServ.Annotations, r.allowedServAnnotations(), r.DeniedAnnotations))
+}
+
+// namespacePredicate only lets through namespace updates that change
+// whether the namespace is watched or not, or that change the allowed
+// annotations of a watched namespace.
+func (r *ServiceReconciler) namespacePredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool {
+			// A new namespace has no services yet.
+			return false
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			// Services will be deleted on their own.
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return nsWatchChanged(e, r.selection()) || r.nsAnnotationsChanged(e)
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// nsWatchChanged returns whether the update changes the namespace from
+// watched to not watched or vice versa.
+func nsWatchChanged(e event.UpdateEvent, sel selection) bool {
+	oldNs, ok := e.ObjectOld.(*corev1.Namespace)
+	if !ok {
+		return false
+	}
+	newNs, ok := e.ObjectNew.(*corev1.Namespace)
+	if !ok {
+		return false
+	}
+
+	return sel.isNsWatched(oldNs) != sel.isNsWatched(newNs)
+}
+
+// nsAnnotationsChanged returns whether the update changes the allowed
+// annotations of a watched namespace, including the default metadata of its
+// services.
+func (r *ServiceReconciler) nsAnnotationsChanged(e event.UpdateEvent) bool {
+	oldNs, ok := e.ObjectOld.(*corev1.Namespace)
+	if !ok {
+		return false
+	}
+	newNs, ok := e.ObjectNew.(*corev1.Namespace)
+	if !ok {
+		return false
+	}
+
+	if !r.selection().isNsWatched(newNs) {
+		return false
+	}
+
+	return !reflect.DeepEqual(
+		filterAnnotations(oldNs.Annotations, r.allowedNsAnnotations(), r.DeniedNsAnnotations),
+		filterAnnotations(newNs.Annotations, r.allowedNsAnnotations(), r.DeniedNsAnnotations))
+}
+
+// mapNamespaceToServices returns a reconcile request for each service
+// inside the namespace.
+func (r *ServiceReconciler) mapNamespaceToServices(o handler.MapObject) []reconcile.Request {
+	var servList corev1.ServiceList
+	if err := r.List(context.Background(), &servList, client.InNamespace(o.Meta.GetName())); err != nil {
+		r.Log.WithValues("namespace", o.Meta.GetName()).Error(err, "error while getting services after a namespace change")
+		return nil
+	}
+
+	reqs := make([]reconcile.Request, len(servList.Items))
+	for i, serv := range servList.Items {
+		reqs[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: serv.Namespace, Name: serv.Name},
+		}
+	}
+
+	return reqs
+}
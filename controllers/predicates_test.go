@@ -0,0 +1,234 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestServicePredicate(t *testing.T) {
+	r := &ServiceReconciler{
+		AllowedAnnotations:       []string{"cnwan.io/*"},
+		AllowedLabels:            []string{"tier"},
+		ServiceSelector:          labels.SelectorFromSet(labels.Set{"env": "prod"}),
+		EndpointAnnotationPrefix: "endpoint.cnwan.io",
+		unwatched:                newUnwatchedTracker(),
+	}
+	p := r.servicePredicate()
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "serv",
+			Namespace:       "ns",
+			ResourceVersion: "1",
+			Annotations:     map[string]string{"cnwan.io/profile": "video", "ignored": "yes"},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{{Port: 80}},
+		},
+	}
+
+	cases := []struct {
+		id           string
+		change       func(*corev1.Service)
+		expRes       bool
+		expUnwatched bool
+	}{
+		{
+			id:     "only-resource-version",
+			change: func(s *corev1.Service) { s.ResourceVersion = "2" },
+		},
+		{
+			id:     "not-allowed-annotation",
+			change: func(s *corev1.Service) { s.Annotations["ignored"] = "no" },
+		},
+		{
+			id:     "allowed-annotation",
+			change: func(s *corev1.Service) { s.Annotations["cnwan.io/profile"] = "voice" },
+			expRes: true,
+		},
+		{
+			id:     "endpoint-annotation",
+			change: func(s *corev1.Service) { s.Annotations["endpoint.cnwan.io/web.profile"] = "voice" },
+			expRes: true,
+		},
+		{
+			id:     "ports",
+			change: func(s *corev1.Service) { s.Spec.Ports[0].Port = 8080 },
+			expRes: true,
+		},
+		{
+			id:     "external-ips",
+			change: func(s *corev1.Service) { s.Spec.ExternalIPs = []string{"1.1.1.1"} },
+			expRes: true,
+		},
+		{
+			id: "lb-ingress",
+			change: func(s *corev1.Service) {
+				s.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "2.2.2.2"}}
+			},
+			expRes: true,
+		},
+		{
+			id:     "selected-label",
+			change: func(s *corev1.Service) { s.Labels = map[string]string{"env": "prod"} },
+			expRes: true,
+		},
+		{
+			id:     "deleted",
+			change: func(s *corev1.Service) { s.DeletionTimestamp = &metav1.Time{} },
+			expRes: true,
+		},
+		{
+			id:     "not-selected-label",
+			change: func(s *corev1.Service) { s.Labels = map[string]string{"team": "red"} },
+		},
+		{
+			id:     "allowed-label",
+			change: func(s *corev1.Service) { s.Labels = map[string]string{"tier": "gold"} },
+			expRes: true,
+		},
+		{
+			id:     "register-annotation",
+			change: func(s *corev1.Service) { s.Annotations[registerAnnotation] = "disabled" },
+			expRes: true,
+		},
+		{
+			id: "opt-out",
+			change: func(s *corev1.Service) {
+				s.Labels = map[string]string{"env": "prod"}
+				s.Annotations[registerAnnotation] = "disabled"
+			},
+			expRes:       true,
+			expUnwatched: true,
+		},
+	}
+
+	a := assert.New(t)
+	key := types.NamespacedName{Namespace: "ns", Name: "serv"}
+	for _, currCase := range cases {
+		oldServ, newServ := serv.DeepCopy(), serv.DeepCopy()
+		currCase.change(newServ)
+		if currCase.expUnwatched {
+			oldServ.Labels = newServ.Labels
+		}
+
+		res := p.Update(event.UpdateEvent{MetaOld: oldServ, ObjectOld: oldServ, MetaNew: newServ, ObjectNew: newServ})
+		a.Equal(currCase.expRes, res, "case %s failed", currCase.id)
+		a.Equal(currCase.expUnwatched, r.unwatched.has(key), "case %s failed", currCase.id)
+		r.unwatched.forget(key)
+	}
+
+	// Deleted services are recorded only if they may have been watched
+	a.True(p.Delete(event.DeleteEvent{Meta: serv, Object: serv}))
+	a.False(r.unwatched.has(key))
+	selected := serv.DeepCopy()
+	selected.Labels = map[string]string{"env": "prod"}
+	a.True(p.Delete(event.DeleteEvent{Meta: selected, Object: selected}))
+	a.True(r.unwatched.has(key))
+}
+
+func TestNsWatchChanged(t *testing.T) {
+	newNs := func(watch string) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{}}}
+		if watch != "" {
+			ns.Labels[watchLabel] = watch
+		}
+		return ns
+	}
+
+	a := assert.New(t)
+	a.False(nsWatchChanged(event.UpdateEvent{ObjectOld: newNs(""), ObjectNew: newNs("")}, selection{}))
+	a.True(nsWatchChanged(event.UpdateEvent{ObjectOld: newNs(""), ObjectNew: newNs("enabled")}, selection{}))
+	a.False(nsWatchChanged(event.UpdateEvent{ObjectOld: newNs(""), ObjectNew: newNs("enabled")}, selection{watchByDefault: true}))
+	a.True(nsWatchChanged(event.UpdateEvent{ObjectOld: newNs("enabled"), ObjectNew: newNs("disabled")}, selection{watchByDefault: true}))
+
+	sel := selection{nsSelector: labels.SelectorFromSet(labels.Set{"env": "prod"})}
+	prodNs := newNs("")
+	prodNs.Labels["env"] = "prod"
+	a.True(nsWatchChanged(event.UpdateEvent{ObjectOld: newNs(""), ObjectNew: prodNs}, sel))
+}
+
+func TestMapNamespaceToServices(t *testing.T) {
+	r := &ServiceReconciler{
+		Log: zap.New(zap.UseDevMode(true)),
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme,
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "one", Namespace: "ns"}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "two", Namespace: "ns"}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "three", Namespace: "another"}},
+		),
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+
+	a := assert.New(t)
+	reqs := r.mapNamespaceToServices(handler.MapObject{Meta: ns, Object: ns})
+	if a.Len(reqs, 2) {
+		for _, req := range reqs {
+			a.Equal("ns", req.Namespace)
+			a.Contains([]string{"one", "two"}, req.Name)
+		}
+	}
+}
+
+func TestNsAnnotationsChanged(t *testing.T) {
+	r := &ServiceReconciler{AllowedNsAnnotations: []string{"cnwan.io/*"}}
+	newNs := func(watch string, annotations map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "ns",
+			Labels:      map[string]string{watchLabel: watch},
+			Annotations: annotations,
+		}}
+	}
+
+	a := assert.New(t)
+	a.False(r.nsAnnotationsChanged(event.UpdateEvent{
+		ObjectOld: newNs("enabled", map[string]string{"ignored": "one"}),
+		ObjectNew: newNs("enabled", map[string]string{"ignored": "two"}),
+	}))
+	a.True(r.nsAnnotationsChanged(event.UpdateEvent{
+		ObjectOld: newNs("enabled", map[string]string{"cnwan.io/site": "one"}),
+		ObjectNew: newNs("enabled", map[string]string{"cnwan.io/site": "two"}),
+	}))
+	a.True(r.nsAnnotationsChanged(event.UpdateEvent{
+		ObjectOld: newNs("enabled", nil),
+		ObjectNew: newNs("enabled", map[string]string{"cnwan.io/site": "two"}),
+	}))
+	a.False(r.nsAnnotationsChanged(event.UpdateEvent{
+		ObjectOld: newNs("disabled", map[string]string{"cnwan.io/site": "one"}),
+		ObjectNew: newNs("disabled", map[string]string{"cnwan.io/site": "two"}),
+	}))
+
+	// Defaults of the services don't need to be allowed
+	r.NsDefaultsPrefix = "defaults.cnwan.io"
+	a.True(r.nsAnnotationsChanged(event.UpdateEvent{
+		ObjectOld: newNs("enabled", map[string]string{"defaults.cnwan.io/traffic-profile": "one"}),
+		ObjectNew: newNs("enabled", map[string]string{"defaults.cnwan.io/traffic-profile": "two"}),
+	}))
+}
@@ -0,0 +1,252 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Resyncer periodically compares the objects owned by the operator in the
+// service registry with the current state of Kubernetes and repairs any
+// difference between the two, e.g. when the service registry has been
+// modified by someone else or when an event has been missed.
+//
+// It is meant to be added to a manager, so that it starts only after the
+// caches have been synced.
+type Resyncer struct {
+	// Reconciler is the service reconciler that is used to get the data
+	// that should be registered for each service.
+	Reconciler *ServiceReconciler
+	Log        logr.Logger
+	// Period is the time to wait between two resyncs.
+	Period time.Duration
+}
+
+// Start performs a resync every period until the stop channel is closed.
+func (r *Resyncer) Start(stop <-chan struct{}) error {
+	ctx, canc := context.WithCancel(context.Background())
+	defer canc()
+	go func() {
+		<-stop
+		canc()
+	}()
+
+	ticker := time.NewTicker(r.Period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			r.resyncAndReport(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection returns true, as only one instance of the operator
+// must write to the service registry.
+func (r *Resyncer) NeedLeaderElection() bool {
+	return true
+}
+
+func (r *Resyncer) resyncAndReport(ctx context.Context) {
+	l := r.Log.WithName("Resync")
+	l.V(1).Info("going to compare kubernetes with the service registry")
+
+	fixes, err := r.Reconciler.resync(ctx)
+	if err != nil {
+		l.Error(err, "error while comparing kubernetes with the service registry")
+	}
+
+	for _, fix := range fixes {
+		l.Info("repaired drift in service registry", "fix", fix.String())
+	}
+	l.Info("resync completed", "repaired", len(fixes))
+}
+
+// resync compares all the objects owned by the operator in the service
+// registry with the current state of Kubernetes and repairs differences in
+// both directions: objects that should be registered are created or
+// updated, and owned objects that should not be there are removed.
+//
+// Errors that only affect a single object are logged and do not stop the
+// resync. The returned fixes are the objects that have been repaired.
+func (r *ServiceReconciler) resync(ctx context.Context) ([]sr.DriftFix, error) {
+	if r.ServRegBroker == nil {
+		return nil, fmt.Errorf("%s", "service registry broker is nil")
+	}
+
+	// Load the service registry first, like the garbage collector does: a
+	// service registered by the reconciler while we're loading Kubernetes
+	// will not be among the owned ones, and will not be removed.
+	owned, err := r.listOwned(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, servList, err := r.listWatched(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fixes := []sr.DriftFix{}
+
+	// keep is the list of services, grouped by namespace, that must not be
+	// removed from the service registry.
+	keep := map[string]map[string]bool{}
+	for _, serv := range servList {
+		if serv.DeletionTimestamp != nil {
+			continue
+		}
+		l := r.Log.WithName("Resync").WithValues("service", serv.Namespace+"/"+serv.Name)
+
+		nsData, servData, endpList, err := r.buildServiceData(ctx, namespaces[serv.Namespace], serv)
+		if err != nil {
+			// We don't know how it should look like, so better leave it
+			// as it is.
+			l.Error(err, "error while getting data from the namespace and service")
+			keepServ(keep, serv.Namespace, serv.Name)
+			continue
+		}
+
+		if !shouldRegister(servData, endpList) {
+			continue
+		}
+
+		keepServ(keep, serv.Namespace, serv.Name)
+		servFixes, endpErrs, err := r.ServRegBroker.SyncServ(ctx, nsData, servData, endpList)
+		fixes = append(fixes, servFixes...)
+		if err == nil {
+			err = firstRetryableEndpErr(endpErrs)
+		}
+		if err != nil {
+			l.Error(err, "error while repairing service in service registry")
+		}
+	}
+
+	// Now remove everything that should not be there
+	return append(fixes, r.removeOrphans(ctx, owned, keep, namespaces)...), nil
+}
+
+// listOwned returns the services owned by the operator in the service
+// registry, grouped by the owned namespace they belong to.
+func (r *ServiceReconciler) listOwned(ctx context.Context) (map[string][]*sr.Service, error) {
+	ownedNs, err := r.ServRegBroker.ListOwnedNs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list namespaces from service registry: %w", err)
+	}
+
+	owned := map[string][]*sr.Service{}
+	for _, regNs := range ownedNs {
+		ownedServs, err := r.ServRegBroker.ListOwnedServ(ctx, regNs.Name)
+		if err != nil {
+			return nil, fmt.Errorf("could not list services of namespace %s from service registry: %w", regNs.Name, err)
+		}
+
+		owned[regNs.Name] = ownedServs
+	}
+
+	return owned, nil
+}
+
+// removeOrphans removes the owned services that are not included in keep,
+// and the owned namespaces that have been deleted or are not watched
+// anymore, as long as they are empty. Ownership is checked by RemoveServ and
+// RemoveNs.
+func (r *ServiceReconciler) removeOrphans(ctx context.Context, owned map[string][]*sr.Service, keep map[string]map[string]bool, namespaces map[string]*corev1.Namespace) []sr.DriftFix {
+	fixes := []sr.DriftFix{}
+
+	for nsName, ownedServs := range owned {
+		l := r.Log.WithValues("ns-name", nsName)
+
+		for _, regServ := range ownedServs {
+			if keep[nsName][regServ.Name] {
+				continue
+			}
+
+			if err := r.ServRegBroker.RemoveServ(ctx, nsName, regServ.Name, true); err != nil {
+				l.WithValues("serv-name", regServ.Name).Error(err, "error while removing service from service registry")
+				continue
+			}
+			fixes = append(fixes, sr.DriftFix{Action: sr.DriftDeleted, NsName: nsName, ServName: regServ.Name})
+		}
+
+		if ns, exists := namespaces[nsName]; (exists && r.selection().isNsWatched(ns)) || len(keep[nsName]) > 0 {
+			continue
+		}
+
+		// The namespace has been deleted or is not watched anymore.
+		err := r.ServRegBroker.RemoveNs(ctx, nsName, false)
+		switch err {
+		case nil:
+			fixes = append(fixes, sr.DriftFix{Action: sr.DriftDeleted, NsName: nsName})
+		case sr.ErrNsNotEmpty:
+			l.V(1).Info("namespace contains objects not owned by the operator and will not be removed")
+		default:
+			l.Error(err, "error while removing namespace from service registry")
+		}
+	}
+
+	return fixes
+}
+
+// listWatched returns all the namespaces, keyed by name, and the services
+// that should be registered, either because their namespace is watched or
+// because of their register annotation.
+func (r *ServiceReconciler) listWatched(ctx context.Context) (map[string]*corev1.Namespace, []*corev1.Service, error) {
+	var nsList corev1.NamespaceList
+	if err := r.List(ctx, &nsList); err != nil {
+		return nil, nil, fmt.Errorf("could not list namespaces: %w", err)
+	}
+
+	var servList corev1.ServiceList
+	if err := r.List(ctx, &servList); err != nil {
+		return nil, nil, fmt.Errorf("could not list services: %w", err)
+	}
+
+	namespaces := map[string]*corev1.Namespace{}
+	for i := range nsList.Items {
+		namespaces[nsList.Items[i].Name] = &nsList.Items[i]
+	}
+
+	servs := []*corev1.Service{}
+	for i := range servList.Items {
+		ns, exists := namespaces[servList.Items[i].Namespace]
+		if exists && r.selection().isServWatched(&servList.Items[i], ns) {
+			servs = append(servs, &servList.Items[i])
+		}
+	}
+
+	return namespaces, servs, nil
+}
+
+func keepServ(keep map[string]map[string]bool, nsName, servName string) {
+	if _, exists := keep[nsName]; !exists {
+		keep[nsName] = map[string]bool{}
+	}
+
+	keep[nsName][servName] = true
+}
@@ -0,0 +1,145 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry/convert"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestResync(t *testing.T) {
+	owned := map[string]string{"owner": "cnwan-operator"}
+	lbServ := func(ns, name string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Annotations: map[string]string{"cnwan.io/profile": "video"}},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: 80}},
+			},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "10.10.10.10"}}},
+			},
+		}
+	}
+
+	f := newFakeRegistry()
+	r := &ServiceReconciler{
+		Log: zap.New(zap.UseDevMode(true)),
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "watched", Labels: map[string]string{watchLabel: "enabled"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "not-watched"}},
+			lbServ("watched", "missing"),
+			lbServ("watched", "modified"),
+			lbServ("not-watched", "ignored"),
+		),
+		ServRegBroker:      newFakeBroker(f),
+		AllowedAnnotations: []string{"cnwan.io/*"},
+	}
+
+	modifiedEndp := convert.HashedName("modified", convert.Address{Address: "10.10.10.10", Port: 80})
+	f.ns["watched"] = &sr.Namespace{Name: "watched", Metadata: copyMetadata(owned)}
+	f.servs["watched/modified"] = &sr.Service{Name: "modified", NsName: "watched", Metadata: copyMetadata(owned)}
+	f.endps["watched/modified/"+modifiedEndp] = &sr.Endpoint{Name: modifiedEndp, NsName: "watched", ServName: "modified", Address: "10.10.10.10", Port: 8080, Metadata: copyMetadata(owned)}
+	f.servs["watched/orphan"] = &sr.Service{Name: "orphan", NsName: "watched", Metadata: copyMetadata(owned)}
+	f.servs["watched/not-owned"] = &sr.Service{Name: "not-owned", NsName: "watched", Metadata: map[string]string{}}
+	f.ns["not-watched"] = &sr.Namespace{Name: "not-watched", Metadata: copyMetadata(owned)}
+	f.ns["deleted"] = &sr.Namespace{Name: "deleted", Metadata: copyMetadata(owned)}
+	f.servs["deleted/orphan"] = &sr.Service{Name: "orphan", NsName: "deleted", Metadata: copyMetadata(owned)}
+	f.ns["someone-else"] = &sr.Namespace{Name: "someone-else", Metadata: map[string]string{}}
+
+	a := assert.New(t)
+	fixes, err := r.resync(context.Background())
+	a.NoError(err)
+	a.ElementsMatch([]sr.DriftFix{
+		{Action: sr.DriftCreated, NsName: "watched", ServName: "missing"},
+		{Action: sr.DriftCreated, NsName: "watched", ServName: "missing", EndpName: convert.HashedName("missing", convert.Address{Address: "10.10.10.10", Port: 80})},
+		{Action: sr.DriftUpdated, NsName: "watched", ServName: "modified"},
+		{Action: sr.DriftUpdated, NsName: "watched", ServName: "modified", EndpName: modifiedEndp},
+		{Action: sr.DriftDeleted, NsName: "watched", ServName: "orphan"},
+		{Action: sr.DriftDeleted, NsName: "not-watched"},
+		{Action: sr.DriftDeleted, NsName: "deleted", ServName: "orphan"},
+		{Action: sr.DriftDeleted, NsName: "deleted"},
+	}, fixes)
+
+	a.Contains(f.servs, "watched/not-owned")
+	a.Contains(f.ns, "someone-else")
+	a.Contains(f.ns, "watched")
+	a.NotContains(f.servs, "not-watched/ignored")
+
+	// Nothing should be left to repair
+	fixes, err = r.resync(context.Background())
+	a.NoError(err)
+	a.Empty(fixes)
+}
+
+// listHookClient calls afterList after services have been listed, e.g. to
+// register a service in the meantime.
+type listHookClient struct {
+	client.Client
+	afterList func()
+}
+
+func (c *listHookClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	err := c.Client.List(ctx, list, opts...)
+	if _, ok := list.(*corev1.ServiceList); ok && c.afterList != nil {
+		c.afterList()
+		c.afterList = nil
+	}
+	return err
+}
+
+func TestResyncServiceRegisteredMeanwhile(t *testing.T) {
+	owned := map[string]string{"owner": "cnwan-operator"}
+	f := newFakeRegistry()
+	f.ns["watched"] = &sr.Namespace{Name: "watched", Metadata: copyMetadata(owned)}
+
+	// The service is created and registered right after Kubernetes has
+	// been loaded, so resync doesn't know about it.
+	cli := &listHookClient{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "watched", Labels: map[string]string{watchLabel: "enabled"}}},
+		),
+		afterList: func() {
+			f.servs["watched/new"] = &sr.Service{Name: "new", NsName: "watched", Metadata: copyMetadata(owned)}
+		},
+	}
+	r := &ServiceReconciler{
+		Log:                zap.New(zap.UseDevMode(true)),
+		Client:             cli,
+		ServRegBroker:      newFakeBroker(f),
+		AllowedAnnotations: []string{"cnwan.io/*"},
+	}
+
+	a := assert.New(t)
+	fixes, err := r.resync(context.Background())
+	a.NoError(err)
+	a.Empty(fixes)
+	a.Contains(f.servs, "watched/new")
+}
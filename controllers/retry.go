@@ -0,0 +1,137 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+)
+
+const (
+	// retryBaseDelay is the time to wait before retrying a failed
+	// operation for the first time. It is doubled on every failure.
+	retryBaseDelay time.Duration = time.Second
+	// retryMaxDelay is the maximum time to wait before retrying a failed
+	// operation.
+	retryMaxDelay time.Duration = 5 * time.Minute
+)
+
+// newRetryRateLimiter returns the rate limiter used by the controllers to
+// requeue failed operations with exponential backoff.
+func newRetryRateLimiter() ratelimiter.RateLimiter {
+	return workqueue.NewItemExponentialFailureRateLimiter(retryBaseDelay, retryMaxDelay)
+}
+
+// retryTracker keeps track of the failed attempts to reflect an object to
+// the service registry.
+type retryTracker struct {
+	lock    sync.Mutex
+	entries map[types.NamespacedName]*retryEntry
+}
+
+type retryEntry struct {
+	retries int
+	lastErr error
+}
+
+func newRetryTracker() *retryTracker {
+	return &retryTracker{entries: map[types.NamespacedName]*retryEntry{}}
+}
+
+// failed records a failed attempt and returns the number of failed
+// attempts so far.
+func (t *retryTracker) failed(key types.NamespacedName, err error) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	entry, exists := t.entries[key]
+	if !exists {
+		entry = &retryEntry{}
+		t.entries[key] = entry
+	}
+
+	entry.retries++
+	entry.lastErr = err
+	return entry.retries
+}
+
+// forget removes all the failed attempts of the object and returns the
+// number of failed attempts and the last error occurred, if any.
+func (t *retryTracker) forget(key types.NamespacedName) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	entry, exists := t.entries[key]
+	if !exists {
+		return 0, nil
+	}
+
+	delete(t.entries, key)
+	return entry.retries, entry.lastErr
+}
+
+// handleResult returns the values that Reconcile must return after the
+// registry operations for the object completed with the provided error.
+//
+// Retryable errors are returned so that the object will be requeued with
+// exponential backoff, while permanent errors are only logged. When an
+// object that failed before succeeds or fails permanently, the number of
+// failed attempts and the last retryable error are logged as well.
+func (t *retryTracker) handleResult(l logr.Logger, key types.NamespacedName, err error) (ctrl.Result, error) {
+	if err != nil && sr.IsRetryable(err) {
+		retries := t.failed(key, err)
+		l.Error(err, "error while reflecting changes to the service registry, going to retry", "retries", retries)
+		return ctrl.Result{}, err
+	}
+
+	retries, lastErr := t.forget(key)
+	if retries > 0 {
+		l = l.WithValues("retries", retries, "last-retryable-error", lastErr.Error())
+	}
+
+	if err != nil {
+		l.Error(err, "error while reflecting changes to the service registry, will not retry")
+		return ctrl.Result{}, nil
+	}
+
+	if retries > 0 {
+		l.Info("changes reflected to the service registry after failed attempts")
+	}
+	return ctrl.Result{}, nil
+}
+
+// firstRetryableEndpErr returns the first retryable error among the ones
+// returned for the endpoints, or nil if there are none.
+func firstRetryableEndpErr(endpErrs map[string]error) error {
+	for endpName, err := range endpErrs {
+		if sr.IsRetryable(err) {
+			return fmt.Errorf("could not reflect endpoint %s: %w", endpName, err)
+		}
+	}
+
+	return nil
+}
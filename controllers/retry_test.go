@@ -0,0 +1,65 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"errors"
+	"testing"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestRetryTrackerHandleResult(t *testing.T) {
+	l := zap.New(zap.UseDevMode(true))
+	key := types.NamespacedName{Namespace: "ns", Name: "serv"}
+	tempErr := errors.New("connection refused")
+	tr := newRetryTracker()
+
+	a := assert.New(t)
+	_, err := tr.handleResult(l, key, tempErr)
+	a.Equal(tempErr, err)
+	_, err = tr.handleResult(l, key, tempErr)
+	a.Equal(tempErr, err)
+	a.Equal(&retryEntry{retries: 2, lastErr: tempErr}, tr.entries[key])
+
+	_, err = tr.handleResult(l, key, nil)
+	a.NoError(err)
+	a.NotContains(tr.entries, key)
+
+	tr.failed(key, tempErr)
+	_, err = tr.handleResult(l, key, sr.ErrServNotOwnedByOp)
+	a.NoError(err)
+	a.NotContains(tr.entries, key)
+
+	retries, lastErr := tr.forget(key)
+	a.Zero(retries)
+	a.NoError(lastErr)
+}
+
+func TestFirstRetryableEndpErr(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(firstRetryableEndpErr(map[string]error{}))
+	a.NoError(firstRetryableEndpErr(map[string]error{"one": sr.ErrEndpNotOwnedByOp}))
+
+	err := firstRetryableEndpErr(map[string]error{"one": sr.ErrEndpNotOwnedByOp, "two": sr.ErrTimeOutExpired})
+	a.True(errors.Is(err, sr.ErrTimeOutExpired))
+}
@@ -0,0 +1,102 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// selection contains the criteria used to decide which namespaces and
+// services should be registered.
+//
+// The watch label of a namespace and the register annotation of a service
+// always take precedence over the selectors.
+type selection struct {
+	// watchByDefault is used for namespaces without the watch label when
+	// nsSelector is nil.
+	watchByDefault bool
+	// nsSelector, if not nil, selects the namespaces to watch among those
+	// without the watch label.
+	nsSelector labels.Selector
+	// servSelector, if not nil, must be matched by services without the
+	// register annotation in a watched namespace.
+	servSelector labels.Selector
+}
+
+// isNsWatched returns whether the operator should watch the namespace,
+// according to its watch label or to the namespace selector, or the
+// default watch state in case neither is there.
+func (s selection) isNsWatched(ns *corev1.Namespace) bool {
+	switch strings.ToLower(ns.Labels[watchLabel]) {
+	case "enabled":
+		return true
+	case "disabled":
+		return false
+	default:
+		if s.nsSelector != nil {
+			return s.nsSelector.Matches(labels.Set(ns.Labels))
+		}
+
+		return s.watchByDefault
+	}
+}
+
+// isServWatched returns whether the operator should register the service,
+// according to its register annotation or, in case the service does not
+// have it, to whether its namespace is watched and it matches the service
+// selector.
+func (s selection) isServWatched(serv *corev1.Service, ns *corev1.Namespace) bool {
+	switch strings.ToLower(serv.Annotations[registerAnnotation]) {
+	case "enabled":
+		return true
+	case "disabled":
+		return false
+	default:
+		return s.isNsWatched(ns) && s.servSelected(serv)
+	}
+}
+
+// mayBeWatched returns whether the service may be watched, according to its
+// register annotation and the service selector. The namespace is not
+// checked, as services of namespaces that stop being watched are removed by
+// the NamespaceReconciler.
+func (s selection) mayBeWatched(serv *corev1.Service) bool {
+	switch strings.ToLower(serv.Annotations[registerAnnotation]) {
+	case "enabled":
+		return true
+	case "disabled":
+		return false
+	default:
+		return s.servSelected(serv)
+	}
+}
+
+// servSelected returns whether the service matches the service selector.
+func (s selection) servSelected(serv *corev1.Service) bool {
+	return s.servSelector == nil || s.servSelector.Matches(labels.Set(serv.Labels))
+}
+
+// defaultWatched returns whether a namespace is considered watched before
+// its labels are known.
+func (s selection) defaultWatched() bool {
+	return s.watchByDefault && s.nsSelector == nil
+}
@@ -0,0 +1,109 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestIsNsWatched(t *testing.T) {
+	sel, _ := labels.Parse("env in (prod,staging)")
+	newNs := func(watch, env string) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{}}}
+		if watch != "" {
+			ns.Labels[watchLabel] = watch
+		}
+		if env != "" {
+			ns.Labels["env"] = env
+		}
+		return ns
+	}
+
+	cases := []struct {
+		id     string
+		sel    selection
+		ns     *corev1.Namespace
+		expRes bool
+	}{
+		{id: "no-label", ns: newNs("", "")},
+		{id: "no-label-by-default", sel: selection{watchByDefault: true}, ns: newNs("", ""), expRes: true},
+		{id: "enabled", ns: newNs("Enabled", ""), expRes: true},
+		{id: "disabled-by-default", sel: selection{watchByDefault: true}, ns: newNs("disabled", "")},
+		{id: "selector-matches", sel: selection{nsSelector: sel}, ns: newNs("", "staging"), expRes: true},
+		{id: "selector-does-not-match", sel: selection{watchByDefault: true, nsSelector: sel}, ns: newNs("", "dev")},
+		{id: "label-wins-over-selector", sel: selection{nsSelector: sel}, ns: newNs("disabled", "prod")},
+		{id: "label-wins-over-selector-enabled", sel: selection{nsSelector: sel}, ns: newNs("enabled", "dev"), expRes: true},
+	}
+
+	a := assert.New(t)
+	for _, currCase := range cases {
+		a.Equal(currCase.expRes, currCase.sel.isNsWatched(currCase.ns), "case %s failed", currCase.id)
+	}
+}
+
+func TestIsServWatched(t *testing.T) {
+	sel, _ := labels.Parse("tier=frontend")
+	newNs := func(watch string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{watchLabel: watch}}}
+	}
+	newServ := func(register, tier string) *corev1.Service {
+		serv := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:        "serv",
+			Namespace:   "ns",
+			Annotations: map[string]string{},
+			Labels:      map[string]string{},
+		}}
+		if register != "" {
+			serv.Annotations[registerAnnotation] = register
+		}
+		if tier != "" {
+			serv.Labels["tier"] = tier
+		}
+		return serv
+	}
+
+	cases := []struct {
+		id     string
+		sel    selection
+		serv   *corev1.Service
+		ns     *corev1.Namespace
+		expRes bool
+	}{
+		{id: "ns-watched", serv: newServ("", ""), ns: newNs("enabled"), expRes: true},
+		{id: "ns-not-watched", sel: selection{watchByDefault: true}, serv: newServ("", ""), ns: newNs("disabled")},
+		{id: "ns-watched-by-default", sel: selection{watchByDefault: true}, serv: newServ("", ""), ns: newNs(""), expRes: true},
+		{id: "opt-out", serv: newServ("disabled", ""), ns: newNs("enabled")},
+		{id: "opt-in", serv: newServ("Enabled", ""), ns: newNs("disabled"), expRes: true},
+		{id: "invalid-value", serv: newServ("yes", ""), ns: newNs("disabled")},
+		{id: "selector-matches", sel: selection{servSelector: sel}, serv: newServ("", "frontend"), ns: newNs("enabled"), expRes: true},
+		{id: "selector-does-not-match", sel: selection{servSelector: sel}, serv: newServ("", "backend"), ns: newNs("enabled")},
+		{id: "selector-ns-not-watched", sel: selection{servSelector: sel}, serv: newServ("", "frontend"), ns: newNs("disabled")},
+		{id: "opt-in-wins-over-selector", sel: selection{servSelector: sel}, serv: newServ("enabled", "backend"), ns: newNs("enabled"), expRes: true},
+	}
+
+	a := assert.New(t)
+	for _, currCase := range cases {
+		a.Equal(currCase.expRes, currCase.sel.isServWatched(currCase.serv, currCase.ns), "case %s failed", currCase.id)
+	}
+}
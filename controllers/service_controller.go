@@ -22,15 +22,35 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry/convert"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// registerAnnotation can be set to enabled or disabled on a service to
+	// override the watch state of its namespace.
+	registerAnnotation string = "operator.cnwan.io/register"
+	// reconcileTimeout is the maximum time a single reconcile can take,
+	// including the requests sent to the service registry.
+	reconcileTimeout time.Duration = 2 * time.Minute
 )
 
 // ServiceReconciler reconciles a Service object
@@ -40,15 +60,91 @@ type ServiceReconciler struct {
 	Scheme                   *runtime.Scheme
 	ServRegBroker            *sr.Broker
 	WatchNamespacesByDefault bool
-	AllowedAnnotations       []string
+	// NamespaceSelector, if not nil, selects the namespaces to watch among
+	// those that don't have the watch label, instead of
+	// WatchNamespacesByDefault.
+	NamespaceSelector labels.Selector
+	// ServiceSelector, if not nil, must be matched by services in watched
+	// namespaces to be registered, unless they have the register
+	// annotation.
+	ServiceSelector    labels.Selector
+	AllowedAnnotations []string
+	// DeniedAnnotations is the list of service annotations that are never
+	// registered, even if they are allowed by AllowedAnnotations.
+	DeniedAnnotations []string
+	// AllowedLabels is the list of service labels that are registered as
+	// metadata of the service, in the same format as AllowedAnnotations.
+	// Annotations win over labels with the same key.
+	AllowedLabels []string
+	// AllowedNsAnnotations is the list of namespace annotations that are
+	// registered as metadata of the namespace.
+	AllowedNsAnnotations []string
+	// DeniedNsAnnotations is the list of namespace annotations that are
+	// never registered, even if they are allowed by AllowedNsAnnotations.
+	DeniedNsAnnotations []string
+	// AllowedEndpointMetadata is the list of metadata about the port and
+	// the address of endpoints, e.g. cnwan.io/protocol, that are registered
+	// as metadata of endpoints.
+	AllowedEndpointMetadata []string
+	// EndpointAnnotationPrefix, if not empty, is the prefix of the service
+	// annotations that are registered as metadata of the endpoints of one
+	// of its ports, e.g. endpoint.cnwan.io/<port-name>.<key>, instead of
+	// the service. They don't need to be allowed by AllowedAnnotations.
+	EndpointAnnotationPrefix string
+	// NsDefaultsPrefix, if not empty, is the prefix of the namespace
+	// annotations that are default metadata of its services, e.g.
+	// defaults.cnwan.io/<key>, instead of the namespace. They don't need to
+	// be allowed by AllowedNsAnnotations.
+	NsDefaultsPrefix string
+	// MetadataRules transform, in order, the metadata of services after
+	// their annotations have been filtered.
+	MetadataRules []convert.MetadataRule
+	// NodePort contains options about NodePort services. If nil, services
+	// of type NodePort are not registered with the addresses of the nodes.
+	NodePort *NodePortOptions
+	// RegisterClusterIP specifies whether ClusterIP and headless services
+	// should be registered with the addresses of their ready endpoints,
+	// taken from their EndpointSlices.
+	RegisterClusterIP bool
+	// LoadBalancerHostname contains options about load balancer ingresses
+	// that have a hostname but no IP. If nil, they are ignored.
+	LoadBalancerHostname *HostnameOptions
+	// Naming gives a name to the endpoints of services. If nil,
+	// convert.SafeNaming is used with the limits of the service registry.
+	Naming convert.NamingStrategy
+	// StatusAnnotations, if not nil, makes the operator write the
+	// registration status of services as annotations on them.
+	StatusAnnotations *StatusOptions
+	// UseFinalizer specifies whether registered services and namespaces
+	// should have a finalizer, so that they are removed from the service
+	// registry before they are deleted from Kubernetes.
+	UseFinalizer bool
+	// Recorder records events on services and namespaces about their
+	// registration. If nil, no events are recorded.
+	Recorder record.EventRecorder
+	// MaxConcurrentReconciles is the maximum number of services that can
+	// be reconciled at the same time. Defaults to 1.
+	MaxConcurrentReconciles int
+
+	retries   *retryTracker
+	unwatched *unwatchedTracker
+
+	// skippedNsKeys contains, for each namespace, the metadata keys that
+	// were skipped the last time one of its services was reconciled.
+	skippedNsKeys map[string]string
+	lock          sync.Mutex
 }
 
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
 
 // Reconcile checks the changes in a service and reflects those changes in the service registry
 func (r *ServiceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
-	ctx := context.Background()
+	ctx, canc := context.WithTimeout(context.Background(), reconcileTimeout)
+	defer canc()
 	l := r.Log.WithValues("service", req.NamespacedName)
 	deleted := false
 
@@ -76,6 +172,16 @@ func (r *ServiceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, nil
 	}
 
+	if !deleted && service.DeletionTimestamp != nil {
+		if controllerutil.ContainsFinalizer(&service, deregisterFinalizer) {
+			return r.deregister(ctx, l, req.NamespacedName, &service)
+		}
+
+		// The service will be gone soon, so there is no point in updating
+		// it: just remove it from the service registry.
+		deleted = true
+	}
+
 	// Get the namespace
 	var ns corev1.Namespace
 	if err := r.Get(ctx, types.NamespacedName{Name: service.Namespace}, &ns); err != nil {
@@ -83,60 +189,271 @@ func (r *ServiceReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 
-	var shouldWatchNs bool
-	switch strings.ToLower(ns.Labels[watchLabel]) {
-	case "enabled":
-		shouldWatchNs = true
-	case "disabled":
-		shouldWatchNs = false
+	servWatched := !deleted && r.selection().isServWatched(&service, &ns)
+	if servWatched {
+		nsData, servData, endpList, err := r.buildServiceData(ctx, &ns, &service)
+		if err != nil {
+			l.Error(err, "error while getting data from the namespace and service")
+			r.reportError(ctx, l, &service, err)
+			return ctrl.Result{}, err
+		}
+
+		if shouldRegister(servData, endpList) {
+			if r.UseFinalizer {
+				// Add the finalizers before registering anything, so that
+				// nothing is left on the service registry if they are
+				// deleted right after that.
+				if err := addFinalizer(ctx, r, &service); err != nil {
+					l.Error(err, "could not add finalizer to service")
+					return ctrl.Result{}, err
+				}
+				if err := addFinalizer(ctx, r, &ns); err != nil {
+					l.Error(err, "could not add finalizer to namespace")
+					return ctrl.Result{}, err
+				}
+			}
+
+			// Namespace metadata that are not supported are skipped by
+			// SyncServ, rather than failing all the services of the
+			// namespace, so let users know about them.
+			if keys := r.ServRegBroker.Capabilities().NsMetadataLimits.UnsupportedKeys(nsData.Metadata); r.skippedNsKeysChanged(ns.Name, keys) {
+				r.events().unsupportedMetadata(&ns, keys)
+			}
+
+			// SyncServ tells us what changed, so that we can record it on
+			// the service.
+			fixes, endpErrs, err := r.ServRegBroker.SyncServ(ctx, nsData, servData, endpList)
+			r.events().syncResult(&ns, &service, fixes, endpErrs, err)
+			if err == nil {
+				err = firstRetryableEndpErr(endpErrs)
+			}
+			if err != nil {
+				l = l.WithValues("serv-name", servData.Name)
+				r.reportError(ctx, l, &service, err)
+				return r.retries.handleResult(l, req.NamespacedName, fmt.Errorf("could not process service: %w", err))
+			}
+
+			if r.StatusAnnotations != nil {
+				r.reportStatus(ctx, l, &service, r.registeredStatus(ctx, &service, servData, endpList, fixes, endpErrs))
+			}
+			res, err := r.retries.handleResult(l, req.NamespacedName, nil)
+			if r.needsHostnameRefresh(&service) {
+				// Resolve the hostnames again later, in case their DNS
+				// records change.
+				res.RequeueAfter = r.LoadBalancerHostname.RefreshInterval
+			}
+			return res, err
+		}
+	}
+
+	// Services that are not watched are only removed if they may have been
+	// registered, so that the service registry is not read for all of them,
+	// e.g. when the operator starts. The others, e.g. the ones that stopped
+	// being watched while the operator was down, are left to the garbage
+	// collection and the resync. DeregisterServ never removes services that are
+	// not owned by the operator.
+	if !servWatched && !r.mayBeRegistered(req.NamespacedName, &service) {
+		return r.retries.handleResult(l, req.NamespacedName, nil)
+	}
+
+	removed, err := r.ServRegBroker.DeregisterServ(ctx, ns.Name, service.Name)
+	if err == nil || !sr.IsRetryable(err) {
+		r.unwatched.forget(req.NamespacedName)
+	}
+	if err != nil {
+		l = l.WithValues("serv-name", service.Name)
+		if !deleted {
+			r.events().registryError(&service, err)
+			r.reportError(ctx, l, &service, err)
+		}
+		return r.retries.handleResult(l, req.NamespacedName, fmt.Errorf("could not process service deletion: %w", err))
+	}
+	if removed && !deleted {
+		r.events().normal(&service, EventReasonDeregistered, "service removed from service registry")
+	}
+	if !deleted {
+		r.cleanUp(ctx, l, &service)
+	}
+
+	return r.retries.handleResult(l, req.NamespacedName, nil)
+}
+
+// deregister removes a service that is being deleted from the service
+// registry and then removes its finalizer, so that Kubernetes can delete it.
+//
+// The finalizer is also removed in case of errors that would occur again if
+// retried, e.g. because the service is not owned by the operator, as they
+// would prevent the service from being deleted forever.
+func (r *ServiceReconciler) deregister(ctx context.Context, l logr.Logger, key types.NamespacedName, serv *corev1.Service) (ctrl.Result, error) {
+	err := r.ServRegBroker.RemoveServ(ctx, serv.Namespace, serv.Name, true)
+	switch {
+	case err == nil:
+		r.events().normal(serv, EventReasonDeregistered, "service removed from service registry")
+	case sr.IsRetryable(err):
+		r.events().registryError(serv, err)
+		return r.retries.handleResult(l, key, fmt.Errorf("could not process service deletion: %w", err))
 	default:
-		shouldWatchNs = r.WatchNamespacesByDefault
+		r.events().registryError(serv, err)
 	}
 
-	if !shouldWatchNs {
-		l.V(1).Info("ignoring service as namespace is not in the allow list")
-		return ctrl.Result{}, nil
+	if err := removeFinalizer(ctx, r, serv); err != nil {
+		l.Error(err, "could not remove finalizer from service")
+		return ctrl.Result{}, err
 	}
 
-	// Get the data in our simpler format
-	// Note: as of now, we are not copying any annotations from a namespace
-	service.Annotations = filterAnnotations(service.Annotations, r.AllowedAnnotations)
-	nsData, servData, endpList, err := r.ServRegBroker.Reg.ExtractData(&ns, &service)
 	if err != nil {
-		l.Error(err, "error while getting data from the namespace and service")
-		return ctrl.Result{}, nil
+		err = fmt.Errorf("could not process service deletion: %w", err)
 	}
+	return r.retries.handleResult(l, key, err)
+}
 
-	// We don't support metadata on namespaces right now
-	nsData.Metadata = map[string]string{}
+// cleanUp removes the status annotations and the finalizer from a service
+// that is not registered anymore.
+func (r *ServiceReconciler) cleanUp(ctx context.Context, l logr.Logger, serv *corev1.Service) {
+	r.reportStatus(ctx, l, serv, nil)
+	if err := removeFinalizer(ctx, r, serv); err != nil {
+		l.Error(err, "could not remove finalizer from service")
+	}
+}
 
-	if !deleted && len(endpList) > 0 && len(servData.Metadata) > 0 {
-		if _, err := r.ServRegBroker.ManageNs(nsData); err != nil {
-			l.WithValues("ns-name", nsData.Name).Error(err, "an error occurred while processing the namespace")
-			return ctrl.Result{}, nil
-		}
-		if _, err := r.ServRegBroker.ManageServ(servData); err != nil {
-			l.WithValues("serv-name", nsData.Name).Error(err, "an error occurred while processing the service")
-			return ctrl.Result{}, nil
-		}
-		if _, err := r.ServRegBroker.ManageServEndps(nsData.Name, servData.Name, endpList); err != nil {
-			l.WithValues("serv-name", nsData.Name).Error(err, "an error occurred while processing service's endpoints")
-			return ctrl.Result{}, nil
-		}
-		return ctrl.Result{}, nil
+func (r *ServiceReconciler) selection() selection {
+	return selection{
+		watchByDefault: r.WatchNamespacesByDefault,
+		nsSelector:     r.NamespaceSelector,
+		servSelector:   r.ServiceSelector,
 	}
+}
 
-	if err := r.ServRegBroker.RemoveServ(ns.Name, service.Name, true); err != nil {
-		l.WithValues("serv-name", nsData.Name).Error(err, "an error occurred while processing service deletion")
-		return ctrl.Result{}, nil
+func (r *ServiceReconciler) events() eventRecorder {
+	return eventRecorder{r.Recorder}
+}
+
+// skippedNsKeysChanged records the metadata keys of the namespace that are
+// skipped and returns whether they changed since the last time, so that
+// they are not reported every time a service of the namespace is
+// reconciled.
+func (r *ServiceReconciler) skippedNsKeysChanged(nsName string, keys []string) bool {
+	joined := strings.Join(keys, ",")
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.skippedNsKeys[nsName] == joined {
+		return false
+	}
+
+	if r.skippedNsKeys == nil {
+		r.skippedNsKeys = map[string]string{}
+	}
+	if joined == "" {
+		delete(r.skippedNsKeys, nsName)
+	} else {
+		r.skippedNsKeys[nsName] = joined
+	}
+	return true
+}
+
+// buildServiceData returns the namespace, service and endpoints as they
+// should appear in the service registry.
+func (r *ServiceReconciler) buildServiceData(ctx context.Context, ns *corev1.Namespace, service *corev1.Service) (*sr.Namespace, *sr.Service, []*sr.Endpoint, error) {
+	namespace := ns.DeepCopy()
+	namespace.Annotations = filterAnnotations(namespace.Annotations, r.allowedNsAnnotations(), r.DeniedNsAnnotations)
+	serv := service.DeepCopy()
+	serv.Annotations = filterAnnotations(serv.Annotations, r.allowedServAnnotations(), r.DeniedAnnotations)
+	serv.Labels = filterAnnotations(serv.Labels, r.AllowedLabels, nil)
+
+	return r.converter().Convert(ctx, namespace, serv)
+}
+
+// converter returns the Converter that builds the data of services, with
+// the sources of addresses that are enabled in the options.
+func (r *ServiceReconciler) converter() *convert.Converter {
+	sources := []convert.AddressSource{convert.ExternalIPs(), convert.LoadBalancerIPs()}
+	if r.NodePort != nil {
+		sources = append(sources, convert.NodePorts(r, r.NodePort.NodeSelector, r.NodePort.AddressTypes))
+	}
+	if r.RegisterClusterIP {
+		sources = append(sources, convert.EndpointSlices(r))
+	}
+	if r.LoadBalancerHostname != nil {
+		sources = append(sources, convert.LoadBalancerHostnames(r.LoadBalancerHostname.Resolve, r.LoadBalancerHostname.Resolver))
+	}
+
+	naming := r.Naming
+	if naming == nil {
+		naming = convert.NewSafeNaming(r.ServRegBroker.Capabilities())
 	}
 
-	return ctrl.Result{}, nil
+	return &convert.Converter{
+		Sources:                  sources,
+		Naming:                   naming,
+		PortMetadata:             filterKeys(convert.PortMetadataKeys, r.AllowedEndpointMetadata),
+		EndpointAnnotationPrefix: r.EndpointAnnotationPrefix,
+		NsDefaultsPrefix:         r.NsDefaultsPrefix,
+		MetadataRules:            r.MetadataRules,
+	}
+}
+
+// allowedServAnnotations returns the filter of the annotations of services,
+// including the ones meant for their endpoints.
+func (r *ServiceReconciler) allowedServAnnotations() []string {
+	if r.EndpointAnnotationPrefix == "" {
+		return r.AllowedAnnotations
+	}
+
+	return append([]string{r.EndpointAnnotationPrefix + "/*"}, r.AllowedAnnotations...)
+}
+
+// allowedNsAnnotations returns the filter of the annotations of
+// namespaces, including the ones meant for their services.
+func (r *ServiceReconciler) allowedNsAnnotations() []string {
+	if r.NsDefaultsPrefix == "" {
+		return r.AllowedNsAnnotations
+	}
+
+	return append([]string{r.NsDefaultsPrefix + "/*"}, r.AllowedNsAnnotations...)
+}
+
+// needsHostnameRefresh returns whether the service must be reconciled
+// periodically because it is registered with resolved hostnames.
+func (r *ServiceReconciler) needsHostnameRefresh(serv *corev1.Service) bool {
+	return r.LoadBalancerHostname != nil && r.LoadBalancerHostname.Resolve &&
+		r.LoadBalancerHostname.RefreshInterval > 0 &&
+		serv.Spec.Type == corev1.ServiceTypeLoadBalancer &&
+		len(convert.IngressHostnames(serv)) > 0
+}
+
+// shouldRegister returns whether the service has enough data to be
+// registered.
+func shouldRegister(servData *sr.Service, endpList []*sr.Endpoint) bool {
+	return len(endpList) > 0 && len(servData.Metadata) > 0
 }
 
 // SetupWithManager ...
 func (r *ServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Service{}).
-		Complete(r)
+	r.retries = newRetryTracker()
+	r.unwatched = newUnwatchedTracker()
+
+	bldr := ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+			RateLimiter:             newRetryRateLimiter(),
+		}).
+		For(&corev1.Service{}, builder.WithPredicates(r.servicePredicate())).
+		Watches(&source.Kind{Type: &corev1.Namespace{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.mapNamespaceToServices)},
+			builder.WithPredicates(r.namespacePredicate()))
+
+	if r.NodePort != nil {
+		bldr = bldr.Watches(&source.Kind{Type: &corev1.Node{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.mapNodeToServices)},
+			builder.WithPredicates(r.nodePredicate()))
+	}
+
+	if r.RegisterClusterIP {
+		bldr = bldr.Watches(&source.Kind{Type: &discoveryv1beta1.EndpointSlice{}},
+			&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(mapEndpointSliceToService)})
+	}
+
+	return bldr.Complete(r)
 }
@@ -0,0 +1,234 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestBuildServiceData(t *testing.T) {
+	r := &ServiceReconciler{
+		ServRegBroker:        newFakeBroker(newFakeRegistry()),
+		AllowedAnnotations:   []string{"cnwan.io/profile"},
+		AllowedNsAnnotations: []string{"cnwan.io/*"},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "ns",
+		Annotations: map[string]string{"cnwan.io/site": "milan", "ignored": "yes"},
+	}}
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "serv",
+			Namespace:   "ns",
+			Annotations: map[string]string{"cnwan.io/profile": "video", "cnwan.io/other": "no"},
+		},
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Ports: []corev1.ServicePort{{Port: 80}}},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "10.10.10.10"}}},
+		},
+	}
+
+	a := assert.New(t)
+	nsData, servData, endpList, err := r.buildServiceData(context.Background(), ns, serv)
+	a.NoError(err)
+	a.Equal(map[string]string{"cnwan.io/site": "milan"}, nsData.Metadata)
+	a.Equal(map[string]string{"cnwan.io/profile": "video"}, servData.Metadata)
+	a.Len(endpList, 1)
+	a.True(shouldRegister(servData, endpList))
+
+	// The original objects must not be modified
+	a.Len(ns.Annotations, 2)
+	a.Len(serv.Annotations, 2)
+}
+
+func TestOptOutAfterRestart(t *testing.T) {
+	key := types.NamespacedName{Namespace: "ns", Name: "serv"}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+	newServ := func(register string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        key.Name,
+				Namespace:   key.Namespace,
+				Annotations: map[string]string{"cnwan.io/profile": "video", registerAnnotation: register},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: 80}},
+			},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "10.10.10.10"}}},
+			},
+		}
+	}
+	newReconciler := func(f *fakeRegistry, serv *corev1.Service) *ServiceReconciler {
+		return &ServiceReconciler{
+			Log:                zap.New(zap.UseDevMode(true)),
+			Client:             fake.NewFakeClientWithScheme(scheme.Scheme, ns.DeepCopy(), serv),
+			ServRegBroker:      newFakeBroker(f),
+			AllowedAnnotations: []string{"cnwan.io/*"},
+			Recorder:           record.NewFakeRecorder(10),
+			retries:            newRetryTracker(),
+			unwatched:          newUnwatchedTracker(),
+		}
+	}
+
+	a := assert.New(t)
+
+	// The service is registered because of its annotation
+	f := newFakeRegistry()
+	_, err := newReconciler(f, newServ("enabled")).Reconcile(ctrl.Request{NamespacedName: key})
+	a.NoError(err)
+	a.Contains(f.servs, "ns/serv")
+
+	// The annotation is changed while the operator is down: this is left
+	// to the garbage collection, without reading the service registry
+	r := newReconciler(f, newServ("disabled"))
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+	a.NoError(err)
+	a.Contains(f.servs, "ns/serv")
+	a.Empty(recordedEvents(r.Recorder.(*record.FakeRecorder)))
+
+	// The operator restarts and then the annotation is changed
+	r = newReconciler(f, newServ("disabled"))
+	r.servicePredicate().Update(event.UpdateEvent{ObjectOld: newServ("enabled"), ObjectNew: newServ("disabled")})
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+	a.NoError(err)
+	a.NotContains(f.servs, "ns/serv")
+	a.False(r.unwatched.has(key))
+	a.Equal([]string{"Normal Deregistered service removed from service registry"}, recordedEvents(r.Recorder.(*record.FakeRecorder)))
+
+	// Services that were never registered are left alone
+	r = newReconciler(f, newServ(""))
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+	a.NoError(err)
+	a.Empty(recordedEvents(r.Recorder.(*record.FakeRecorder)))
+}
+
+func TestBuildServiceDataNsDefaults(t *testing.T) {
+	r := &ServiceReconciler{
+		ServRegBroker:      newFakeBroker(newFakeRegistry()),
+		AllowedAnnotations: []string{"cnwan.io/*"},
+		NsDefaultsPrefix:   "defaults.cnwan.io",
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name: "ns",
+		Annotations: map[string]string{
+			"defaults.cnwan.io/traffic-profile": "standard",
+			"defaults.cnwan.io/site":            "milan",
+		},
+	}}
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "serv",
+			Namespace:   "ns",
+			Annotations: map[string]string{"cnwan.io/traffic-profile": "video"},
+		},
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Ports: []corev1.ServicePort{{Port: 80}}},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "10.10.10.10"}}},
+		},
+	}
+
+	a := assert.New(t)
+	nsData, servData, _, err := r.buildServiceData(context.Background(), ns, serv)
+	a.NoError(err)
+	a.Empty(nsData.Metadata)
+	a.Equal(map[string]string{"cnwan.io/traffic-profile": "video", "site": "milan"}, servData.Metadata)
+}
+
+// capsRegistry is a fakeRegistry with limited capabilities.
+type capsRegistry struct {
+	*fakeRegistry
+	caps sr.Capabilities
+}
+
+func (c *capsRegistry) Capabilities() sr.Capabilities {
+	return c.caps
+}
+
+func TestUnsupportedNsMetadata(t *testing.T) {
+	key := types.NamespacedName{Namespace: "ns", Name: "serv"}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "ns",
+		Labels:      map[string]string{watchLabel: "enabled"},
+		Annotations: map[string]string{"site": "milan", "example.com/site": "milan"},
+	}}
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Annotations: map[string]string{"cnwan.io/profile": "video"}},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{{Port: 80}},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "10.10.10.10"}}},
+		},
+	}
+	f := newFakeRegistry()
+	b, _ := sr.NewBroker(&capsRegistry{
+		fakeRegistry: f,
+		caps: sr.Capabilities{
+			NsMetadata:          true,
+			NsMetadataLimits:    sr.MetadataLimits{KeyPattern: regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)},
+			EndpMetadata:        true,
+			TransactionalWrites: true,
+		},
+	}, sr.MetadataPair{})
+	r := &ServiceReconciler{
+		Log:                  zap.New(zap.UseDevMode(true)),
+		Client:               fake.NewFakeClientWithScheme(scheme.Scheme, ns, serv),
+		ServRegBroker:        b,
+		AllowedAnnotations:   []string{"cnwan.io/*"},
+		AllowedNsAnnotations: []string{"site", "example.com/*"},
+		Recorder:             record.NewFakeRecorder(10),
+		retries:              newRetryTracker(),
+	}
+
+	a := assert.New(t)
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: key})
+	a.NoError(err)
+	a.Contains(f.servs, "ns/serv")
+	a.Equal("milan", f.ns["ns"].Metadata["site"])
+	a.NotContains(f.ns["ns"].Metadata, "example.com/site")
+	a.Contains(recordedEvents(r.Recorder.(*record.FakeRecorder)), "Warning UnsupportedMetadata metadata not supported by the service registry have been skipped: example.com/site")
+
+	// The event is not recorded again until the skipped keys change
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+	a.NoError(err)
+	a.Empty(recordedEvents(r.Recorder.(*record.FakeRecorder)))
+
+	ns.Annotations["example.com/zone"] = "north"
+	a.NoError(r.Update(context.Background(), ns))
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: key})
+	a.NoError(err)
+	a.Contains(recordedEvents(r.Recorder.(*record.FakeRecorder)), "Warning UnsupportedMetadata metadata not supported by the service registry have been skipped: example.com/site, example.com/zone")
+}
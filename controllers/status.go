@@ -0,0 +1,199 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// statusAnnotationPrefix is the prefix of the annotations written by
+	// the operator on services to report their registration status.
+	statusAnnotationPrefix string = "status.operator.cnwan.io/"
+
+	statusRegistryAnnotation   string = statusAnnotationPrefix + "registry"
+	statusNamespaceAnnotation  string = statusAnnotationPrefix + "namespace"
+	statusServiceAnnotation    string = statusAnnotationPrefix + "service"
+	statusEndpointsAnnotation  string = statusAnnotationPrefix + "endpoints"
+	statusLastChangeAnnotation string = statusAnnotationPrefix + "last-change"
+	statusLastErrorAnnotation  string = statusAnnotationPrefix + "last-error"
+	statusResourceIDAnnotation string = statusAnnotationPrefix + "resource-id"
+)
+
+// StatusOptions contains options about the annotations that the operator
+// writes on services to report their registration status.
+type StatusOptions struct {
+	// RegistryType is the type of the service registry, e.g. "etcd".
+	RegistryType string
+}
+
+// registeredStatus returns the status annotations of a service that has
+// been reflected to the service registry, with the fixes returned by
+// SyncServ.
+//
+// The resource ID is only included if the service registry provides one,
+// and it is only loaded if the service has just been created or if it is not
+// known yet. The last change time only changes if something else changed, so
+// that the service is not patched on every reconcile.
+func (r *ServiceReconciler) registeredStatus(ctx context.Context, serv *corev1.Service, servData *sr.Service, endpList []*sr.Endpoint, fixes []sr.DriftFix, endpErrs map[string]error) map[string]string {
+	endps := 0
+	for _, endp := range endpList {
+		if _, failed := endpErrs[endp.Name]; !failed {
+			endps++
+		}
+	}
+
+	status := map[string]string{
+		statusRegistryAnnotation:  r.StatusAnnotations.RegistryType,
+		statusNamespaceAnnotation: servData.NsName,
+		statusServiceAnnotation:   servData.Name,
+		statusEndpointsAnnotation: strconv.Itoa(endps),
+	}
+
+	if idGetter, ok := r.ServRegBroker.Reg.(sr.ServiceIDGetter); ok {
+		id, known := serv.Annotations[statusResourceIDAnnotation]
+		if !known || servCreated(fixes) {
+			var err error
+			id, err = idGetter.GetServID(ctx, servData.NsName, servData.Name)
+			if err != nil {
+				r.Log.WithValues("service", servData.NsName+"/"+servData.Name).V(1).Info("could not get ID of service from service registry", "error", err.Error())
+			}
+		}
+		if id != "" {
+			status[statusResourceIDAnnotation] = id
+		}
+	}
+
+	lastChange, known := serv.Annotations[statusLastChangeAnnotation]
+	if !known || len(fixes) > 0 || statusChanged(serv.Annotations, status) {
+		lastChange = time.Now().UTC().Format(time.RFC3339)
+	}
+	status[statusLastChangeAnnotation] = lastChange
+
+	return status
+}
+
+// servCreated returns whether the service has been created, according to
+// the fixes returned by SyncServ.
+func servCreated(fixes []sr.DriftFix) bool {
+	for _, fix := range fixes {
+		if fix.ServName != "" && fix.EndpName == "" && fix.Action == sr.DriftCreated {
+			return true
+		}
+	}
+
+	return false
+}
+
+// statusChanged returns whether the status annotations differ from the
+// provided status, other than for the last change time.
+func statusChanged(annotations, status map[string]string) bool {
+	for key, val := range annotations {
+		if key == statusLastChangeAnnotation || !strings.HasPrefix(key, statusAnnotationPrefix) {
+			continue
+		}
+
+		if newVal, exists := status[key]; !exists || newVal != val {
+			return true
+		}
+	}
+
+	for key, val := range status {
+		if annotations[key] != val {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeStatus replaces the status annotations of the service with the
+// provided ones. Nothing is done if status annotations are disabled or if
+// they are already up to date.
+//
+// A nil status removes all status annotations.
+func (r *ServiceReconciler) writeStatus(ctx context.Context, serv *corev1.Service, status map[string]string) error {
+	if r.StatusAnnotations == nil {
+		return nil
+	}
+
+	patched := serv.DeepCopy()
+	if patched.Annotations == nil {
+		patched.Annotations = map[string]string{}
+	}
+
+	changed := false
+	for key := range patched.Annotations {
+		if _, exists := status[key]; !exists && strings.HasPrefix(key, statusAnnotationPrefix) {
+			delete(patched.Annotations, key)
+			changed = true
+		}
+	}
+	for key, val := range status {
+		if patched.Annotations[key] != val {
+			patched.Annotations[key] = val
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return r.Patch(ctx, patched, client.MergeFrom(serv))
+}
+
+// writeErrorStatus reports the error on the service, leaving the other
+// status annotations as they are.
+func (r *ServiceReconciler) writeErrorStatus(ctx context.Context, serv *corev1.Service, err error) error {
+	status := map[string]string{}
+	for key, val := range serv.Annotations {
+		if strings.HasPrefix(key, statusAnnotationPrefix) {
+			status[key] = val
+		}
+	}
+	status[statusLastErrorAnnotation] = err.Error()
+
+	return r.writeStatus(ctx, serv, status)
+}
+
+// reportStatus writes the status annotations on the service, logging any
+// error: failing to do so must not prevent the service from being
+// registered.
+func (r *ServiceReconciler) reportStatus(ctx context.Context, l logr.Logger, serv *corev1.Service, status map[string]string) {
+	if err := r.writeStatus(ctx, serv, status); err != nil {
+		l.Error(err, "could not write status annotations on service")
+	}
+}
+
+// reportError writes the error as the last error of the service, logging
+// any error.
+func (r *ServiceReconciler) reportError(ctx context.Context, l logr.Logger, serv *corev1.Service, err error) {
+	if err := r.writeErrorStatus(ctx, serv, err); err != nil {
+		l.Error(err, "could not write status annotations on service")
+	}
+}
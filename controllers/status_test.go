@@ -0,0 +1,148 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// fakeIDRegistry is a fakeRegistry that also provides IDs of services.
+type fakeIDRegistry struct {
+	*fakeRegistry
+	lookups int
+}
+
+func (f *fakeIDRegistry) GetServID(_ context.Context, nsName, servName string) (string, error) {
+	f.lookups++
+	return fmt.Sprintf("id/%s/%s", nsName, servName), nil
+}
+
+func TestRegisteredStatus(t *testing.T) {
+	serv := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "serv", Namespace: "ns"}}
+	servData := &sr.Service{Name: "serv", NsName: "ns"}
+	endpList := []*sr.Endpoint{{Name: "one"}, {Name: "two"}, {Name: "three"}}
+	endpErrs := map[string]error{"two": sr.ErrTimeOutExpired, "not-owned": sr.ErrEndpNotOwnedByOp}
+	created := []sr.DriftFix{{Action: sr.DriftCreated, NsName: "ns", ServName: "serv"}}
+
+	a := assert.New(t)
+	r := &ServiceReconciler{
+		Log:               zap.New(zap.UseDevMode(true)),
+		ServRegBroker:     newFakeBroker(newFakeRegistry()),
+		StatusAnnotations: &StatusOptions{RegistryType: "etcd"},
+	}
+	status := r.registeredStatus(context.Background(), serv, servData, endpList, created, endpErrs)
+	a.Equal("etcd", status[statusRegistryAnnotation])
+	a.Equal("ns", status[statusNamespaceAnnotation])
+	a.Equal("serv", status[statusServiceAnnotation])
+	a.Equal("2", status[statusEndpointsAnnotation])
+	a.NotEmpty(status[statusLastChangeAnnotation])
+	a.NotContains(status, statusResourceIDAnnotation)
+	a.NotContains(status, statusLastErrorAnnotation)
+
+	f := &fakeIDRegistry{fakeRegistry: newFakeRegistry()}
+	r.ServRegBroker, _ = sr.NewBroker(f, sr.MetadataPair{})
+	status = r.registeredStatus(context.Background(), serv, servData, endpList, created, endpErrs)
+	a.Equal("id/ns/serv", status[statusResourceIDAnnotation])
+	a.Equal(1, f.lookups)
+
+	// Nothing changes if nothing happened, so that the service is not
+	// patched again, and the ID is not loaded again
+	status[statusLastChangeAnnotation] = "2022-03-01T10:00:00Z"
+	serv.Annotations = status
+	a.Equal(status, r.registeredStatus(context.Background(), serv, servData, endpList, nil, endpErrs))
+	a.Equal(1, f.lookups)
+
+	// The last change time changes with the service registry or the status
+	changed := r.registeredStatus(context.Background(), serv, servData, endpList, []sr.DriftFix{{Action: sr.DriftUpdated, NsName: "ns", ServName: "serv"}}, endpErrs)
+	a.NotEqual("2022-03-01T10:00:00Z", changed[statusLastChangeAnnotation])
+	changed = r.registeredStatus(context.Background(), serv, servData, endpList, nil, nil)
+	a.Equal("3", changed[statusEndpointsAnnotation])
+	a.NotEqual("2022-03-01T10:00:00Z", changed[statusLastChangeAnnotation])
+	a.Equal(1, f.lookups)
+
+	// The ID is loaded again if the service has been created again
+	r.registeredStatus(context.Background(), serv, servData, endpList, created, endpErrs)
+	a.Equal(2, f.lookups)
+}
+
+func TestWriteStatus(t *testing.T) {
+	key := types.NamespacedName{Namespace: "ns", Name: "serv"}
+	serv := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        key.Name,
+		Namespace:   key.Namespace,
+		Annotations: map[string]string{"cnwan.io/profile": "video"},
+	}}
+	r := &ServiceReconciler{
+		Client:            fake.NewFakeClientWithScheme(scheme.Scheme, serv),
+		StatusAnnotations: &StatusOptions{RegistryType: "etcd"},
+	}
+	get := func() *corev1.Service {
+		var s corev1.Service
+		r.Get(context.Background(), key, &s)
+		return &s
+	}
+
+	a := assert.New(t)
+	a.NoError(r.writeStatus(context.Background(), get(), map[string]string{
+		statusRegistryAnnotation:  "etcd",
+		statusEndpointsAnnotation: "2",
+	}))
+	a.Equal(map[string]string{
+		"cnwan.io/profile":        "video",
+		statusRegistryAnnotation:  "etcd",
+		statusEndpointsAnnotation: "2",
+	}, get().Annotations)
+
+	// The error is added to the other status annotations
+	a.NoError(r.writeErrorStatus(context.Background(), get(), sr.ErrTimeOutExpired))
+	a.Equal(map[string]string{
+		"cnwan.io/profile":        "video",
+		statusRegistryAnnotation:  "etcd",
+		statusEndpointsAnnotation: "2",
+		statusLastErrorAnnotation: sr.ErrTimeOutExpired.Error(),
+	}, get().Annotations)
+
+	// Stale status annotations are removed
+	a.NoError(r.writeStatus(context.Background(), get(), map[string]string{
+		statusRegistryAnnotation: "etcd",
+	}))
+	a.Equal(map[string]string{
+		"cnwan.io/profile":       "video",
+		statusRegistryAnnotation: "etcd",
+	}, get().Annotations)
+
+	a.NoError(r.writeStatus(context.Background(), get(), nil))
+	a.Equal(map[string]string{"cnwan.io/profile": "video"}, get().Annotations)
+
+	// Nothing is written if disabled
+	r.StatusAnnotations = nil
+	a.NoError(r.writeStatus(context.Background(), get(), map[string]string{statusRegistryAnnotation: "etcd"}))
+	a.Equal(map[string]string{"cnwan.io/profile": "video"}, get().Annotations)
+}
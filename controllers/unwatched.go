@@ -0,0 +1,83 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package controllers
+
+import (
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// unwatchedTracker keeps track of the services that stopped being watched,
+// as seen by the predicates, until they are removed from the service
+// registry.
+type unwatchedTracker struct {
+	lock sync.Mutex
+	keys map[types.NamespacedName]bool
+}
+
+func newUnwatchedTracker() *unwatchedTracker {
+	return &unwatchedTracker{keys: map[types.NamespacedName]bool{}}
+}
+
+// add records that the service stopped being watched.
+func (t *unwatchedTracker) add(key types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.keys[key] = true
+}
+
+// has returns whether the service stopped being watched and has not been
+// removed from the service registry yet.
+func (t *unwatchedTracker) has(key types.NamespacedName) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.keys[key]
+}
+
+// forget removes the service from the tracker.
+func (t *unwatchedTracker) forget(key types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.keys, key)
+}
+
+// mayBeRegistered returns whether a service that is not watched may be in
+// the service registry, because it has the finalizer or the status
+// annotations written by the operator or because it stopped being watched
+// since the operator started.
+func (r *ServiceReconciler) mayBeRegistered(key types.NamespacedName, serv *corev1.Service) bool {
+	if controllerutil.ContainsFinalizer(serv, deregisterFinalizer) {
+		return true
+	}
+
+	for annotation := range serv.Annotations {
+		if strings.HasPrefix(annotation, statusAnnotationPrefix) {
+			return true
+		}
+	}
+
+	return r.unwatched.has(key)
+}
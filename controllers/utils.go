@@ -20,45 +20,99 @@ package controllers
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
 // filterAnnotations is used to remove annotations that should be ignored
-// by the operator
-func filterAnnotations(currentAnnotations map[string]string, filter []string) map[string]string {
-	filterMap := map[string]bool{}
-	for _, ann := range filter {
-		filterMap[ann] = true
-	}
-
-	if _, exists := filterMap["*/*"]; exists {
-		return currentAnnotations
-	}
+// by the operator. An annotation is kept if it is allowed by an entry of
+// the filter and not denied by an entry of the deny-list, which has the same
+// format: the deny-list always wins.
+// The status annotations written by the operator itself are always removed.
+//
+// Entries can be exact keys, prefix/*, */name, */* or, if they start with
+// ^, regular expressions that keys must match.
+func filterAnnotations(currentAnnotations map[string]string, filter, deny []string) map[string]string {
+	allowMatcher := newKeyMatcher(filter)
+	denyMatcher := newKeyMatcher(deny)
 
 	filtered := map[string]string{}
 	for key, val := range currentAnnotations {
+		if strings.HasPrefix(key, statusAnnotationPrefix) {
+			continue
+		}
 
-		// Check this key specifically
-		if _, exists := filterMap[key]; exists {
+		if allowMatcher.matches(key) && !denyMatcher.matches(key) {
 			filtered[key] = val
-			continue
 		}
+	}
 
-		prefixName := strings.Split(key, "/")
-		if len(prefixName) != 2 {
-			// This key is not in prefix/name format
+	return filtered
+}
+
+// keyMatcher tells whether a key matches the entries of a filter.
+type keyMatcher struct {
+	keys     map[string]bool
+	patterns []*regexp.Regexp
+}
+
+func newKeyMatcher(filter []string) *keyMatcher {
+	m := &keyMatcher{keys: map[string]bool{}}
+	for _, entry := range filter {
+		if !isKeyPattern(entry) {
+			m.keys[entry] = true
 			continue
 		}
 
-		prefixWildcard := fmt.Sprintf("%s/*", prefixName[0])
-		if _, exists := filterMap[prefixWildcard]; exists {
-			filtered[key] = val
-			continue
+		// Patterns are validated with the settings, so invalid ones
+		// can only come from tests and are just ignored.
+		if pattern, err := regexp.Compile(entry); err == nil {
+			m.patterns = append(m.patterns, pattern)
+		}
+	}
+
+	return m
+}
+
+func (m *keyMatcher) matches(key string) bool {
+	if m.keys["*/*"] || m.keys[key] {
+		return true
+	}
+
+	for _, pattern := range m.patterns {
+		if pattern.MatchString(key) {
+			return true
 		}
+	}
 
-		wildcardName := fmt.Sprintf("*/%s", prefixName[1])
-		if _, exists := filterMap[wildcardName]; exists {
-			filtered[key] = val
+	prefixName := strings.Split(key, "/")
+	if len(prefixName) != 2 {
+		// This key is not in prefix/name format
+		return false
+	}
+
+	return m.keys[fmt.Sprintf("%s/*", prefixName[0])] || m.keys[fmt.Sprintf("*/%s", prefixName[1])]
+}
+
+// isKeyPattern returns whether the entry of a filter is a regular
+// expression. As keys cannot start with ^, there is no ambiguity.
+func isKeyPattern(entry string) bool {
+	return strings.HasPrefix(entry, "^")
+}
+
+// filterKeys returns the keys that are allowed by the filter, which has the
+// same format as the one of filterAnnotations.
+func filterKeys(keys []string, filter []string) []string {
+	asAnnotations := map[string]string{}
+	for _, key := range keys {
+		asAnnotations[key] = ""
+	}
+	allowed := filterAnnotations(asAnnotations, filter, nil)
+
+	filtered := []string{}
+	for _, key := range keys {
+		if _, exists := allowed[key]; exists {
+			filtered = append(filtered, key)
 		}
 	}
 
@@ -39,6 +39,7 @@ func TestFilterAnnotations(t *testing.T) {
 	cases := []struct {
 		annotations map[string]string
 		filter      []string
+		deny        []string
 		expRes      map[string]string
 	}{
 		{
@@ -56,6 +57,16 @@ func TestFilterAnnotations(t *testing.T) {
 			filter:      []string{"*/*"},
 			expRes:      annotations,
 		},
+		{
+			annotations: map[string]string{"prefix.io/one": "one", statusLastChangeAnnotation: "2022-01-01T00:00:00Z"},
+			filter:      []string{"*/*"},
+			expRes:      map[string]string{"prefix.io/one": "one"},
+		},
+		{
+			annotations: map[string]string{statusLastChangeAnnotation: "2022-01-01T00:00:00Z"},
+			filter:      []string{statusLastChangeAnnotation, "status.operator.cnwan.io/*"},
+			expRes:      map[string]string{},
+		},
 		{
 			annotations: annotations,
 			filter:      []string{"stand-alone", "prefix.io/*", "*/first"},
@@ -67,11 +78,46 @@ func TestFilterAnnotations(t *testing.T) {
 				"yet-another.io/first": "yet-first",
 			},
 		},
+		{
+			annotations: annotations,
+			filter:      []string{"^(stand-alone|another-.*)$", "^prefix\\.io/"},
+			expRes: map[string]string{
+				"stand-alone":         "alone",
+				"another-stand-alone": "another-alone",
+				"prefix.io/one":       "one",
+				"prefix.io/two":       "two",
+			},
+		},
+		{
+			annotations: annotations,
+			filter:      []string{"*/*"},
+			deny:        []string{"another-stand-alone", "prefix.io/*", "*/second", "^yet-"},
+			expRes: map[string]string{
+				"stand-alone":      "alone",
+				"another.io/first": "another-first",
+			},
+		},
+		{
+			annotations: annotations,
+			filter:      []string{"prefix.io/one"},
+			deny:        []string{"*/*"},
+			expRes:      map[string]string{},
+		},
 	}
 
 	a := assert.New(t)
 	for _, currCase := range cases {
-		res := filterAnnotations(currCase.annotations, currCase.filter)
+		res := filterAnnotations(currCase.annotations, currCase.filter, currCase.deny)
 		a.Equal(currCase.expRes, res)
 	}
 }
+
+func TestFilterKeys(t *testing.T) {
+	keys := []string{"cnwan.io/port-name", "cnwan.io/protocol", "other.io/key"}
+
+	a := assert.New(t)
+	a.Empty(filterKeys(keys, nil))
+	a.Equal([]string{"cnwan.io/protocol"}, filterKeys(keys, []string{"cnwan.io/protocol"}))
+	a.Equal([]string{"cnwan.io/port-name", "cnwan.io/protocol"}, filterKeys(keys, []string{"cnwan.io/*"}))
+	a.Equal(keys, filterKeys(keys, []string{"*/*"}))
+}
@@ -18,17 +18,194 @@
 
 package types
 
+import "time"
+
 // Settings of the application
 type Settings struct {
-	WatchNamespacesByDefault bool            `yaml:"watchNamespacesByDefault"`
-	Service                  ServiceSettings `yaml:",inline"`
+	WatchNamespacesByDefault bool              `yaml:"watchNamespacesByDefault"`
+	NamespaceSelector        string            `yaml:"namespaceSelector,omitempty"`
+	ServiceSelector          string            `yaml:"serviceSelector,omitempty"`
+	Service                  ServiceSettings   `yaml:",inline"`
+	Namespace                NamespaceSettings `yaml:",inline"`
+	// EndpointMetadata is the list of metadata about the port and the
+	// address of endpoints, e.g. "cnwan.io/protocol", that should be
+	// registered as metadata of endpoints.
+	EndpointMetadata    []string                     `yaml:"endpointMetadata,omitempty"`
+	EndpointAnnotations *EndpointAnnotationsSettings `yaml:"endpointAnnotations,omitempty"`
+	NamespaceDefaults   *NamespaceDefaultsSettings   `yaml:"namespaceDefaults,omitempty"`
+	// MetadataRules transform the metadata of services, in order.
+	MetadataRules            []MetadataRuleSettings `yaml:"metadataRules,omitempty"`
 	*ServiceRegistrySettings `yaml:"serviceRegistry"`
-	CloudMetadata            *CloudMetadata `yaml:"cloudMetadata"`
+	CloudMetadata            *CloudMetadata                `yaml:"cloudMetadata"`
+	NodePort                 *NodePortSettings             `yaml:"nodePort,omitempty"`
+	ClusterIP                *ClusterIPSettings            `yaml:"clusterIP,omitempty"`
+	Resync                   *ResyncSettings               `yaml:"resync,omitempty"`
+	LoadBalancerHostname     *LoadBalancerHostnameSettings `yaml:"loadBalancerHostname,omitempty"`
+	StatusAnnotations        *StatusAnnotationsSettings    `yaml:"statusAnnotations,omitempty"`
+	Finalizers               *FinalizersSettings           `yaml:"finalizers,omitempty"`
+	LeaderElection           *LeaderElectionSettings       `yaml:"leaderElection,omitempty"`
+	// MaxConcurrentReconciles is the maximum number of services, and of
+	// namespaces, that can be reconciled at the same time.
+	MaxConcurrentReconciles int `yaml:"maxConcurrentReconciles,omitempty"`
 }
 
 // ServiceSettings includes settings about services
 type ServiceSettings struct {
 	Annotations []string `yaml:"serviceAnnotations"`
+	// DeniedAnnotations is the list of service annotations that should
+	// never be registered, even if they are allowed by Annotations.
+	DeniedAnnotations []string `yaml:"deniedServiceAnnotations,omitempty"`
+	// Labels is the list of service labels that should be registered as
+	// metadata of the service, along with the allowed annotations.
+	Labels []string `yaml:"serviceLabels,omitempty"`
+}
+
+// NamespaceSettings includes settings about namespaces
+type NamespaceSettings struct {
+	// Annotations is the list of namespace annotations that should be
+	// registered as metadata of the namespace in the service registry.
+	Annotations []string `yaml:"namespaceAnnotations,omitempty"`
+	// DeniedAnnotations is the list of namespace annotations that should
+	// never be registered, even if they are allowed by Annotations.
+	DeniedAnnotations []string `yaml:"deniedNamespaceAnnotations,omitempty"`
+}
+
+// EndpointAnnotationsSettings contains settings about the annotations of
+// services that are registered as metadata of the endpoints of one of their
+// ports, instead of the service.
+type EndpointAnnotationsSettings struct {
+	// Enabled specifies whether these annotations should be registered as
+	// metadata of endpoints.
+	Enabled bool `yaml:"enabled"`
+	// Prefix of the annotations, e.g. "endpoint.cnwan.io", which are in the
+	// format <prefix>/<port-name>.<key>. Defaults to "endpoint.cnwan.io".
+	Prefix string `yaml:"prefix,omitempty"`
+}
+
+// NamespaceDefaultsSettings contains settings about the annotations of
+// namespaces that are registered as default metadata of their services,
+// instead of the namespace.
+type NamespaceDefaultsSettings struct {
+	// Enabled specifies whether these annotations should be registered as
+	// default metadata of services.
+	Enabled bool `yaml:"enabled"`
+	// Prefix of the annotations, e.g. "defaults.cnwan.io", which are in the
+	// format <prefix>/<key>. Defaults to "defaults.cnwan.io".
+	Prefix string `yaml:"prefix,omitempty"`
+}
+
+// MetadataRuleSettings contains a rule that transforms the metadata of
+// services after their annotations have been filtered.
+type MetadataRuleSettings struct {
+	// Registries are the types of service registry the rule applies to,
+	// i.e. "etcd", "servicedirectory" or "cloudmap". If empty, the rule
+	// applies to all of them.
+	Registries []string `yaml:"registries,omitempty"`
+	// RenameKeys maps keys to their new names.
+	RenameKeys map[string]string `yaml:"renameKeys,omitempty"`
+	// StripPrefix is removed from the keys that start with it.
+	StripPrefix string `yaml:"stripPrefix,omitempty"`
+	// AddPrefix is added to all keys.
+	AddPrefix string `yaml:"addPrefix,omitempty"`
+	// LowercaseKeys specifies whether keys should be lowercased.
+	LowercaseKeys bool `yaml:"lowercaseKeys,omitempty"`
+	// MapValues maps the values of a key to new values.
+	MapValues map[string]map[string]string `yaml:"mapValues,omitempty"`
+	// Defaults are the metadata to set when they are missing.
+	Defaults map[string]string `yaml:"defaults,omitempty"`
+}
+
+// NodePortSettings contains settings about how services of type NodePort
+// should be registered.
+type NodePortSettings struct {
+	// Enabled specifies whether NodePort services should be registered by
+	// using the addresses of the nodes.
+	Enabled bool `yaml:"enabled"`
+	// NodeSelector is a Kubernetes label selector, e.g. "role=edge", that
+	// nodes must match to have their addresses registered.
+	// If empty, all nodes will be used.
+	NodeSelector string `yaml:"nodeSelector,omitempty"`
+	// AddressTypes is the list of node address types that should be used,
+	// in order of preference, i.e. "ExternalIP" or "InternalIP".
+	AddressTypes []string `yaml:"addressTypes,omitempty"`
+}
+
+// ClusterIPSettings contains settings about how services of type ClusterIP,
+// including headless services, should be registered.
+type ClusterIPSettings struct {
+	// Enabled specifies whether ClusterIP services should be registered by
+	// using the addresses of their ready endpoints, as they appear on their
+	// EndpointSlices.
+	Enabled bool `yaml:"enabled"`
+}
+
+// ResyncSettings contains settings about the periodic comparison between
+// the state of Kubernetes and the objects owned by the operator in the
+// service registry.
+type ResyncSettings struct {
+	// Enabled specifies whether the comparison should be performed and
+	// differences should be repaired.
+	Enabled bool `yaml:"enabled"`
+	// Period is the time to wait between two comparisons, e.g. "10m".
+	Period time.Duration `yaml:"period,omitempty"`
+}
+
+// LoadBalancerHostnamePolicy specifies what to do with load balancer
+// ingresses that have a hostname but no IP, e.g. the ones of AWS load
+// balancers.
+type LoadBalancerHostnamePolicy string
+
+const (
+	// LoadBalancerHostnameResolve specifies that the hostname must be
+	// resolved and its addresses registered as endpoints.
+	LoadBalancerHostnameResolve LoadBalancerHostnamePolicy = "Resolve"
+	// LoadBalancerHostnameRegister specifies that the hostname itself must
+	// be registered as the address of the endpoints.
+	LoadBalancerHostnameRegister LoadBalancerHostnamePolicy = "Register"
+)
+
+// LoadBalancerHostnameSettings contains settings about how load balancer
+// ingresses with a hostname and no IP should be registered.
+type LoadBalancerHostnameSettings struct {
+	// Policy is either "Resolve" or "Register". Defaults to "Resolve".
+	Policy LoadBalancerHostnamePolicy `yaml:"policy,omitempty"`
+	// RefreshInterval is how often hostnames should be resolved again
+	// to reflect changes in their DNS records, e.g. "1m". Only used
+	// with the "Resolve" policy.
+	RefreshInterval time.Duration `yaml:"refreshInterval,omitempty"`
+}
+
+// StatusAnnotationsSettings contains settings about the annotations that the
+// operator writes on services to report their registration status.
+type StatusAnnotationsSettings struct {
+	// Enabled specifies whether the status annotations should be written.
+	Enabled bool `yaml:"enabled"`
+}
+
+// FinalizersSettings contains settings about the finalizer that the operator
+// sets on registered services and namespaces.
+type FinalizersSettings struct {
+	// Enabled specifies whether services and namespaces should be removed
+	// from the service registry before they are deleted from Kubernetes.
+	Enabled bool `yaml:"enabled"`
+}
+
+// LeaderElectionSettings contains settings about how the leader is elected
+// among the replicas of the operator, so that only one of them writes to the
+// service registry at any time.
+type LeaderElectionSettings struct {
+	// Enabled specifies whether leader election should be performed. It
+	// must be enabled to run more than one replica.
+	Enabled bool `yaml:"enabled"`
+	// LeaseDuration is how long standby replicas wait before taking over a
+	// leader that stopped renewing its lease, e.g. "15s".
+	LeaseDuration time.Duration `yaml:"leaseDuration,omitempty"`
+	// RenewDeadline is how long the leader tries to renew its lease before
+	// giving up leadership, e.g. "10s".
+	RenewDeadline time.Duration `yaml:"renewDeadline,omitempty"`
+	// RetryPeriod is how long to wait between two attempts to acquire or
+	// renew the lease, e.g. "2s".
+	RetryPeriod time.Duration `yaml:"retryPeriod,omitempty"`
 }
 
 // ServiceRegistrySettings contains information about the service registry
@@ -20,14 +20,44 @@ package utils
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/CloudNativeSDWAN/cnwan-operator/internal/types"
 	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
+const (
+	defaultResyncPeriod time.Duration = 10 * time.Minute
+	minResyncPeriod     time.Duration = time.Minute
+
+	defaultHostnameRefreshInterval time.Duration = time.Minute
+	minHostnameRefreshInterval     time.Duration = 10 * time.Second
+
+	defaultLeaseDuration time.Duration = 15 * time.Second
+	defaultRenewDeadline time.Duration = 10 * time.Second
+	defaultRetryPeriod   time.Duration = 2 * time.Second
+	// leaderElectionJitter is the jitter factor applied to the retry period
+	// by the leader election.
+	leaderElectionJitter float64 = 1.2
+
+	defaultMaxConcurrentReconciles int = 1
+
+	defaultEndpointAnnotationsPrefix string = "endpoint.cnwan.io"
+	defaultNamespaceDefaultsPrefix   string = "defaults.cnwan.io"
+)
+
 var (
 	log = zap.New(zap.UseDevMode(false))
+
+	// registryTypes are the types of service registry that metadata rules
+	// can be scoped to.
+	registryTypes = map[string]bool{"etcd": true, "servicedirectory": true, "cloudmap": true}
 )
 
 // ParseAndValidateSettings parses the settings and validates them.
@@ -57,10 +87,120 @@ func ParseAndValidateSettings(settings *types.Settings) (*types.Settings, error)
 		}
 	}
 
-	if len(settings.Service.Annotations) == 0 {
-		log.V(int(zapcore.WarnLevel)).Info("no allowed annotations provided: no service will be registered")
+	if len(settings.Service.Annotations) == 0 && len(settings.Service.Labels) == 0 {
+		log.V(int(zapcore.WarnLevel)).Info("no allowed annotations or labels provided: no service will be registered")
+	}
+	if _, err := labels.Parse(settings.NamespaceSelector); err != nil {
+		return nil, fmt.Errorf("invalid namespace selector provided: %w", err)
+	}
+	finalSettings.NamespaceSelector = settings.NamespaceSelector
+
+	if _, err := labels.Parse(settings.ServiceSelector); err != nil {
+		return nil, fmt.Errorf("invalid service selector provided: %w", err)
 	}
+	finalSettings.ServiceSelector = settings.ServiceSelector
+
+	annotationFilters := []struct {
+		name   string
+		filter []string
+	}{
+		{"serviceAnnotations", settings.Service.Annotations},
+		{"deniedServiceAnnotations", settings.Service.DeniedAnnotations},
+		{"serviceLabels", settings.Service.Labels},
+		{"namespaceAnnotations", settings.Namespace.Annotations},
+		{"deniedNamespaceAnnotations", settings.Namespace.DeniedAnnotations},
+		{"endpointMetadata", settings.EndpointMetadata},
+	}
+	for _, annFilter := range annotationFilters {
+		if err := validateAnnotationFilter(annFilter.filter); err != nil {
+			return nil, fmt.Errorf("invalid %s provided: %w", annFilter.name, err)
+		}
+	}
+
 	finalSettings.Service = settings.Service
+	finalSettings.Namespace = settings.Namespace
+	finalSettings.EndpointMetadata = settings.EndpointMetadata
+
+	if settings.EndpointAnnotations != nil && settings.EndpointAnnotations.Enabled {
+		parsedSettings, err := parseEndpointAnnotationsSettings(settings.EndpointAnnotations)
+		if err != nil {
+			return nil, err
+		}
+
+		finalSettings.EndpointAnnotations = parsedSettings
+	}
+
+	if settings.NamespaceDefaults != nil && settings.NamespaceDefaults.Enabled {
+		parsedSettings, err := parseNamespaceDefaultsSettings(settings.NamespaceDefaults)
+		if err != nil {
+			return nil, err
+		}
+
+		finalSettings.NamespaceDefaults = parsedSettings
+	}
+
+	for i, rule := range settings.MetadataRules {
+		if err := validateMetadataRule(rule); err != nil {
+			return nil, fmt.Errorf("invalid metadata rule #%d: %w", i+1, err)
+		}
+	}
+	finalSettings.MetadataRules = settings.MetadataRules
+
+	if settings.NodePort != nil && settings.NodePort.Enabled {
+		parsedSettings, err := parseNodePortSettings(settings.NodePort)
+		if err != nil {
+			return nil, err
+		}
+
+		finalSettings.NodePort = parsedSettings
+	}
+
+	if settings.ClusterIP != nil && settings.ClusterIP.Enabled {
+		finalSettings.ClusterIP = &types.ClusterIPSettings{Enabled: true}
+	}
+
+	if settings.StatusAnnotations != nil && settings.StatusAnnotations.Enabled {
+		finalSettings.StatusAnnotations = &types.StatusAnnotationsSettings{Enabled: true}
+	}
+
+	if settings.Finalizers != nil && settings.Finalizers.Enabled {
+		finalSettings.Finalizers = &types.FinalizersSettings{Enabled: true}
+	}
+
+	if settings.Resync != nil && settings.Resync.Enabled {
+		parsedSettings, err := parseResyncSettings(settings.Resync)
+		if err != nil {
+			return nil, err
+		}
+
+		finalSettings.Resync = parsedSettings
+	}
+
+	if settings.LeaderElection != nil && settings.LeaderElection.Enabled {
+		parsedSettings, err := parseLeaderElectionSettings(settings.LeaderElection)
+		if err != nil {
+			return nil, err
+		}
+
+		finalSettings.LeaderElection = parsedSettings
+	}
+
+	if settings.MaxConcurrentReconciles < 0 {
+		return nil, fmt.Errorf("max concurrent reconciles cannot be negative")
+	}
+	finalSettings.MaxConcurrentReconciles = settings.MaxConcurrentReconciles
+	if finalSettings.MaxConcurrentReconciles == 0 {
+		finalSettings.MaxConcurrentReconciles = defaultMaxConcurrentReconciles
+	}
+
+	if settings.LoadBalancerHostname != nil {
+		parsedSettings, err := parseLoadBalancerHostnameSettings(settings.LoadBalancerHostname)
+		if err != nil {
+			return nil, err
+		}
+
+		finalSettings.LoadBalancerHostname = parsedSettings
+	}
 
 	if settings.ServiceRegistrySettings == nil {
 		return nil, fmt.Errorf("no service registry provided")
@@ -170,3 +310,198 @@ func parseEtcdSettings(settings *types.EtcdSettings) (*types.EtcdSettings, error
 
 	return finalSettings, nil
 }
+
+func parseNodePortSettings(settings *types.NodePortSettings) (*types.NodePortSettings, error) {
+	if _, err := labels.Parse(settings.NodeSelector); err != nil {
+		return nil, fmt.Errorf("invalid node selector provided: %w", err)
+	}
+
+	finalSettings := &types.NodePortSettings{
+		Enabled:      true,
+		NodeSelector: settings.NodeSelector,
+		AddressTypes: []string{},
+	}
+
+	if len(settings.AddressTypes) == 0 {
+		finalSettings.AddressTypes = []string{string(corev1.NodeExternalIP), string(corev1.NodeInternalIP)}
+		return finalSettings, nil
+	}
+
+	dups := map[string]bool{}
+	for _, addrType := range settings.AddressTypes {
+		if addrType != string(corev1.NodeExternalIP) && addrType != string(corev1.NodeInternalIP) {
+			return nil, fmt.Errorf("unsupported node address type: %s", addrType)
+		}
+
+		if dups[addrType] {
+			continue
+		}
+
+		finalSettings.AddressTypes = append(finalSettings.AddressTypes, addrType)
+		dups[addrType] = true
+	}
+
+	return finalSettings, nil
+}
+
+func parseEndpointAnnotationsSettings(settings *types.EndpointAnnotationsSettings) (*types.EndpointAnnotationsSettings, error) {
+	finalSettings := &types.EndpointAnnotationsSettings{
+		Enabled: true,
+		Prefix:  settings.Prefix,
+	}
+
+	if finalSettings.Prefix == "" {
+		finalSettings.Prefix = defaultEndpointAnnotationsPrefix
+	}
+
+	if err := validateAnnotationPrefix(finalSettings.Prefix); err != nil {
+		return nil, fmt.Errorf("invalid endpoint annotations prefix provided: %w", err)
+	}
+
+	return finalSettings, nil
+}
+
+func parseNamespaceDefaultsSettings(settings *types.NamespaceDefaultsSettings) (*types.NamespaceDefaultsSettings, error) {
+	finalSettings := &types.NamespaceDefaultsSettings{
+		Enabled: true,
+		Prefix:  settings.Prefix,
+	}
+
+	if finalSettings.Prefix == "" {
+		finalSettings.Prefix = defaultNamespaceDefaultsPrefix
+	}
+
+	if err := validateAnnotationPrefix(finalSettings.Prefix); err != nil {
+		return nil, fmt.Errorf("invalid namespace defaults prefix provided: %w", err)
+	}
+
+	return finalSettings, nil
+}
+
+// validateAnnotationPrefix checks that the prefix can be the prefix of an
+// annotation key, i.e. that it is a DNS subdomain.
+func validateAnnotationPrefix(prefix string) error {
+	if errs := validation.IsDNS1123Subdomain(prefix); len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// validateAnnotationFilter checks that the entries of a list of allowed or
+// denied annotations that are regular expressions, i.e. that start with ^,
+// are valid.
+func validateAnnotationFilter(filter []string) error {
+	for _, entry := range filter {
+		if !strings.HasPrefix(entry, "^") {
+			continue
+		}
+
+		if _, err := regexp.Compile(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateMetadataRule(rule types.MetadataRuleSettings) error {
+	for _, registry := range rule.Registries {
+		if !registryTypes[registry] {
+			return fmt.Errorf("unknown service registry type %q", registry)
+		}
+	}
+
+	for key, newKey := range rule.RenameKeys {
+		if key == "" || newKey == "" {
+			return fmt.Errorf("keys to rename cannot be empty")
+		}
+	}
+
+	for key := range rule.MapValues {
+		if key == "" {
+			return fmt.Errorf("keys whose values to map cannot be empty")
+		}
+	}
+
+	for key := range rule.Defaults {
+		if key == "" {
+			return fmt.Errorf("keys of defaults cannot be empty")
+		}
+	}
+
+	return nil
+}
+
+func parseResyncSettings(settings *types.ResyncSettings) (*types.ResyncSettings, error) {
+	finalSettings := &types.ResyncSettings{
+		Enabled: true,
+		Period:  settings.Period,
+	}
+
+	if finalSettings.Period == 0 {
+		finalSettings.Period = defaultResyncPeriod
+	}
+
+	if finalSettings.Period < minResyncPeriod {
+		return nil, fmt.Errorf("resync period cannot be less than %s", minResyncPeriod)
+	}
+
+	return finalSettings, nil
+}
+
+func parseLoadBalancerHostnameSettings(settings *types.LoadBalancerHostnameSettings) (*types.LoadBalancerHostnameSettings, error) {
+	finalSettings := &types.LoadBalancerHostnameSettings{
+		Policy:          types.LoadBalancerHostnameResolve,
+		RefreshInterval: defaultHostnameRefreshInterval,
+	}
+
+	switch settings.Policy {
+	case "", types.LoadBalancerHostnameResolve:
+	case types.LoadBalancerHostnameRegister:
+		finalSettings.Policy = types.LoadBalancerHostnameRegister
+	default:
+		return nil, fmt.Errorf("unsupported load balancer hostname policy: %s", settings.Policy)
+	}
+
+	if settings.RefreshInterval != 0 {
+		finalSettings.RefreshInterval = settings.RefreshInterval
+	}
+
+	if finalSettings.RefreshInterval < minHostnameRefreshInterval {
+		return nil, fmt.Errorf("load balancer hostname refresh interval cannot be less than %s", minHostnameRefreshInterval)
+	}
+
+	return finalSettings, nil
+}
+
+func parseLeaderElectionSettings(settings *types.LeaderElectionSettings) (*types.LeaderElectionSettings, error) {
+	finalSettings := &types.LeaderElectionSettings{
+		Enabled:       true,
+		LeaseDuration: settings.LeaseDuration,
+		RenewDeadline: settings.RenewDeadline,
+		RetryPeriod:   settings.RetryPeriod,
+	}
+
+	if finalSettings.LeaseDuration == 0 {
+		finalSettings.LeaseDuration = defaultLeaseDuration
+	}
+	if finalSettings.RenewDeadline == 0 {
+		finalSettings.RenewDeadline = defaultRenewDeadline
+	}
+	if finalSettings.RetryPeriod == 0 {
+		finalSettings.RetryPeriod = defaultRetryPeriod
+	}
+
+	if finalSettings.RetryPeriod < 0 {
+		return nil, fmt.Errorf("leader election retry period cannot be negative")
+	}
+	if finalSettings.LeaseDuration <= finalSettings.RenewDeadline {
+		return nil, fmt.Errorf("leader election lease duration must be greater than renew deadline")
+	}
+	if float64(finalSettings.RenewDeadline) <= leaderElectionJitter*float64(finalSettings.RetryPeriod) {
+		return nil, fmt.Errorf("leader election renew deadline must be greater than %.1f times the retry period", leaderElectionJitter)
+	}
+
+	return finalSettings, nil
+}
@@ -21,9 +21,11 @@ package utils
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/CloudNativeSDWAN/cnwan-operator/internal/types"
 	. "github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func TestParseAndValidateSettings(t *testing.T) {
@@ -32,6 +34,7 @@ func TestParseAndValidateSettings(t *testing.T) {
 	port2800 := 2800
 	portDef := 2379
 	port2810 := 2810
+	_, selErr := labels.Parse("env in prod")
 	cases := []struct {
 		id     string
 		arg    *types.Settings
@@ -42,6 +45,21 @@ func TestParseAndValidateSettings(t *testing.T) {
 			id:     "nil-settings",
 			expErr: fmt.Errorf("no settings provided"),
 		},
+		{
+			id:     "invalid-namespace-selector",
+			arg:    &types.Settings{NamespaceSelector: "env in prod"},
+			expErr: fmt.Errorf("invalid namespace selector provided: %w", selErr),
+		},
+		{
+			id:     "invalid-service-selector",
+			arg:    &types.Settings{ServiceSelector: "env in prod"},
+			expErr: fmt.Errorf("invalid service selector provided: %w", selErr),
+		},
+		{
+			id:     "negative-max-concurrent-reconciles",
+			arg:    &types.Settings{MaxConcurrentReconciles: -1},
+			expErr: fmt.Errorf("max concurrent reconciles cannot be negative"),
+		},
 		{
 			id:     "no-service-registry-settings",
 			arg:    &types.Settings{WatchNamespacesByDefault: true},
@@ -240,6 +258,21 @@ func TestParseAndValidateSettings(t *testing.T) {
 				CloudMetadata: nil,
 			},
 		},
+		{
+			id: "successful-with-max-concurrent-reconciles",
+			arg: &types.Settings{
+				ServiceRegistrySettings: &types.ServiceRegistrySettings{
+					CloudMapSettings: &types.CloudMapSettings{},
+				},
+				MaxConcurrentReconciles: 4,
+			},
+			expRes: &types.Settings{
+				ServiceRegistrySettings: &types.ServiceRegistrySettings{
+					CloudMapSettings: &types.CloudMapSettings{},
+				},
+				MaxConcurrentReconciles: 4,
+			},
+		},
 	}
 
 	for _, currCase := range cases {
@@ -250,6 +283,14 @@ func TestParseAndValidateSettings(t *testing.T) {
 				a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
 			}
 
+			expMax := currCase.expRes.MaxConcurrentReconciles
+			if expMax == 0 {
+				expMax = defaultMaxConcurrentReconciles
+			}
+			if !a.Equal(expMax, res.MaxConcurrentReconciles) {
+				a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
+			}
+
 			if currCase.expRes.ServiceRegistrySettings != nil {
 				if !a.NotNil(res.ServiceRegistrySettings) {
 					a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
@@ -280,3 +321,310 @@ func TestParseAndValidateSettings(t *testing.T) {
 		}
 	}
 }
+
+func TestParseNodePortSettings(t *testing.T) {
+	a := New(t)
+	cases := []struct {
+		id     string
+		arg    *types.NodePortSettings
+		expRes *types.NodePortSettings
+		expErr bool
+	}{
+		{
+			id:  "defaults",
+			arg: &types.NodePortSettings{Enabled: true},
+			expRes: &types.NodePortSettings{
+				Enabled:      true,
+				AddressTypes: []string{"ExternalIP", "InternalIP"},
+			},
+		},
+		{
+			id: "with-selector-and-duplicates",
+			arg: &types.NodePortSettings{
+				Enabled:      true,
+				NodeSelector: "role in (edge,gateway)",
+				AddressTypes: []string{"InternalIP", "InternalIP"},
+			},
+			expRes: &types.NodePortSettings{
+				Enabled:      true,
+				NodeSelector: "role in (edge,gateway)",
+				AddressTypes: []string{"InternalIP"},
+			},
+		},
+		{
+			id:     "invalid-selector",
+			arg:    &types.NodePortSettings{Enabled: true, NodeSelector: "role in edge"},
+			expErr: true,
+		},
+		{
+			id:     "invalid-address-type",
+			arg:    &types.NodePortSettings{Enabled: true, AddressTypes: []string{"Hostname"}},
+			expErr: true,
+		},
+	}
+
+	for _, currCase := range cases {
+		res, err := parseNodePortSettings(currCase.arg)
+		if !a.Equal(currCase.expErr, err != nil) || !a.Equal(currCase.expRes, res) {
+			a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
+		}
+	}
+}
+
+func TestValidateAnnotationFilter(t *testing.T) {
+	a := New(t)
+	cases := []struct {
+		id     string
+		arg    []string
+		expErr bool
+	}{
+		{
+			id:  "wildcards",
+			arg: []string{"key", "prefix.io/*", "*/name", "*/*"},
+		},
+		{
+			id:  "valid-pattern",
+			arg: []string{"^team-[a-z]+$", "key"},
+		},
+		{
+			id:     "invalid-pattern",
+			arg:    []string{"^team-[a-z+$"},
+			expErr: true,
+		},
+	}
+
+	for _, currCase := range cases {
+		err := validateAnnotationFilter(currCase.arg)
+		if !a.Equal(currCase.expErr, err != nil) {
+			a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
+		}
+	}
+}
+
+func TestValidateMetadataRule(t *testing.T) {
+	a := New(t)
+	cases := []struct {
+		id     string
+		arg    types.MetadataRuleSettings
+		expErr bool
+	}{
+		{
+			id: "valid",
+			arg: types.MetadataRuleSettings{
+				Registries:    []string{"etcd", "cloudmap"},
+				RenameKeys:    map[string]string{"cnwan.io/traffic-profile": "traffic-profile"},
+				StripPrefix:   "cnwan.io/",
+				LowercaseKeys: true,
+				MapValues:     map[string]map[string]string{"traffic-profile": {"gold": "premium"}},
+				Defaults:      map[string]string{"traffic-profile": "standard"},
+			},
+		},
+		{
+			id:     "unknown-registry",
+			arg:    types.MetadataRuleSettings{Registries: []string{"consul"}},
+			expErr: true,
+		},
+		{
+			id:     "empty-new-key",
+			arg:    types.MetadataRuleSettings{RenameKeys: map[string]string{"key": ""}},
+			expErr: true,
+		},
+		{
+			id:     "empty-default-key",
+			arg:    types.MetadataRuleSettings{Defaults: map[string]string{"": "val"}},
+			expErr: true,
+		},
+	}
+
+	for _, currCase := range cases {
+		err := validateMetadataRule(currCase.arg)
+		if !a.Equal(currCase.expErr, err != nil) {
+			a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
+		}
+	}
+}
+
+func TestParseEndpointAnnotationsSettings(t *testing.T) {
+	a := New(t)
+	cases := []struct {
+		id     string
+		arg    *types.EndpointAnnotationsSettings
+		expRes *types.EndpointAnnotationsSettings
+		expErr bool
+	}{
+		{
+			id:     "defaults",
+			arg:    &types.EndpointAnnotationsSettings{Enabled: true},
+			expRes: &types.EndpointAnnotationsSettings{Enabled: true, Prefix: defaultEndpointAnnotationsPrefix},
+		},
+		{
+			id:     "custom-prefix",
+			arg:    &types.EndpointAnnotationsSettings{Enabled: true, Prefix: "ports.example.com"},
+			expRes: &types.EndpointAnnotationsSettings{Enabled: true, Prefix: "ports.example.com"},
+		},
+		{
+			id:     "invalid-prefix",
+			arg:    &types.EndpointAnnotationsSettings{Enabled: true, Prefix: "example.com/ports"},
+			expErr: true,
+		},
+	}
+
+	for _, currCase := range cases {
+		res, err := parseEndpointAnnotationsSettings(currCase.arg)
+		if !a.Equal(currCase.expErr, err != nil) || !a.Equal(currCase.expRes, res) {
+			a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
+		}
+	}
+}
+
+func TestParseNamespaceDefaultsSettings(t *testing.T) {
+	a := New(t)
+	cases := []struct {
+		id     string
+		arg    *types.NamespaceDefaultsSettings
+		expRes *types.NamespaceDefaultsSettings
+		expErr bool
+	}{
+		{
+			id:     "defaults",
+			arg:    &types.NamespaceDefaultsSettings{Enabled: true},
+			expRes: &types.NamespaceDefaultsSettings{Enabled: true, Prefix: defaultNamespaceDefaultsPrefix},
+		},
+		{
+			id:     "custom-prefix",
+			arg:    &types.NamespaceDefaultsSettings{Enabled: true, Prefix: "defaults.example.com"},
+			expRes: &types.NamespaceDefaultsSettings{Enabled: true, Prefix: "defaults.example.com"},
+		},
+		{
+			id:     "invalid-prefix",
+			arg:    &types.NamespaceDefaultsSettings{Enabled: true, Prefix: "Defaults"},
+			expErr: true,
+		},
+	}
+
+	for _, currCase := range cases {
+		res, err := parseNamespaceDefaultsSettings(currCase.arg)
+		if !a.Equal(currCase.expErr, err != nil) || !a.Equal(currCase.expRes, res) {
+			a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
+		}
+	}
+}
+
+func TestParseResyncSettings(t *testing.T) {
+	a := New(t)
+	cases := []struct {
+		id     string
+		arg    *types.ResyncSettings
+		expRes *types.ResyncSettings
+		expErr bool
+	}{
+		{
+			id:     "defaults",
+			arg:    &types.ResyncSettings{Enabled: true},
+			expRes: &types.ResyncSettings{Enabled: true, Period: defaultResyncPeriod},
+		},
+		{
+			id:     "custom-period",
+			arg:    &types.ResyncSettings{Enabled: true, Period: 30 * time.Minute},
+			expRes: &types.ResyncSettings{Enabled: true, Period: 30 * time.Minute},
+		},
+		{
+			id:     "too-short",
+			arg:    &types.ResyncSettings{Enabled: true, Period: 10 * time.Second},
+			expErr: true,
+		},
+	}
+
+	for _, currCase := range cases {
+		res, err := parseResyncSettings(currCase.arg)
+		if !a.Equal(currCase.expErr, err != nil) || !a.Equal(currCase.expRes, res) {
+			a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
+		}
+	}
+}
+
+func TestParseLoadBalancerHostnameSettings(t *testing.T) {
+	a := New(t)
+	cases := []struct {
+		id     string
+		arg    *types.LoadBalancerHostnameSettings
+		expRes *types.LoadBalancerHostnameSettings
+		expErr bool
+	}{
+		{
+			id:     "defaults",
+			arg:    &types.LoadBalancerHostnameSettings{},
+			expRes: &types.LoadBalancerHostnameSettings{Policy: types.LoadBalancerHostnameResolve, RefreshInterval: defaultHostnameRefreshInterval},
+		},
+		{
+			id:     "register",
+			arg:    &types.LoadBalancerHostnameSettings{Policy: types.LoadBalancerHostnameRegister},
+			expRes: &types.LoadBalancerHostnameSettings{Policy: types.LoadBalancerHostnameRegister, RefreshInterval: defaultHostnameRefreshInterval},
+		},
+		{
+			id:     "custom-interval",
+			arg:    &types.LoadBalancerHostnameSettings{RefreshInterval: 5 * time.Minute},
+			expRes: &types.LoadBalancerHostnameSettings{Policy: types.LoadBalancerHostnameResolve, RefreshInterval: 5 * time.Minute},
+		},
+		{
+			id:     "invalid-policy",
+			arg:    &types.LoadBalancerHostnameSettings{Policy: "Ignore"},
+			expErr: true,
+		},
+		{
+			id:     "too-short",
+			arg:    &types.LoadBalancerHostnameSettings{RefreshInterval: time.Second},
+			expErr: true,
+		},
+	}
+
+	for _, currCase := range cases {
+		res, err := parseLoadBalancerHostnameSettings(currCase.arg)
+		if !a.Equal(currCase.expErr, err != nil) || !a.Equal(currCase.expRes, res) {
+			a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
+		}
+	}
+}
+
+func TestParseLeaderElectionSettings(t *testing.T) {
+	a := New(t)
+	cases := []struct {
+		id     string
+		arg    *types.LeaderElectionSettings
+		expRes *types.LeaderElectionSettings
+		expErr bool
+	}{
+		{
+			id:     "defaults",
+			arg:    &types.LeaderElectionSettings{Enabled: true},
+			expRes: &types.LeaderElectionSettings{Enabled: true, LeaseDuration: defaultLeaseDuration, RenewDeadline: defaultRenewDeadline, RetryPeriod: defaultRetryPeriod},
+		},
+		{
+			id:     "custom-durations",
+			arg:    &types.LeaderElectionSettings{Enabled: true, LeaseDuration: 30 * time.Second, RenewDeadline: 20 * time.Second, RetryPeriod: 5 * time.Second},
+			expRes: &types.LeaderElectionSettings{Enabled: true, LeaseDuration: 30 * time.Second, RenewDeadline: 20 * time.Second, RetryPeriod: 5 * time.Second},
+		},
+		{
+			id:     "lease-not-longer-than-renew",
+			arg:    &types.LeaderElectionSettings{Enabled: true, LeaseDuration: 10 * time.Second},
+			expErr: true,
+		},
+		{
+			id:     "renew-too-short-for-retry",
+			arg:    &types.LeaderElectionSettings{Enabled: true, RenewDeadline: 5 * time.Second, RetryPeriod: 5 * time.Second},
+			expErr: true,
+		},
+		{
+			id:     "negative-retry",
+			arg:    &types.LeaderElectionSettings{Enabled: true, RetryPeriod: -time.Second},
+			expErr: true,
+		},
+	}
+
+	for _, currCase := range cases {
+		res, err := parseLeaderElectionSettings(currCase.arg)
+		if !a.Equal(currCase.expErr, err != nil) || !a.Equal(currCase.expRes, res) {
+			a.FailNow(fmt.Sprintf("case %s failed", currCase.id))
+		}
+	}
+}
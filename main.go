@@ -20,6 +20,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -35,6 +36,7 @@ import (
 	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -49,6 +51,7 @@ const (
 	defaultSdServAccPath string = "./credentials/gcloud-credentials.json"
 	defaultTimeout       int    = 30
 	defaultNsName        string = "cnwan-operator-system"
+	leaderElectionLease  string = "cnwan-operator-leader"
 
 	// Exit codes
 	Success int = iota
@@ -65,6 +68,10 @@ const (
 	CannotCreateServiceController
 	CannotCreateNamespaceController
 	CannotRunControllerManager
+	CannotCreateResyncer
+	CannotCreateGarbageCollector
+	CannotRunLeaderElection
+	LeadershipLost
 )
 
 var (
@@ -152,7 +159,8 @@ func run() (int, error) {
 	//--------------------------------------
 
 	var etcdClient *clientv3.Client
-	var servreg sr.ServiceRegistry
+	var servreg sr.ServiceRegistryV2
+	var servregType string
 
 	if settings.ServiceRegistrySettings.EtcdSettings != nil {
 		setupLog.Info("using etcd as a service registry...")
@@ -163,7 +171,8 @@ func run() (int, error) {
 
 		etcdClient = _cli
 		defer etcdClient.Close()
-		servreg = etcd.NewServiceRegistryWithEtcd(ctx, etcdClient, settings.EtcdSettings.Prefix)
+		servreg = etcd.NewServiceRegistryWithEtcdV2(etcdClient, settings.EtcdSettings.Prefix)
+		servregType = "etcd"
 	}
 
 	if settings.ServiceRegistrySettings.ServiceDirectorySettings != nil {
@@ -183,9 +192,9 @@ func run() (int, error) {
 			ProjectID:     sdSettings.ProjectID,
 			DefaultRegion: sdSettings.DefaultRegion,
 			Log:           setupLog.WithName("ServiceDirectory"),
-			Context:       ctx,
 			Client:        cli,
 		}
+		servregType = "servicedirectory"
 	}
 
 	if settings.ServiceRegistrySettings.CloudMapSettings != nil {
@@ -201,7 +210,8 @@ func run() (int, error) {
 			return CannotGetCloudMapClient, fmt.Errorf("cannot get cloud map client: %w", err)
 		}
 
-		servreg = cloudmap.NewHandler(ctx, cli, setupLog)
+		servreg = cloudmap.NewHandlerV2(cli, setupLog)
+		servregType = "cloudmap"
 	}
 
 	srBroker, err := sr.NewBroker(servreg, sr.MetadataPair{Key: opKey, Value: opVal}, persistentMeta...)
@@ -209,27 +219,91 @@ func run() (int, error) {
 		return CannotGetBroker, fmt.Errorf("cannot get service registry broker: %w", err)
 	}
 
+	var nodePortOpts *controllers.NodePortOptions
+	if settings.NodePort != nil {
+		nodePortOpts, err = getNodePortOptions(settings.NodePort)
+		if err != nil {
+			return SettingsValidationError, fmt.Errorf("invalid node port settings: %w", err)
+		}
+	}
+
+	hostnameSupported := srBroker.Capabilities().HostnameAddresses
+	if settings.LoadBalancerHostname != nil && settings.LoadBalancerHostname.Policy == types.LoadBalancerHostnameRegister && !hostnameSupported {
+		setupLog.Info("service registry does not support hostnames as addresses: load balancer hostnames will be resolved instead")
+	}
+	hostnameOpts := getHostnameOptions(settings.LoadBalancerHostname, hostnameSupported)
+
+	var statusOpts *controllers.StatusOptions
+	if settings.StatusAnnotations != nil {
+		statusOpts = &controllers.StatusOptions{RegistryType: servregType}
+	}
+
+	var endpAnnotationPrefix string
+	if settings.EndpointAnnotations != nil {
+		endpAnnotationPrefix = settings.EndpointAnnotations.Prefix
+	}
+	var nsDefaultsPrefix string
+	if settings.NamespaceDefaults != nil {
+		nsDefaultsPrefix = settings.NamespaceDefaults.Prefix
+	}
+
+	nsSelector, err := getSelector(settings.NamespaceSelector)
+	if err != nil {
+		return SettingsValidationError, fmt.Errorf("invalid namespace selector: %w", err)
+	}
+	servSelector, err := getSelector(settings.ServiceSelector)
+	if err != nil {
+		return SettingsValidationError, fmt.Errorf("invalid service selector: %w", err)
+	}
+
 	//--------------------------------------
 	// Init manager
 	//--------------------------------------
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	cfg := ctrl.GetConfigOrDie()
+	mgrOpts := ctrl.Options{
 		Scheme:             scheme,
 		LeaderElection:     false,
 		MetricsBindAddress: "0",
-	})
+	}
+	if settings.LeaderElection != nil {
+		// If the lease is lost, the manager must stop before a standby
+		// replica can acquire it.
+		shutdownTimeout := settings.LeaderElection.LeaseDuration - settings.LeaderElection.RenewDeadline
+		mgrOpts.GracefulShutdownTimeout = &shutdownTimeout
+	}
+
+	mgr, err := ctrl.NewManager(cfg, mgrOpts)
 	if err != nil {
 		return CannotGetControllerManager, fmt.Errorf("cannot create controller manager: %w", err)
 	}
 
-	if err = (&controllers.ServiceReconciler{
+	servReconciler := &controllers.ServiceReconciler{
 		Client:                   mgr.GetClient(),
 		Log:                      ctrl.Log.WithName("controllers").WithName("Service"),
 		Scheme:                   mgr.GetScheme(),
 		ServRegBroker:            srBroker,
 		WatchNamespacesByDefault: settings.WatchNamespacesByDefault,
+		NamespaceSelector:        nsSelector,
+		ServiceSelector:          servSelector,
 		AllowedAnnotations:       settings.Service.Annotations,
-	}).SetupWithManager(mgr); err != nil {
+		DeniedAnnotations:        settings.Service.DeniedAnnotations,
+		AllowedLabels:            settings.Service.Labels,
+		AllowedNsAnnotations:     settings.Namespace.Annotations,
+		DeniedNsAnnotations:      settings.Namespace.DeniedAnnotations,
+		AllowedEndpointMetadata:  settings.EndpointMetadata,
+		EndpointAnnotationPrefix: endpAnnotationPrefix,
+		NsDefaultsPrefix:         nsDefaultsPrefix,
+		MetadataRules:            getMetadataRules(settings.MetadataRules, servregType),
+		NodePort:                 nodePortOpts,
+		RegisterClusterIP:        settings.ClusterIP != nil,
+		LoadBalancerHostname:     hostnameOpts,
+		StatusAnnotations:        statusOpts,
+		UseFinalizer:             settings.Finalizers != nil,
+		Recorder:                 mgr.GetEventRecorderFor("cnwan-operator"),
+		MaxConcurrentReconciles:  settings.MaxConcurrentReconciles,
+	}
+	if err = servReconciler.SetupWithManager(mgr); err != nil {
 		return CannotCreateServiceController, fmt.Errorf("cannot create service controller: %w", err)
 	}
 
@@ -239,15 +313,80 @@ func run() (int, error) {
 		Scheme:                   mgr.GetScheme(),
 		ServRegBroker:            srBroker,
 		WatchNamespacesByDefault: settings.WatchNamespacesByDefault,
-		AllowedAnnotations:       settings.Service.Annotations,
+		NamespaceSelector:        nsSelector,
+		ServiceSelector:          servSelector,
+		Recorder:                 mgr.GetEventRecorderFor("cnwan-operator"),
+		MaxConcurrentReconciles:  settings.MaxConcurrentReconciles,
 	}).SetupWithManager(mgr); err != nil {
 		return CannotCreateNamespaceController, fmt.Errorf("cannot create namespace controller: %w", err)
 	}
+
+	if err := mgr.Add(&controllers.GarbageCollector{
+		Reconciler: servReconciler,
+		Log:        ctrl.Log.WithName("controllers").WithName("GarbageCollector"),
+	}); err != nil {
+		return CannotCreateGarbageCollector, fmt.Errorf("cannot create garbage collector: %w", err)
+	}
+
+	if settings.Resync != nil {
+		if err := mgr.Add(&controllers.Resyncer{
+			Reconciler: servReconciler,
+			Log:        ctrl.Log.WithName("controllers").WithName("Resync"),
+			Period:     settings.Resync.Period,
+		}); err != nil {
+			return CannotCreateResyncer, fmt.Errorf("cannot create resyncer: %w", err)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
-	setupLog.Info("starting controller manager...")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
-		return CannotRunControllerManager, fmt.Errorf("cannot run controller manager: %w", err)
+	stop := ctrl.SetupSignalHandler()
+	if settings.LeaderElection == nil {
+		setupLog.Info("starting controller manager...")
+		if err := mgr.Start(stop); err != nil {
+			return CannotRunControllerManager, fmt.Errorf("cannot run controller manager: %w", err)
+		}
+
+		return Success, nil
+	}
+
+	//--------------------------------------
+	// Run as leader
+	//--------------------------------------
+
+	// The election is performed here rather than by the manager, so that a
+	// Lease can be used as a lock. The manager only runs on the leader.
+	kcli, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return CannotRunLeaderElection, fmt.Errorf("cannot get kubernetes client for leader election: %w", err)
+	}
+
+	electCtx, electCanc := context.WithCancel(context.Background())
+	defer electCanc()
+	go func() {
+		<-stop
+		electCanc()
+	}()
+
+	var mgrErr error
+	setupLog.Info("waiting to be elected as leader...", "lease", nsName+"/"+leaderElectionLease)
+	err = cluster.RunAsLeader(electCtx, kcli, cluster.LeaderElectionOptions{
+		Namespace:     nsName,
+		Name:          leaderElectionLease,
+		LeaseDuration: settings.LeaderElection.LeaseDuration,
+		RenewDeadline: settings.LeaderElection.RenewDeadline,
+		RetryPeriod:   settings.LeaderElection.RetryPeriod,
+	}, func(stop <-chan struct{}) error {
+		setupLog.Info("elected as leader, starting controller manager...")
+		mgrErr = mgr.Start(stop)
+		return mgrErr
+	})
+	switch {
+	case mgrErr != nil:
+		return CannotRunControllerManager, fmt.Errorf("cannot run controller manager: %w", mgrErr)
+	case errors.Is(err, cluster.ErrLeadershipLost):
+		return LeadershipLost, fmt.Errorf("controller manager stopped: %w", err)
+	case err != nil:
+		return CannotRunLeaderElection, fmt.Errorf("cannot run leader election: %w", err)
 	}
 
 	return Success, nil
@@ -0,0 +1,202 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+var (
+	// ErrLeadershipLost is returned by RunAsLeader when the lease could not
+	// be renewed in time and another replica may have taken over.
+	ErrLeadershipLost = errors.New("leadership lost")
+)
+
+// LeaderElectionOptions contains options about how the leader is elected
+// among the replicas of the operator.
+type LeaderElectionOptions struct {
+	// Namespace and Name of the Lease used as a lock.
+	Namespace string
+	Name      string
+	// Identity of this replica. If empty, the hostname followed by a
+	// random suffix is used.
+	Identity string
+	// LeaseDuration is how long standby replicas wait before trying to
+	// acquire a lease that has not been renewed.
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader tries to renew the lease before
+	// giving up leadership.
+	RenewDeadline time.Duration
+	// RetryPeriod is how long to wait between two attempts to acquire or
+	// renew the lease.
+	RetryPeriod time.Duration
+}
+
+// RunAsLeader blocks until this replica becomes the leader and then calls
+// run, which must return as soon as its stop channel is closed.
+//
+// The stop channel is closed when the context is cancelled or when the
+// leadership is lost, in which case ErrLeadershipLost is returned. When the
+// context is cancelled, the lease is only released after run returns, so
+// that a standby replica can take over immediately without running at the
+// same time as this one. When the leadership is lost, the lease is not
+// released at all and standby replicas wait for it to expire.
+func RunAsLeader(ctx context.Context, cli kubernetes.Interface, opts LeaderElectionOptions, run func(stop <-chan struct{}) error) error {
+	if opts.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("cannot get identity for leader election: %w", err)
+		}
+		opts.Identity = hostname + "_" + string(uuid.NewUUID())
+	}
+
+	// The election is not stopped as soon as ctx is cancelled, because the
+	// lease must be held until run returns.
+	electCtx, stopElection := context.WithCancel(context.Background())
+	defer stopElection()
+
+	var (
+		lock     sync.Mutex
+		wg       sync.WaitGroup
+		leading  bool
+		finished bool
+		lost     bool
+		runErr   error
+	)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			lock.Lock()
+			if !leading {
+				stopElection()
+			}
+			lock.Unlock()
+		case <-electCtx.Done():
+		}
+	}()
+
+	leaseLock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: opts.Namespace,
+			Name:      opts.Name,
+		},
+		Client:     cli.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: opts.Identity},
+	}
+
+	// The lease is not released by the elector, as it would do it as soon
+	// as the lease cannot be renewed, while run may still be running.
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          leaseLock,
+		LeaseDuration: opts.LeaseDuration,
+		RenewDeadline: opts.RenewDeadline,
+		RetryPeriod:   opts.RetryPeriod,
+		Name:          opts.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leadCtx context.Context) {
+				// This runs on its own goroutine, which may be scheduled
+				// after the election has already been stopped.
+				lock.Lock()
+				if finished || electCtx.Err() != nil {
+					lock.Unlock()
+					return
+				}
+				leading = true
+				wg.Add(1)
+				lock.Unlock()
+				defer wg.Done()
+
+				stop := make(chan struct{})
+				go func() {
+					select {
+					case <-ctx.Done():
+					case <-leadCtx.Done():
+					}
+					close(stop)
+				}()
+
+				err := run(stop)
+
+				lock.Lock()
+				runErr = err
+				lost = leadCtx.Err() != nil && ctx.Err() == nil
+				lock.Unlock()
+
+				// Now it is safe to stop renewing the lease.
+				stopElection()
+			},
+			OnStoppedLeading: func() {},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create leader elector: %w", err)
+	}
+
+	// Run returns when the election is stopped or when the lease could not
+	// be renewed in time.
+	elector.Run(electCtx)
+
+	lock.Lock()
+	finished = true
+	lock.Unlock()
+	wg.Wait()
+
+	if leading && !lost {
+		// If this fails, standby replicas wait for the lease to expire.
+		_ = releaseLease(leaseLock, opts.RenewDeadline)
+	}
+
+	switch {
+	case runErr != nil:
+		return runErr
+	case lost:
+		return ErrLeadershipLost
+	default:
+		return nil
+	}
+}
+
+// releaseLease releases the lease if it is still held by this replica, so
+// that a standby replica can acquire it without waiting for it to expire.
+func releaseLease(leaseLock *resourcelock.LeaseLock, timeout time.Duration) error {
+	ctx, canc := context.WithTimeout(context.Background(), timeout)
+	defer canc()
+
+	record, _, err := leaseLock.Get(ctx)
+	if err != nil {
+		return err
+	}
+	if record.HolderIdentity != leaseLock.Identity() {
+		return nil
+	}
+
+	return leaseLock.Update(ctx, resourcelock.LeaderElectionRecord{LeaderTransitions: record.LeaderTransitions})
+}
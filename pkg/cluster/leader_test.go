@@ -0,0 +1,121 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRunAsLeader(t *testing.T) {
+	opts := LeaderElectionOptions{
+		Namespace:     "ns",
+		Name:          "lease",
+		Identity:      "me",
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: time.Second,
+		RetryPeriod:   100 * time.Millisecond,
+	}
+	a := assert.New(t)
+
+	// The lease is held until run returns and then released
+	cli := fake.NewSimpleClientset()
+	ctx, canc := context.WithCancel(context.Background())
+	holder := ""
+	err := RunAsLeader(ctx, cli, opts, func(stop <-chan struct{}) error {
+		lease, err := cli.CoordinationV1().Leases("ns").Get(context.Background(), "lease", metav1.GetOptions{})
+		if a.NoError(err) {
+			holder = *lease.Spec.HolderIdentity
+		}
+
+		canc()
+		<-stop
+		return nil
+	})
+	a.NoError(err)
+	a.Equal("me", holder)
+	lease, err := cli.CoordinationV1().Leases("ns").Get(context.Background(), "lease", metav1.GetOptions{})
+	a.NoError(err)
+	a.Empty(*lease.Spec.HolderIdentity)
+
+	// Errors returned by run are returned as well
+	runErr := fmt.Errorf("any")
+	err = RunAsLeader(context.Background(), fake.NewSimpleClientset(), opts, func(stop <-chan struct{}) error {
+		return runErr
+	})
+	a.Equal(runErr, err)
+
+	// The lease is not released if it cannot be renewed
+	cli = fake.NewSimpleClientset()
+	var failRenewals int32
+	cli.PrependReactor("update", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lease := action.(k8stesting.UpdateAction).GetObject().(*coordinationv1.Lease)
+		if atomic.LoadInt32(&failRenewals) == 1 && lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != "" {
+			// The elector keeps the returned lease, so that it could
+			// still release it.
+			return true, lease, fmt.Errorf("any")
+		}
+		return false, nil, nil
+	})
+	err = RunAsLeader(context.Background(), cli, opts, func(stop <-chan struct{}) error {
+		atomic.StoreInt32(&failRenewals, 1)
+		<-stop
+		lease, err := cli.CoordinationV1().Leases("ns").Get(context.Background(), "lease", metav1.GetOptions{})
+		if a.NoError(err) {
+			holder = *lease.Spec.HolderIdentity
+		}
+		return nil
+	})
+	a.Equal(ErrLeadershipLost, err)
+	a.Equal("me", holder)
+	lease, err = cli.CoordinationV1().Leases("ns").Get(context.Background(), "lease", metav1.GetOptions{})
+	a.NoError(err)
+	a.Equal("me", *lease.Spec.HolderIdentity)
+
+	// Standby replicas never run until the lease expires
+	other, leaseDuration, now := "other", int32(60), metav1.NewMicroTime(time.Now())
+	cli = fake.NewSimpleClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "lease"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &other,
+			LeaseDurationSeconds: &leaseDuration,
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	})
+	ctx, canc = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer canc()
+	ran := false
+	err = RunAsLeader(ctx, cli, opts, func(stop <-chan struct{}) error {
+		ran = true
+		return nil
+	})
+	a.NoError(err)
+	a.False(ran)
+}
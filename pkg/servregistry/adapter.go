@@ -0,0 +1,168 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package servregistry
+
+import "context"
+
+// AdaptV1 returns a ServiceRegistryV2 that performs its operations on the
+// provided ServiceRegistry, so that implementations that don't support
+// contexts can still be used by the Broker.
+//
+// As requests cannot be stopped once they have been sent, the context is
+// only checked before sending them: no request is sent if the context is
+// already done.
+//
+// If the provided ServiceRegistry implements ServiceIDGetter, so does the
+// returned one.
+func AdaptV1(reg ServiceRegistry) ServiceRegistryV2 {
+	if reg == nil {
+		return nil
+	}
+
+	if idGetter, ok := reg.(ServiceIDGetter); ok {
+		return &v1IDAdapter{v1Adapter{reg}, idGetter}
+	}
+
+	return &v1Adapter{reg}
+}
+
+type v1Adapter struct {
+	reg ServiceRegistry
+}
+
+// v1IDAdapter is a v1Adapter of a ServiceRegistry that implements
+// ServiceIDGetter. It is a different type, so that the Broker does not
+// think that other service registries provide IDs.
+type v1IDAdapter struct {
+	v1Adapter
+	idGetter ServiceIDGetter
+}
+
+func (a *v1IDAdapter) GetServID(ctx context.Context, nsName, servName string) (string, error) {
+	return a.idGetter.GetServID(ctx, nsName, servName)
+}
+
+func (a *v1Adapter) Capabilities() Capabilities {
+	return CapabilitiesOf(a.reg)
+}
+
+func (a *v1Adapter) GetNs(ctx context.Context, name string) (*Namespace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.GetNs(name)
+}
+
+func (a *v1Adapter) ListNs(ctx context.Context) ([]*Namespace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.ListNs()
+}
+
+func (a *v1Adapter) CreateNs(ctx context.Context, ns *Namespace) (*Namespace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.CreateNs(ns)
+}
+
+func (a *v1Adapter) UpdateNs(ctx context.Context, ns *Namespace) (*Namespace, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.UpdateNs(ns)
+}
+
+func (a *v1Adapter) DeleteNs(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.reg.DeleteNs(name)
+}
+
+func (a *v1Adapter) GetServ(ctx context.Context, nsName, servName string) (*Service, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.GetServ(nsName, servName)
+}
+
+func (a *v1Adapter) ListServ(ctx context.Context, nsName string) ([]*Service, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.ListServ(nsName)
+}
+
+func (a *v1Adapter) CreateServ(ctx context.Context, serv *Service) (*Service, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.CreateServ(serv)
+}
+
+func (a *v1Adapter) UpdateServ(ctx context.Context, serv *Service) (*Service, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.UpdateServ(serv)
+}
+
+func (a *v1Adapter) DeleteServ(ctx context.Context, nsName, servName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.reg.DeleteServ(nsName, servName)
+}
+
+func (a *v1Adapter) GetEndp(ctx context.Context, nsName, servName, endpName string) (*Endpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.GetEndp(nsName, servName, endpName)
+}
+
+func (a *v1Adapter) ListEndp(ctx context.Context, nsName, servName string) ([]*Endpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.ListEndp(nsName, servName)
+}
+
+func (a *v1Adapter) CreateEndp(ctx context.Context, endp *Endpoint) (*Endpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.CreateEndp(endp)
+}
+
+func (a *v1Adapter) UpdateEndp(ctx context.Context, endp *Endpoint) (*Endpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.reg.UpdateEndp(endp)
+}
+
+func (a *v1Adapter) DeleteEndp(ctx context.Context, nsName, servName, endpName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.reg.DeleteEndp(nsName, servName, endpName)
+}
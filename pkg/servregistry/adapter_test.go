@@ -0,0 +1,71 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package servregistry
+
+import (
+	"context"
+	"testing"
+
+	a "github.com/stretchr/testify/assert"
+)
+
+func TestAdaptV1(t *testing.T) {
+	assert := a.New(t)
+	assert.Nil(AdaptV1(nil))
+
+	f := newFakeStruct()
+	reg := AdaptV1(f)
+
+	ns, err := reg.CreateNs(context.Background(), &Namespace{Name: "ns"})
+	assert.NoError(err)
+	assert.Equal("ns", ns.Name)
+	assert.Contains(f.nsList, "ns")
+
+	// Nothing is sent if the context is already done
+	ctx, canc := context.WithCancel(context.Background())
+	canc()
+	ns, err = reg.CreateNs(ctx, &Namespace{Name: "other"})
+	assert.Nil(ns)
+	assert.Equal(context.Canceled, err)
+	assert.NotContains(f.nsList, "other")
+
+	err = reg.DeleteNs(ctx, "ns")
+	assert.Equal(context.Canceled, err)
+	assert.Contains(f.nsList, "ns")
+
+	// Service IDs are only provided if the wrapped registry provides them
+	_, ok := reg.(ServiceIDGetter)
+	assert.False(ok)
+
+	idGetter, ok := AdaptV1(&fakeIDStruct{f}).(ServiceIDGetter)
+	if assert.True(ok) {
+		id, err := idGetter.GetServID(context.Background(), "ns", "serv")
+		assert.NoError(err)
+		assert.Equal("ns/serv", id)
+	}
+}
+
+// fakeIDStruct is a ServiceRegistry that also provides IDs of services.
+type fakeIDStruct struct {
+	*fakeServReg
+}
+
+func (f *fakeIDStruct) GetServID(_ context.Context, nsName, servName string) (string, error) {
+	return nsName + "/" + servName, nil
+}
@@ -30,11 +30,11 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
 )
 
-func (h *Handler) listOrGetEndpoint(nsName, servName string, epName *string) ([]*sr.Endpoint, error) {
-	ctx, canc := context.WithTimeout(h.mainCtx, defaultTimeout)
+func (h *Handler) listOrGetEndpoint(ctx context.Context, nsName, servName string, epName *string) ([]*sr.Endpoint, error) {
+	reqCtx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
-	instList, err := h.Client.DiscoverInstances(ctx, &servicediscovery.DiscoverInstancesInput{
+	instList, err := h.Client.DiscoverInstances(reqCtx, &servicediscovery.DiscoverInstancesInput{
 		NamespaceName: aws.String(nsName),
 		ServiceName:   aws.String(servName),
 	})
@@ -94,7 +94,7 @@ func (h *Handler) listOrGetEndpoint(nsName, servName string, epName *string) ([]
 }
 
 // GetEndp returns the endpoint if exists.
-func (h *Handler) GetEndp(nsName, servName, endpName string) (*sr.Endpoint, error) {
+func (h *Handler) GetEndp(ctx context.Context, nsName, servName, endpName string) (*sr.Endpoint, error) {
 	if nsName == "" {
 		return nil, sr.ErrNsNameNotProvided
 	}
@@ -102,7 +102,7 @@ func (h *Handler) GetEndp(nsName, servName, endpName string) (*sr.Endpoint, erro
 		return nil, sr.ErrServNameNotProvided
 	}
 
-	list, err := h.listOrGetEndpoint(nsName, servName, &endpName)
+	list, err := h.listOrGetEndpoint(ctx, nsName, servName, &endpName)
 	if err != nil {
 
 		return nil, err
@@ -115,7 +115,7 @@ func (h *Handler) GetEndp(nsName, servName, endpName string) (*sr.Endpoint, erro
 }
 
 // ListServ returns a list of services inside the provided namespace.
-func (h *Handler) ListEndp(nsName, servName string) (endpList []*sr.Endpoint, err error) {
+func (h *Handler) ListEndp(ctx context.Context, nsName, servName string) (endpList []*sr.Endpoint, err error) {
 	if nsName == "" {
 		return nil, sr.ErrNsNameNotProvided
 	}
@@ -123,11 +123,11 @@ func (h *Handler) ListEndp(nsName, servName string) (endpList []*sr.Endpoint, er
 		return nil, sr.ErrServNameNotProvided
 	}
 
-	return h.listOrGetEndpoint(nsName, servName, nil)
+	return h.listOrGetEndpoint(ctx, nsName, servName, nil)
 }
 
 // CreateEndp creates the endpoint.
-func (h *Handler) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
+func (h *Handler) CreateEndp(ctx context.Context, endp *sr.Endpoint) (*sr.Endpoint, error) {
 	if endp == nil {
 		return nil, sr.ErrEndpNotProvided
 	}
@@ -147,7 +147,7 @@ func (h *Handler) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 		return nil, fmt.Errorf("invalid port provided")
 	}
 
-	_, nsID, err := h.listOrGetNamespace(&endp.NsName)
+	_, nsID, err := h.listOrGetNamespace(ctx, &endp.NsName)
 	if err != nil {
 		return nil, err
 	}
@@ -155,7 +155,7 @@ func (h *Handler) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 		return nil, sr.ErrNotFound
 	}
 
-	_, servID, err := h.listOrGetService(endp.NsName, &endp.ServName)
+	_, servID, err := h.listOrGetService(ctx, endp.NsName, &endp.ServName)
 	if err != nil {
 		return nil, err
 	}
@@ -167,9 +167,9 @@ func (h *Handler) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 	attributes["AWS_INSTANCE_PORT"] = fmt.Sprintf("%d", endp.Port)
 	attributes["AWS_INSTANCE_IPV4"] = endp.Address
 
-	ctx, canc := context.WithTimeout(h.mainCtx, defaultTimeout)
+	reqCtx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
-	out, err := h.Client.RegisterInstance(ctx, &servicediscovery.RegisterInstanceInput{
+	out, err := h.Client.RegisterInstance(reqCtx, &servicediscovery.RegisterInstanceInput{
 		ServiceId:  aws.String(servID[0].id),
 		InstanceId: aws.String(endp.Name),
 		Attributes: attributes,
@@ -187,7 +187,7 @@ func (h *Handler) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 	l := h.log.WithName("CreateInstance")
 
 	l.Info("waiting for operation to complete...")
-	if err := h.pollOperationStatus(aws.ToString(out.OperationId)); err != nil {
+	if err := h.pollOperationStatus(ctx, aws.ToString(out.OperationId)); err != nil {
 		l.Info("operation completed with error")
 		return nil, err
 	}
@@ -197,12 +197,12 @@ func (h *Handler) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 }
 
 // UpdateEndp updates the endpoint.
-func (h *Handler) UpdateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
-	return h.CreateEndp(endp)
+func (h *Handler) UpdateEndp(ctx context.Context, endp *sr.Endpoint) (*sr.Endpoint, error) {
+	return h.CreateEndp(ctx, endp)
 }
 
 // DeleteEndp deletes the endpoint.
-func (h *Handler) DeleteEndp(nsName, servName, endpName string) error {
+func (h *Handler) DeleteEndp(ctx context.Context, nsName, servName, endpName string) error {
 	if nsName == "" {
 		return sr.ErrNsNameNotProvided
 	}
@@ -213,7 +213,7 @@ func (h *Handler) DeleteEndp(nsName, servName, endpName string) error {
 		return sr.ErrEndpNameNotProvided
 	}
 
-	_, nsID, err := h.listOrGetNamespace(&nsName)
+	_, nsID, err := h.listOrGetNamespace(ctx, &nsName)
 	if err != nil {
 		return err
 	}
@@ -221,7 +221,7 @@ func (h *Handler) DeleteEndp(nsName, servName, endpName string) error {
 		return sr.ErrNotFound
 	}
 
-	_, servID, err := h.listOrGetService(nsName, &servName)
+	_, servID, err := h.listOrGetService(ctx, nsName, &servName)
 	if err != nil {
 		return err
 	}
@@ -229,10 +229,10 @@ func (h *Handler) DeleteEndp(nsName, servName, endpName string) error {
 		return sr.ErrNotFound
 	}
 
-	ctx, canc := context.WithTimeout(h.mainCtx, defaultTimeout)
+	reqCtx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
-	out, err := h.Client.DeregisterInstance(ctx, &servicediscovery.DeregisterInstanceInput{
+	out, err := h.Client.DeregisterInstance(reqCtx, &servicediscovery.DeregisterInstanceInput{
 		ServiceId:  aws.String(servID[0].id),
 		InstanceId: aws.String(endpName),
 	})
@@ -254,7 +254,7 @@ func (h *Handler) DeleteEndp(nsName, servName, endpName string) error {
 	l := h.log.WithName("DeleteInstance")
 
 	l.Info("waiting for operation to complete...")
-	if err := h.pollOperationStatus(aws.ToString(out.OperationId)); err != nil {
+	if err := h.pollOperationStatus(ctx, aws.ToString(out.OperationId)); err != nil {
 		l.Info("operation completed with error")
 		return err
 	}
@@ -143,9 +143,9 @@ func TestListOrGetEndpoint(t *testing.T) {
 	}
 
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, err := h.listOrGetEndpoint(c.nsName, c.servName, c.epName)
+		res, err := h.listOrGetEndpoint(context.Background(), c.nsName, c.servName, c.epName)
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
 		}
@@ -253,9 +253,9 @@ func TestGetEndp(t *testing.T) {
 	}
 
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, err := h.GetEndp(c.nsName, c.servName, c.endpName)
+		res, err := h.GetEndp(context.Background(), c.nsName, c.servName, c.endpName)
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
 		}
@@ -354,9 +354,9 @@ func TestListEndp(t *testing.T) {
 	}
 
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, err := h.ListEndp(c.nsName, c.servName)
+		res, err := h.ListEndp(context.Background(), c.nsName, c.servName)
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
 		}
@@ -515,9 +515,9 @@ func TestCreateEndp(t *testing.T) {
 	}
 
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, err := h.CreateEndp(c.endp)
+		res, err := h.CreateEndp(context.Background(), c.endp)
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
 		}
@@ -683,9 +683,9 @@ func TestDeleteEndp(t *testing.T) {
 	}
 
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		if !a.Equal(c.expErr, h.DeleteEndp(c.nsName, c.servName, c.endpName)) {
+		if !a.Equal(c.expErr, h.DeleteEndp(context.Background(), c.nsName, c.servName, c.endpName)) {
 			a.FailNow("case failed", "case", i)
 		}
 	}
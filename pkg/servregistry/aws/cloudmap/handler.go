@@ -20,22 +20,39 @@ package cloudmap
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
-	"fmt"
+	"regexp"
 	"time"
 
 	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
 	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
 	"github.com/go-logr/logr"
-	corev1 "k8s.io/api/core/v1"
 )
 
 const (
 	defaultTimeout time.Duration = time.Minute
+
+	// Limits of tags, used as metadata of namespaces and services.
+	maxTags           int = 50
+	maxTagKeyLength   int = 128
+	maxTagValueLength int = 256
+
+	// Limits of instance attributes, used as metadata of endpoints. Two of
+	// them are used for the address and the port of the endpoint.
+	maxAttributes           int = 30 - 2
+	maxAttributeKeyLength   int = 255
+	maxAttributeValueLength int = 1024
+	maxAttributesSize       int = 5000 - len("AWS_INSTANCE_IPV4") - len("255.255.255.255") - len("AWS_INSTANCE_PORT") - len("65535")
+
+	// maxEndpNameLength is the maximum length of the ID of instances, used
+	// as names of endpoints.
+	maxEndpNameLength int = 64
 )
 
 var (
+	tagKeyPattern       = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]+$`)
+	attributeKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9!-~]+$`)
+	endpNamePattern     = regexp.MustCompile(`^[0-9a-zA-Z_/:.@-]+$`)
+
 	// pollOperationFrequency is the time betwenn two consecutive
 	// pollOperationResult calls.
 	pollOperationFrequency time.Duration = 2 * time.Second
@@ -51,81 +68,48 @@ type cloudMapIDs struct {
 // performed in AWS Cloud Map.
 type Handler struct {
 	Client cloudMapClientIface
-	// TODO: on next versions the context will be provided explicitly for
-	// each call.
-	mainCtx context.Context
-	log     logr.Logger
+	log    logr.Logger
+}
+
+// NewHandlerV2 returns a new instance of the Handler.
+func NewHandlerV2(client *servicediscovery.Client, log logr.Logger) *Handler {
+	return &Handler{client, log}
 }
 
 // NewHandler returns a new instance of the Handler.
+//
+// Deprecated: use NewHandlerV2 instead. The context is ignored, as the one
+// passed to each method is used instead.
 func NewHandler(ctx context.Context, client *servicediscovery.Client, log logr.Logger) *Handler {
-	return &Handler{client, ctx, log}
+	return NewHandlerV2(client, log)
 }
 
-func (h *Handler) ExtractData(ns *corev1.Namespace, serv *corev1.Service) (*sr.Namespace, *sr.Service, []*sr.Endpoint, error) {
-	// NOTE: on future versions, this function will be removed from service
-	// registry and moved to the broker instead: it's not this package's job
-	// to convert structs.
-	if ns == nil {
-		return nil, nil, nil, sr.ErrNsNotProvided
-	}
-	if serv == nil {
-		return nil, nil, nil, sr.ErrServNotProvided
-	}
-
-	// Parse the namespace
-	namespaceData := &sr.Namespace{
-		Name:     ns.Name,
-		Metadata: ns.Annotations,
-	}
-	if namespaceData.Metadata == nil {
-		namespaceData.Metadata = map[string]string{}
-	}
-
-	// Parse the service
-	// NOTE: we put metadata on the service in service directory,
-	// not on the endpoints
-	serviceData := &sr.Service{
-		Name:     serv.Name,
-		NsName:   ns.Name,
-		Metadata: serv.Annotations,
-	}
-	if serviceData.Metadata == nil {
-		serviceData.Metadata = map[string]string{}
+// Capabilities returns what Cloud Map supports. Namespaces and services are
+// deleted only when they are empty and endpoints can only have an IPv4
+// address.
+func (h *Handler) Capabilities() sr.Capabilities {
+	tagLimits := sr.MetadataLimits{
+		MaxKeys:        maxTags,
+		MaxKeyLength:   maxTagKeyLength,
+		MaxValueLength: maxTagValueLength,
+		KeyPattern:     tagKeyPattern,
 	}
 
-	// Get the endpoints from the service
-	// First, build the ips
-	ips := []string{}
-	ips = append(ips, serv.Spec.ExternalIPs...)
-
-	// Get data from load balancers
-	for _, ing := range serv.Status.LoadBalancer.Ingress {
-		ips = append(ips, ing.IP)
+	return sr.Capabilities{
+		NsMetadata:         true,
+		EndpMetadata:       true,
+		NsMetadataLimits:   tagLimits,
+		ServMetadataLimits: tagLimits,
+		EndpMetadataLimits: sr.MetadataLimits{
+			MaxKeys:        maxAttributes,
+			MaxKeyLength:   maxAttributeKeyLength,
+			MaxValueLength: maxAttributeValueLength,
+			MaxSize:        maxAttributesSize,
+			KeyPattern:     attributeKeyPattern,
+		},
+		EndpNameLimits: sr.NameLimits{
+			MaxLength: maxEndpNameLength,
+			Pattern:   endpNamePattern,
+		},
 	}
-
-	endpointsData := []*sr.Endpoint{}
-	for _, port := range serv.Spec.Ports {
-		for _, ip := range ips {
-
-			// Create an hashed name for this
-			toBeHashed := fmt.Sprintf("%s-%d", ip, port.Port)
-			h := sha256.New()
-			h.Write([]byte(toBeHashed))
-			hash := hex.EncodeToString(h.Sum(nil))
-
-			// Only take the first 10 characters of the hashed name
-			name := fmt.Sprintf("%s-%s", serv.Name, hash[:10])
-			endpointsData = append(endpointsData, &sr.Endpoint{
-				Name:     name,
-				NsName:   namespaceData.Name,
-				ServName: serviceData.Name,
-				Address:  ip,
-				Port:     port.Port,
-				Metadata: map[string]string{},
-			})
-		}
-	}
-
-	return namespaceData, serviceData, endpointsData, nil
 }
@@ -30,7 +30,7 @@ import (
 )
 
 // CreateNs creates the namespace.
-func (h *Handler) CreateNs(ns *sr.Namespace) (*sr.Namespace, error) {
+func (h *Handler) CreateNs(ctx context.Context, ns *sr.Namespace) (*sr.Namespace, error) {
 	if ns == nil {
 		return nil, sr.ErrNsNotProvided
 	}
@@ -39,10 +39,10 @@ func (h *Handler) CreateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 	}
 
 	opID, err := func() (string, error) {
-		ctx, canc := context.WithTimeout(h.mainCtx, defaultTimeout)
+		reqCtx, canc := context.WithTimeout(ctx, defaultTimeout)
 		defer canc()
 
-		out, err := h.Client.CreateHttpNamespace(ctx, &servicediscovery.CreateHttpNamespaceInput{
+		out, err := h.Client.CreateHttpNamespace(reqCtx, &servicediscovery.CreateHttpNamespaceInput{
 			Name: aws.String(ns.Name),
 			Tags: fromMapToTagsSlice(ns.Metadata),
 		})
@@ -65,7 +65,7 @@ func (h *Handler) CreateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 	l := h.log.WithName("CreateNamespace")
 
 	l.Info("waiting for operation to complete...")
-	if err := h.pollOperationStatus(opID); err != nil {
+	if err := h.pollOperationStatus(ctx, opID); err != nil {
 		l.Info("operation completed with error")
 		return nil, err
 	}
@@ -74,9 +74,9 @@ func (h *Handler) CreateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 	return ns, nil
 }
 
-func (h *Handler) listOrGetNamespace(name *string) ([]*sr.Namespace, []*cloudMapIDs, error) {
-	ctx, canc := context.WithTimeout(h.mainCtx, defaultTimeout)
-	out, err := h.Client.ListNamespaces(ctx, &servicediscovery.ListNamespacesInput{})
+func (h *Handler) listOrGetNamespace(ctx context.Context, name *string) ([]*sr.Namespace, []*cloudMapIDs, error) {
+	reqCtx, canc := context.WithTimeout(ctx, defaultTimeout)
+	out, err := h.Client.ListNamespaces(reqCtx, &servicediscovery.ListNamespacesInput{})
 	if err != nil {
 		canc()
 		return nil, nil, err
@@ -99,7 +99,7 @@ func (h *Handler) listOrGetNamespace(name *string) ([]*sr.Namespace, []*cloudMap
 		}
 
 		nsItem.Metadata = func() map[string]string {
-			tagCtx, tagCanc := context.WithTimeout(h.mainCtx, 30*time.Second)
+			tagCtx, tagCanc := context.WithTimeout(ctx, 30*time.Second)
 			defer tagCanc()
 
 			tags, err := h.Client.ListTagsForResource(tagCtx, &servicediscovery.ListTagsForResourceInput{
@@ -127,18 +127,18 @@ func (h *Handler) listOrGetNamespace(name *string) ([]*sr.Namespace, []*cloudMap
 }
 
 // ListNs returns a list of all namespaces.
-func (h *Handler) ListNs() (nsList []*sr.Namespace, err error) {
-	nsList, _, err = h.listOrGetNamespace(nil)
+func (h *Handler) ListNs(ctx context.Context) (nsList []*sr.Namespace, err error) {
+	nsList, _, err = h.listOrGetNamespace(ctx, nil)
 	return
 }
 
 // GetNs returns the namespace if exists.
-func (h *Handler) GetNs(name string) (*sr.Namespace, error) {
+func (h *Handler) GetNs(ctx context.Context, name string) (*sr.Namespace, error) {
 	if name == "" {
 		return nil, sr.ErrNsNameNotProvided
 	}
 
-	list, _, err := h.listOrGetNamespace(&name)
+	list, _, err := h.listOrGetNamespace(ctx, &name)
 	if err != nil {
 		return nil, err
 	}
@@ -151,7 +151,7 @@ func (h *Handler) GetNs(name string) (*sr.Namespace, error) {
 }
 
 // GetNs returns the namespace if exists.
-func (h *Handler) UpdateNs(ns *sr.Namespace) (*sr.Namespace, error) {
+func (h *Handler) UpdateNs(ctx context.Context, ns *sr.Namespace) (*sr.Namespace, error) {
 	if ns == nil {
 		return nil, sr.ErrNsNotProvided
 	}
@@ -159,7 +159,7 @@ func (h *Handler) UpdateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 		return nil, sr.ErrNsNameNotProvided
 	}
 
-	_, ids, err := h.listOrGetNamespace(&ns.Name)
+	_, ids, err := h.listOrGetNamespace(ctx, &ns.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +168,7 @@ func (h *Handler) UpdateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 		return nil, sr.ErrNotFound
 	}
 
-	err = h.tagResource(ids[0].arn, fromMapToTagsSlice(ns.Metadata))
+	err = h.tagResource(ctx, ids[0].arn, fromMapToTagsSlice(ns.Metadata))
 	if err == nil {
 		return ns, nil
 	}
@@ -182,12 +182,12 @@ func (h *Handler) UpdateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 }
 
 // GetNs returns the namespace if exists.
-func (h *Handler) DeleteNs(name string) error {
+func (h *Handler) DeleteNs(ctx context.Context, name string) error {
 	if name == "" {
 		return sr.ErrNsNameNotProvided
 	}
 
-	_, ids, err := h.listOrGetNamespace(&name)
+	_, ids, err := h.listOrGetNamespace(ctx, &name)
 	if err != nil {
 		return err
 	}
@@ -196,10 +196,10 @@ func (h *Handler) DeleteNs(name string) error {
 		return sr.ErrNotFound
 	}
 
-	ctx, canc := context.WithTimeout(h.mainCtx, time.Minute)
+	reqCtx, canc := context.WithTimeout(ctx, time.Minute)
 	defer canc()
 
-	out, err := h.Client.DeleteNamespace(ctx, &servicediscovery.DeleteNamespaceInput{
+	out, err := h.Client.DeleteNamespace(reqCtx, &servicediscovery.DeleteNamespaceInput{
 		Id: aws.String(ids[0].id),
 	})
 	if err != nil {
@@ -216,7 +216,7 @@ func (h *Handler) DeleteNs(name string) error {
 	l := h.log.WithName("DeleteNamespace")
 
 	l.Info("waiting for operation to complete...")
-	if err := h.pollOperationStatus(aws.ToString(out.OperationId)); err != nil {
+	if err := h.pollOperationStatus(ctx, aws.ToString(out.OperationId)); err != nil {
 		l.Info("operation completed with error")
 		return err
 	}
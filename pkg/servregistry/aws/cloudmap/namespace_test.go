@@ -48,8 +48,7 @@ func TestCreateNs(t *testing.T) {
 		{
 			ns: &sr.Namespace{Name: "whatever"},
 			h: &Handler{
-				log:     ctrl.Log.WithName("test"),
-				mainCtx: context.Background(),
+				log: ctrl.Log.WithName("test"),
 				Client: &fakeCloudMapClient{
 					_CreateHttpNamespace: func(ctx context.Context, params *servicediscovery.CreateHttpNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.CreateHttpNamespaceOutput, error) {
 						return nil, &types.NamespaceAlreadyExists{}
@@ -61,8 +60,7 @@ func TestCreateNs(t *testing.T) {
 		{
 			ns: &sr.Namespace{Name: "whatever"},
 			h: &Handler{
-				log:     ctrl.Log.WithName("test"),
-				mainCtx: context.Background(),
+				log: ctrl.Log.WithName("test"),
 				Client: &fakeCloudMapClient{
 					_CreateHttpNamespace: func(ctx context.Context, params *servicediscovery.CreateHttpNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.CreateHttpNamespaceOutput, error) {
 						return nil, fmt.Errorf("whatever-error")
@@ -74,8 +72,7 @@ func TestCreateNs(t *testing.T) {
 		{
 			ns: &sr.Namespace{Name: "whatever", Metadata: map[string]string{"key": "val"}},
 			h: &Handler{
-				log:     ctrl.Log.WithName("test"),
-				mainCtx: context.Background(),
+				log: ctrl.Log.WithName("test"),
 				Client: &fakeCloudMapClient{
 					_CreateHttpNamespace: func(ctx context.Context, params *servicediscovery.CreateHttpNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.CreateHttpNamespaceOutput, error) {
 						if aws.ToString(params.Name) != "whatever" {
@@ -103,8 +100,7 @@ func TestCreateNs(t *testing.T) {
 		{
 			ns: &sr.Namespace{Name: "whatever", Metadata: map[string]string{"key": "val"}},
 			h: &Handler{
-				log:     ctrl.Log.WithName("test"),
-				mainCtx: context.Background(),
+				log: ctrl.Log.WithName("test"),
 				Client: &fakeCloudMapClient{
 					_CreateHttpNamespace: func(ctx context.Context, params *servicediscovery.CreateHttpNamespaceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.CreateHttpNamespaceOutput, error) {
 						return &servicediscovery.CreateHttpNamespaceOutput{OperationId: aws.String("abc123")}, nil
@@ -120,7 +116,7 @@ func TestCreateNs(t *testing.T) {
 
 	a := assert.New(t)
 	for i, c := range cases {
-		res, err := c.h.CreateNs(c.ns)
+		res, err := c.h.CreateNs(context.Background(), c.ns)
 		_ = res
 
 		if !a.Equal(c.expErr, err) {
@@ -253,9 +249,9 @@ func TestListOrGetNamespace(t *testing.T) {
 
 	a := assert.New(t)
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, ids, err := h.listOrGetNamespace(c.nsName)
+		res, ids, err := h.listOrGetNamespace(context.Background(), c.nsName)
 
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expIDs, ids) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
@@ -317,9 +313,9 @@ func TestGetNs(t *testing.T) {
 
 	a := assert.New(t)
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, err := h.GetNs(c.name)
+		res, err := h.GetNs(context.Background(), c.name)
 
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
@@ -423,9 +419,9 @@ func TestUpdateNs(t *testing.T) {
 
 	a := assert.New(t)
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, err := h.UpdateNs(c.ns)
+		res, err := h.UpdateNs(context.Background(), c.ns)
 
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
@@ -543,9 +539,9 @@ func TestDeleteNs(t *testing.T) {
 
 	a := assert.New(t)
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		if !a.Equal(c.expErr, h.DeleteNs(c.name)) {
+		if !a.Equal(c.expErr, h.DeleteNs(context.Background(), c.name)) {
 			a.FailNow("case failed", "case", i)
 		}
 	}
@@ -29,12 +29,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/servicediscovery/types"
 )
 
-func (h *Handler) listOrGetService(nsName string, name *string) ([]*sr.Service, []*cloudMapIDs, error) {
+func (h *Handler) listOrGetService(ctx context.Context, nsName string, name *string) ([]*sr.Service, []*cloudMapIDs, error) {
 	// NOTE: as for other resources on Cloud Map, we cannot get a resource
 	// by its name but *only* with its AWS-generated ID. Thus, here too we
 	// need to actually search for the service rather than its name. Same
 	// reason why we got the namespace ID above.
-	_, ids, err := h.listOrGetNamespace(&nsName)
+	_, ids, err := h.listOrGetNamespace(ctx, &nsName)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -43,10 +43,10 @@ func (h *Handler) listOrGetService(nsName string, name *string) ([]*sr.Service,
 	}
 	nsID := ids[0].id
 
-	ctx, canc := context.WithTimeout(h.mainCtx, time.Minute)
+	reqCtx, canc := context.WithTimeout(ctx, time.Minute)
 	defer canc()
 
-	servs, err := h.Client.ListServices(ctx, &servicediscovery.ListServicesInput{
+	servs, err := h.Client.ListServices(reqCtx, &servicediscovery.ListServicesInput{
 		Filters: []types.ServiceFilter{
 			{
 				Name:      types.ServiceFilterNameNamespaceId,
@@ -76,7 +76,7 @@ func (h *Handler) listOrGetService(nsName string, name *string) ([]*sr.Service,
 		}
 
 		servFound.Metadata = func() map[string]string {
-			tagCtx, tagCanc := context.WithTimeout(h.mainCtx, 30*time.Second)
+			tagCtx, tagCanc := context.WithTimeout(ctx, 30*time.Second)
 			defer tagCanc()
 
 			tags, err := h.Client.ListTagsForResource(tagCtx, &servicediscovery.ListTagsForResourceInput{
@@ -104,7 +104,7 @@ func (h *Handler) listOrGetService(nsName string, name *string) ([]*sr.Service,
 }
 
 // GetServ returns the service if exists.
-func (h *Handler) GetServ(nsName, servName string) (*sr.Service, error) {
+func (h *Handler) GetServ(ctx context.Context, nsName, servName string) (*sr.Service, error) {
 	if nsName == "" {
 		return nil, sr.ErrNsNameNotProvided
 	}
@@ -113,7 +113,7 @@ func (h *Handler) GetServ(nsName, servName string) (*sr.Service, error) {
 	}
 
 	// -- then get the service
-	servs, _, err := h.listOrGetService(nsName, &servName)
+	servs, _, err := h.listOrGetService(ctx, nsName, &servName)
 	if err != nil {
 		return nil, err
 	}
@@ -124,13 +124,33 @@ func (h *Handler) GetServ(nsName, servName string) (*sr.Service, error) {
 	return servs[0], nil
 }
 
+// GetServID returns the ARN of the service.
+func (h *Handler) GetServID(ctx context.Context, nsName, servName string) (string, error) {
+	if nsName == "" {
+		return "", sr.ErrNsNameNotProvided
+	}
+	if servName == "" {
+		return "", sr.ErrServNameNotProvided
+	}
+
+	_, ids, err := h.listOrGetService(ctx, nsName, &servName)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) == 0 {
+		return "", sr.ErrNotFound
+	}
+
+	return ids[0].arn, nil
+}
+
 // ListServ returns a list of services inside the provided namespace.
-func (h *Handler) ListServ(nsName string) (servList []*sr.Service, err error) {
+func (h *Handler) ListServ(ctx context.Context, nsName string) (servList []*sr.Service, err error) {
 	if nsName == "" {
 		return nil, sr.ErrNsNameNotProvided
 	}
 
-	servs, _, err := h.listOrGetService(nsName, nil)
+	servs, _, err := h.listOrGetService(ctx, nsName, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +159,7 @@ func (h *Handler) ListServ(nsName string) (servList []*sr.Service, err error) {
 }
 
 // CreateServ creates the service.
-func (h *Handler) CreateServ(serv *sr.Service) (*sr.Service, error) {
+func (h *Handler) CreateServ(ctx context.Context, serv *sr.Service) (*sr.Service, error) {
 	if serv == nil {
 		return nil, sr.ErrServNotProvided
 	}
@@ -150,7 +170,7 @@ func (h *Handler) CreateServ(serv *sr.Service) (*sr.Service, error) {
 		return nil, sr.ErrServNameNotProvided
 	}
 
-	_, ids, err := h.listOrGetNamespace(&serv.NsName)
+	_, ids, err := h.listOrGetNamespace(ctx, &serv.NsName)
 	if err != nil {
 		return nil, err
 	}
@@ -159,10 +179,10 @@ func (h *Handler) CreateServ(serv *sr.Service) (*sr.Service, error) {
 	}
 	nsID := ids[0].id
 
-	ctx, canc := context.WithTimeout(h.mainCtx, time.Minute)
+	reqCtx, canc := context.WithTimeout(ctx, time.Minute)
 	defer canc()
 
-	_, err = h.Client.CreateService(ctx, &servicediscovery.CreateServiceInput{
+	_, err = h.Client.CreateService(reqCtx, &servicediscovery.CreateServiceInput{
 		Name:        aws.String(serv.Name),
 		NamespaceId: aws.String(nsID),
 		Tags:        fromMapToTagsSlice(serv.Metadata),
@@ -189,7 +209,7 @@ func (h *Handler) CreateServ(serv *sr.Service) (*sr.Service, error) {
 }
 
 // UpdateServ updates the service.
-func (h *Handler) UpdateServ(serv *sr.Service) (*sr.Service, error) {
+func (h *Handler) UpdateServ(ctx context.Context, serv *sr.Service) (*sr.Service, error) {
 	if serv == nil {
 		return nil, sr.ErrServNotProvided
 	}
@@ -200,7 +220,7 @@ func (h *Handler) UpdateServ(serv *sr.Service) (*sr.Service, error) {
 		return nil, sr.ErrServNameNotProvided
 	}
 
-	_, servIDs, err := h.listOrGetService(serv.NsName, &serv.Name)
+	_, servIDs, err := h.listOrGetService(ctx, serv.NsName, &serv.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -208,7 +228,7 @@ func (h *Handler) UpdateServ(serv *sr.Service) (*sr.Service, error) {
 		return nil, sr.ErrNotFound
 	}
 
-	err = h.tagResource(servIDs[0].arn, fromMapToTagsSlice(serv.Metadata))
+	err = h.tagResource(ctx, servIDs[0].arn, fromMapToTagsSlice(serv.Metadata))
 	if err == nil {
 		return serv, nil
 	}
@@ -230,7 +250,7 @@ func (h *Handler) UpdateServ(serv *sr.Service) (*sr.Service, error) {
 }
 
 // DeleteServ deletes the service.
-func (h *Handler) DeleteServ(nsName, servName string) error {
+func (h *Handler) DeleteServ(ctx context.Context, nsName, servName string) error {
 	if nsName == "" {
 		return sr.ErrNsNameNotProvided
 	}
@@ -238,7 +258,7 @@ func (h *Handler) DeleteServ(nsName, servName string) error {
 		return sr.ErrServNameNotProvided
 	}
 
-	_, servIDs, err := h.listOrGetService(nsName, &servName)
+	_, servIDs, err := h.listOrGetService(ctx, nsName, &servName)
 	if err != nil {
 		return err
 	}
@@ -247,10 +267,10 @@ func (h *Handler) DeleteServ(nsName, servName string) error {
 	}
 	servID := servIDs[0].id
 
-	ctx, canc := context.WithTimeout(h.mainCtx, time.Minute)
+	reqCtx, canc := context.WithTimeout(ctx, time.Minute)
 	defer canc()
 
-	_, err = h.Client.DeleteService(ctx, &servicediscovery.DeleteServiceInput{
+	_, err = h.Client.DeleteService(reqCtx, &servicediscovery.DeleteServiceInput{
 		Id: aws.String(servID),
 	})
 	if err == nil {
@@ -178,9 +178,9 @@ func TestListOrGetService(t *testing.T) {
 
 	a := assert.New(t)
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, ids, err := h.listOrGetService("ns-1", c.name)
+		res, ids, err := h.listOrGetService(context.Background(), "ns-1", c.name)
 
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expIDs, ids) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
@@ -282,9 +282,9 @@ func TestGetServ(t *testing.T) {
 
 	a := assert.New(t)
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, err := h.GetServ(c.nsName, c.servName)
+		res, err := h.GetServ(context.Background(), c.nsName, c.servName)
 
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
@@ -378,9 +378,9 @@ func TestListServ(t *testing.T) {
 
 	a := assert.New(t)
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, err := h.ListServ(c.nsName)
+		res, err := h.ListServ(context.Background(), c.nsName)
 
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
@@ -496,9 +496,9 @@ func TestCreateServ(t *testing.T) {
 	}
 
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, err := h.CreateServ(c.serv)
+		res, err := h.CreateServ(context.Background(), c.serv)
 
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
@@ -636,9 +636,9 @@ func TestUpdateServ(t *testing.T) {
 	}
 
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		res, err := h.UpdateServ(c.serv)
+		res, err := h.UpdateServ(context.Background(), c.serv)
 
 		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
@@ -781,9 +781,63 @@ func TestDeleteServ(t *testing.T) {
 	}
 
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
 
-		if !a.Equal(c.expErr, h.DeleteServ(c.nsName, c.servName)) {
+		if !a.Equal(c.expErr, h.DeleteServ(context.Background(), c.nsName, c.servName)) {
+			a.FailNow("case failed", "case", i)
+		}
+	}
+}
+
+func TestGetServID(t *testing.T) {
+	cli := &fakeCloudMapClient{
+		_ListNamespaces: func(ctx context.Context, params *servicediscovery.ListNamespacesInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.ListNamespacesOutput, error) {
+			return &servicediscovery.ListNamespacesOutput{Namespaces: []types.NamespaceSummary{
+				{Arn: aws.String("ns-arn-1"), Id: aws.String("ns-id-1"), Name: aws.String("ns-1")},
+			}}, nil
+		},
+		_ListServices: func(ctx context.Context, params *servicediscovery.ListServicesInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.ListServicesOutput, error) {
+			return &servicediscovery.ListServicesOutput{Services: []types.ServiceSummary{
+				{Arn: aws.String("serv-arn-1"), Id: aws.String("serv-id-1"), Name: aws.String("serv-1")},
+			}}, nil
+		},
+		_ListTagsForResource: func(ctx context.Context, params *servicediscovery.ListTagsForResourceInput, optFns ...func(*servicediscovery.Options)) (*servicediscovery.ListTagsForResourceOutput, error) {
+			return &servicediscovery.ListTagsForResourceOutput{Tags: []types.Tag{}}, nil
+		},
+	}
+
+	cases := []struct {
+		nsName   string
+		servName string
+		expRes   string
+		expErr   error
+	}{
+		{
+			expErr: sr.ErrNsNameNotProvided,
+		},
+		{
+			nsName: "ns-1",
+			expErr: sr.ErrServNameNotProvided,
+		},
+		{
+			nsName:   "ns-1",
+			servName: "serv-2",
+			expErr:   sr.ErrNotFound,
+		},
+		{
+			nsName:   "ns-1",
+			servName: "serv-1",
+			expRes:   "serv-arn-1",
+		},
+	}
+
+	a := assert.New(t)
+	for i, c := range cases {
+		h := &Handler{Client: cli, log: ctrl.Log.WithName("test")}
+
+		res, err := h.GetServID(context.Background(), c.nsName, c.servName)
+
+		if !a.Equal(c.expRes, res) || !a.Equal(c.expErr, err) {
 			a.FailNow("case failed", "case", i)
 		}
 	}
@@ -47,15 +47,17 @@ func fromMapToTagsSlice(metadata map[string]string) []types.Tag {
 	return tags
 }
 
-func (h *Handler) pollOperationStatus(operationID string) error {
+func (h *Handler) pollOperationStatus(ctx context.Context, operationID string) error {
 	ticker := time.NewTicker(pollOperationFrequency)
+	defer ticker.Stop()
 	for {
 		select {
-		case <-h.mainCtx.Done():
+		case <-ctx.Done():
+			// We don't know whether the operation completed or not.
 			h.log.Info("context canceled: stopping checking for operation status")
-			return nil
+			return ctx.Err()
 		case <-ticker.C:
-			opCtx, opCanc := context.WithTimeout(h.mainCtx, 3*time.Second)
+			opCtx, opCanc := context.WithTimeout(ctx, 3*time.Second)
 			op, err := h.Client.GetOperation(opCtx, &servicediscovery.GetOperationInput{OperationId: aws.String(operationID)})
 			if err != nil {
 				opCanc()
@@ -77,8 +79,8 @@ func (h *Handler) pollOperationStatus(operationID string) error {
 	}
 }
 
-func (h *Handler) tagResource(arn string, tags []types.Tag) error {
-	ctx, canc := context.WithTimeout(h.mainCtx, time.Minute)
+func (h *Handler) tagResource(ctx context.Context, arn string, tags []types.Tag) error {
+	reqCtx, canc := context.WithTimeout(ctx, time.Minute)
 	defer canc()
 
 	// NOTE: this won't replace all tags apparently: if you have annotations
@@ -86,7 +88,7 @@ func (h *Handler) tagResource(arn string, tags []types.Tag) error {
 	// entirely sure that C will be deleted by using this function.
 	// Nonetheless, we don't register annotations if the user doesn't want
 	// them, so for now this is good.
-	_, err := h.Client.TagResource(ctx, &servicediscovery.TagResourceInput{
+	_, err := h.Client.TagResource(reqCtx, &servicediscovery.TagResourceInput{
 		ResourceARN: aws.String(arn),
 		Tags:        tags,
 	})
@@ -75,11 +75,17 @@ func TestPollOperationStatus(t *testing.T) {
 
 	a := assert.New(t)
 	for i, c := range cases {
-		h := &Handler{Client: c.cli, mainCtx: context.Background(), log: ctrl.Log.WithName("test")}
-		err := h.pollOperationStatus("whatever")
+		h := &Handler{Client: c.cli, log: ctrl.Log.WithName("test")}
+		err := h.pollOperationStatus(context.Background(), "whatever")
 
 		if !a.Equal(c.expError, err) {
 			a.FailNow("case failed", "case", i)
 		}
 	}
+
+	// The operation may still be running: this must not look like a success
+	ctx, canc := context.WithCancel(context.Background())
+	canc()
+	h := &Handler{Client: &fakeCloudMapClient{}, log: ctrl.Log.WithName("test")}
+	a.Equal(context.Canceled, h.pollOperationStatus(ctx, "whatever"))
 }
@@ -19,8 +19,6 @@
 package servregistry
 
 import (
-	"sync"
-
 	"github.com/go-logr/logr"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
@@ -38,13 +36,24 @@ const (
 //
 // Its functions are split on namespace.go, service.go and endpoint.go to
 // make the package more readable.
+//
+// The context passed to its functions is passed to the service registry as
+// well, so that requests are cancelled when it is done.
+//
+// It is safe to use it from multiple goroutines: operations on different
+// services run in parallel, while operations on the same service or on a
+// namespace that is being changed wait for each other.
 type Broker struct {
-	Reg ServiceRegistry
+	Reg ServiceRegistryV2
 	log logr.Logger
 
 	opMetaPair     MetadataPair
 	persistentMeta []MetadataPair
-	lock           sync.Mutex
+	caps           Capabilities
+	// nsLocks and servLocks make sure that the same namespace or service is
+	// never changed by two operations at the same time: see lock.go.
+	nsLocks   keyedLock
+	servLocks keyedLock
 }
 
 // MetadataPair represents a key-value pair that is/will be registered in a
@@ -60,7 +69,7 @@ type MetadataPair struct {
 //
 // An error is returned in case no service registry where to perform operations
 // is provided.
-func NewBroker(reg ServiceRegistry, opMetaPair MetadataPair, persMeta ...MetadataPair) (*Broker, error) {
+func NewBroker(reg ServiceRegistryV2, opMetaPair MetadataPair, persMeta ...MetadataPair) (*Broker, error) {
 	// Validation and inits
 	l := zap.New(zap.UseDevMode(true)).WithName("ServiceRegistryBroker")
 
@@ -80,5 +89,6 @@ func NewBroker(reg ServiceRegistry, opMetaPair MetadataPair, persMeta ...Metadat
 		Reg:            reg,
 		opMetaPair:     opMetaPair,
 		persistentMeta: persMeta,
+		caps:           CapabilitiesOf(reg),
 	}, nil
 }
@@ -23,7 +23,6 @@ import (
 	"testing"
 
 	a "github.com/stretchr/testify/assert"
-	corev1 "k8s.io/api/core/v1"
 )
 
 type fakeServReg struct {
@@ -316,11 +315,6 @@ func (f *fakeServReg) DeleteEndp(nsName, servName, endpName string) error {
 	return nil
 }
 
-func (f *fakeServReg) ExtractData(ns *corev1.Namespace, serv *corev1.Service) (*Namespace, *Service, []*Endpoint, error) {
-	// No need to implement this
-	return nil, nil, nil, nil
-}
-
 func TestNewBroker(t *testing.T) {
 	// prepare
 	var f *fakeServReg
@@ -330,13 +324,13 @@ func TestNewBroker(t *testing.T) {
 	assert.Nil(b)
 	assert.Equal(ErrServRegNotProvided, err)
 
-	b, err = NewBroker(f, MetadataPair{})
+	b, err = NewBroker(AdaptV1(f), MetadataPair{})
 	assert.NotNil(b)
 	assert.NoError(err)
 	assert.Equal(b.opMetaPair.Key, defOpKey)
 	assert.Equal(b.opMetaPair.Value, defOpVal)
 
-	b, err = NewBroker(f, MetadataPair{Key: "test", Value: "testing"})
+	b, err = NewBroker(AdaptV1(f), MetadataPair{Key: "test", Value: "testing"})
 	assert.Equal(b.opMetaPair.Key, "test")
 	assert.Equal(b.opMetaPair.Value, "testing")
 }
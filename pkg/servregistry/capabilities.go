@@ -0,0 +1,329 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package servregistry
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+)
+
+// This file contains the definition of the capabilities of a service
+// registry and the functions that the Broker uses to validate or adapt
+// data according to them, before writing it.
+
+// Capabilities describes what a service registry supports, so that the
+// Broker can validate or adapt data before writing it instead of getting
+// an error from the service registry.
+type Capabilities struct {
+	// NsMetadata specifies whether namespaces can have metadata, other
+	// than the ones needed by the operator. If false, they are removed.
+	NsMetadata bool
+	// EndpMetadata specifies whether endpoints can have metadata, other
+	// than the ones needed by the operator. If false, they are removed.
+	EndpMetadata bool
+	// NsMetadataLimits, ServMetadataLimits and EndpMetadataLimits are the
+	// limits of the metadata of namespaces, services and endpoints,
+	// including the ones needed by the operator.
+	NsMetadataLimits   MetadataLimits
+	ServMetadataLimits MetadataLimits
+	EndpMetadataLimits MetadataLimits
+	// EndpNameLimits are the limits of the names of endpoints.
+	EndpNameLimits NameLimits
+	// IPv6 specifies whether endpoints can have an IPv6 address.
+	IPv6 bool
+	// HostnameAddresses specifies whether endpoints can have a hostname,
+	// instead of an IP, as their address.
+	HostnameAddresses bool
+	// TransactionalWrites specifies whether deleting a namespace or a
+	// service also deletes everything it contains in a single operation.
+	// If false, the Broker deletes its contents one by one before it.
+	TransactionalWrites bool
+}
+
+// MetadataLimits contains the limits of the metadata of an object. Zero
+// values mean that there is no limit.
+type MetadataLimits struct {
+	// MaxKeys is the maximum number of keys.
+	MaxKeys int
+	// MaxKeyLength and MaxValueLength are the maximum number of characters
+	// of a single key or value.
+	MaxKeyLength   int
+	MaxValueLength int
+	// MaxSize is the maximum number of characters of all keys and values
+	// together.
+	MaxSize int
+	// KeyPattern, if not nil, must be matched by all keys.
+	KeyPattern *regexp.Regexp
+}
+
+// NameLimits contains the limits of the name of an object. Zero values mean
+// that there is no limit.
+type NameLimits struct {
+	// MaxLength is the maximum number of characters.
+	MaxLength int
+	// Pattern, if not nil, must be matched by the name.
+	Pattern *regexp.Regexp
+}
+
+// UnsupportedKeys returns, sorted, the keys of the metadata that are not
+// supported on their own, e.g. because they don't match KeyPattern. Limits
+// that depend on all metadata together, e.g. MaxKeys, are not checked.
+func (l MetadataLimits) UnsupportedKeys(metadata map[string]string) []string {
+	keys := []string{}
+	for key, val := range metadata {
+		if validateMetadataKey(l, key, val) != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// CapabilitiesGetter is implemented by service registries that don't
+// support everything the Broker can write. Service registries that don't
+// implement it are assumed to support everything, as returned by
+// DefaultCapabilities.
+type CapabilitiesGetter interface {
+	// Capabilities returns what the service registry supports.
+	Capabilities() Capabilities
+}
+
+// DefaultCapabilities returns the capabilities of a service registry that
+// supports everything and has no limits.
+func DefaultCapabilities() Capabilities {
+	return Capabilities{
+		NsMetadata:          true,
+		EndpMetadata:        true,
+		IPv6:                true,
+		HostnameAddresses:   true,
+		TransactionalWrites: true,
+	}
+}
+
+// CapabilitiesOf returns the capabilities of the provided service registry.
+func CapabilitiesOf(reg interface{}) Capabilities {
+	if getter, ok := reg.(CapabilitiesGetter); ok {
+		return getter.Capabilities()
+	}
+
+	return DefaultCapabilities()
+}
+
+// ValidationError is returned by the Broker when an object cannot be
+// written to the service registry because it does not support its data.
+//
+// Err is one of the errors listed in errors.go, e.g. ErrTooManyMetadata,
+// so it can be checked with errors.Is.
+type ValidationError struct {
+	// NsName is the name of the namespace
+	NsName string
+	// ServName is the name of the service, empty if the object is a
+	// namespace
+	ServName string
+	// EndpName is the name of the endpoint, empty if the object is a
+	// namespace or a service
+	EndpName string
+	// Key is the metadata key that is not supported, if any
+	Key string
+	// Err is the reason why the object is not supported
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	var obj string
+	switch {
+	case e.ServName == "":
+		obj = fmt.Sprintf("namespace %s", e.NsName)
+	case e.EndpName == "":
+		obj = fmt.Sprintf("service %s/%s", e.NsName, e.ServName)
+	default:
+		obj = fmt.Sprintf("endpoint %s/%s/%s", e.NsName, e.ServName, e.EndpName)
+	}
+
+	if e.Key != "" {
+		return fmt.Sprintf("cannot write %s: %s: %q", obj, e.Err, e.Key)
+	}
+	return fmt.Sprintf("cannot write %s: %s", obj, e.Err)
+}
+
+// Unwrap returns the reason of the error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Capabilities returns the capabilities of the service registry used by the
+// Broker.
+func (b *Broker) Capabilities() Capabilities {
+	return b.caps
+}
+
+// adaptNs removes the metadata of the namespace that are not supported by
+// the service registry.
+//
+// Unlike services, namespaces are shared by many services and their
+// metadata are optional, so keys that are not supported, e.g. because of
+// their format, are removed as well rather than preventing all its services
+// from being written.
+func (b *Broker) adaptNs(nsData *Namespace) {
+	if !b.caps.NsMetadata {
+		nsData.Metadata = b.keepOperatorMetadata(nsData.Metadata, false)
+		return
+	}
+
+	for _, key := range b.caps.NsMetadataLimits.UnsupportedKeys(nsData.Metadata) {
+		if key != b.opMetaPair.Key {
+			delete(nsData.Metadata, key)
+		}
+	}
+}
+
+// adaptEndps removes the metadata of the endpoints that are not supported
+// by the service registry.
+func (b *Broker) adaptEndps(endpsData []*Endpoint) {
+	if b.caps.EndpMetadata {
+		return
+	}
+
+	for _, endp := range endpsData {
+		endp.Metadata = b.keepOperatorMetadata(endp.Metadata, false)
+	}
+}
+
+// keepOperatorMetadata returns only the metadata that are written by the
+// operator itself, i.e. the owner and, if withPersistent is true, the
+// persistent metadata.
+func (b *Broker) keepOperatorMetadata(metadata map[string]string, withPersistent bool) map[string]string {
+	kept := map[string]string{}
+	if val, exists := metadata[b.opMetaPair.Key]; exists {
+		kept[b.opMetaPair.Key] = val
+	}
+
+	if withPersistent {
+		for _, metaPair := range b.persistentMeta {
+			if val, exists := metadata[metaPair.Key]; exists {
+				kept[metaPair.Key] = val
+			}
+		}
+	}
+
+	return kept
+}
+
+func (b *Broker) validateNs(nsData *Namespace) error {
+	if key, err := validateMetadata(b.caps.NsMetadataLimits, nsData.Metadata); err != nil {
+		return &ValidationError{NsName: nsData.Name, Key: key, Err: err}
+	}
+
+	return nil
+}
+
+func (b *Broker) validateServ(servData *Service) error {
+	if key, err := validateMetadata(b.caps.ServMetadataLimits, servData.Metadata); err != nil {
+		return &ValidationError{NsName: servData.NsName, ServName: servData.Name, Key: key, Err: err}
+	}
+
+	return nil
+}
+
+func (b *Broker) validateEndp(endpData *Endpoint) error {
+	verr := &ValidationError{NsName: endpData.NsName, ServName: endpData.ServName, EndpName: endpData.Name}
+
+	if err := validateName(b.caps.EndpNameLimits, endpData.Name); err != nil {
+		verr.Err = err
+		return verr
+	}
+
+	if !b.caps.IPv6 {
+		if ip := net.ParseIP(endpData.Address); ip != nil && ip.To4() == nil {
+			verr.Err = ErrIPv6NotSupported
+			return verr
+		}
+	}
+
+	if key, err := validateMetadata(b.caps.EndpMetadataLimits, endpData.Metadata); err != nil {
+		verr.Key, verr.Err = key, err
+		return verr
+	}
+
+	return nil
+}
+
+// validateName checks the name against the provided limits and returns the
+// reason why it is not valid, if any.
+func validateName(limits NameLimits, name string) error {
+	if limits.MaxLength > 0 && len(name) > limits.MaxLength {
+		return ErrEndpNameTooLong
+	}
+	if limits.Pattern != nil && !limits.Pattern.MatchString(name) {
+		return ErrInvalidEndpName
+	}
+
+	return nil
+}
+
+// validateMetadata checks the metadata against the provided limits and
+// returns the reason why they are not valid and the key that caused it,
+// if any.
+func validateMetadata(limits MetadataLimits, metadata map[string]string) (string, error) {
+	if limits.MaxKeys > 0 && len(metadata) > limits.MaxKeys {
+		return "", ErrTooManyMetadata
+	}
+
+	// Keys are checked in order, so that the same key is reported every
+	// time.
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	size := 0
+	for _, key := range keys {
+		if err := validateMetadataKey(limits, key, metadata[key]); err != nil {
+			return key, err
+		}
+
+		size += len(key) + len(metadata[key])
+	}
+
+	if limits.MaxSize > 0 && size > limits.MaxSize {
+		return "", ErrMetadataTooLarge
+	}
+
+	return "", nil
+}
+
+// validateMetadataKey checks a single key and its value against the
+// provided limits and returns the reason why they are not valid, if any.
+func validateMetadataKey(limits MetadataLimits, key, val string) error {
+	if limits.KeyPattern != nil && !limits.KeyPattern.MatchString(key) {
+		return ErrInvalidMetadataKey
+	}
+	if limits.MaxKeyLength > 0 && len(key) > limits.MaxKeyLength {
+		return ErrMetadataKeyTooLong
+	}
+	if limits.MaxValueLength > 0 && len(val) > limits.MaxValueLength {
+		return ErrMetadataValueTooLong
+	}
+
+	return nil
+}
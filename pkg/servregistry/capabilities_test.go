@@ -0,0 +1,192 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package servregistry
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	a "github.com/stretchr/testify/assert"
+)
+
+type fakeCapsReg struct {
+	ServiceRegistryV2
+	caps Capabilities
+}
+
+func (f *fakeCapsReg) Capabilities() Capabilities {
+	return f.caps
+}
+
+func TestValidateMetadata(t *testing.T) {
+	cases := []struct {
+		id     string
+		limits MetadataLimits
+		meta   map[string]string
+		expKey string
+		expErr error
+	}{
+		{
+			id:   "no-limits",
+			meta: map[string]string{"key": "val", "other key": "other val"},
+		},
+		{
+			id:     "too-many",
+			limits: MetadataLimits{MaxKeys: 1},
+			meta:   map[string]string{"one": "1", "two": "2"},
+			expErr: ErrTooManyMetadata,
+		},
+		{
+			id:     "key-too-long",
+			limits: MetadataLimits{MaxKeyLength: 3},
+			meta:   map[string]string{"four": "4"},
+			expKey: "four",
+			expErr: ErrMetadataKeyTooLong,
+		},
+		{
+			id:     "value-too-long",
+			limits: MetadataLimits{MaxValueLength: 3},
+			meta:   map[string]string{"key": "four"},
+			expKey: "key",
+			expErr: ErrMetadataValueTooLong,
+		},
+		{
+			id:     "too-large",
+			limits: MetadataLimits{MaxSize: 5},
+			meta:   map[string]string{"one": "1", "two": "2"},
+			expErr: ErrMetadataTooLarge,
+		},
+		{
+			id:     "invalid-key",
+			limits: MetadataLimits{KeyPattern: regexp.MustCompile(`^[a-z]+$`)},
+			meta:   map[string]string{"Invalid": "1"},
+			expKey: "Invalid",
+			expErr: ErrInvalidMetadataKey,
+		},
+		{
+			id:     "first-invalid-key",
+			limits: MetadataLimits{KeyPattern: regexp.MustCompile(`^[a-z]+$`)},
+			meta:   map[string]string{"Two": "2", "One": "1", "Three": "3", "ok": "4"},
+			expKey: "One",
+			expErr: ErrInvalidMetadataKey,
+		},
+		{
+			id: "within-limits",
+			limits: MetadataLimits{
+				MaxKeys:        2,
+				MaxKeyLength:   3,
+				MaxValueLength: 1,
+				MaxSize:        8,
+				KeyPattern:     regexp.MustCompile(`^[a-z]+$`),
+			},
+			meta: map[string]string{"one": "1", "two": "2"},
+		},
+	}
+
+	assert := a.New(t)
+	for _, currCase := range cases {
+		key, err := validateMetadata(currCase.limits, currCase.meta)
+		assert.Equal(currCase.expKey, key, "case %s failed", currCase.id)
+		assert.Equal(currCase.expErr, err, "case %s failed", currCase.id)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	limits := NameLimits{MaxLength: 5, Pattern: regexp.MustCompile(`^[a-z]+$`)}
+
+	assert := a.New(t)
+	assert.NoError(validateName(NameLimits{}, "Any name"))
+	assert.NoError(validateName(limits, "name"))
+	assert.Equal(ErrEndpNameTooLong, validateName(limits, "toolong"))
+	assert.Equal(ErrInvalidEndpName, validateName(limits, "Name"))
+}
+
+func TestValidationError(t *testing.T) {
+	assert := a.New(t)
+
+	err := error(&ValidationError{NsName: "ns", ServName: "serv", Key: "key", Err: ErrInvalidMetadataKey})
+	assert.Equal(`cannot write service ns/serv: invalid metadata key: "key"`, err.Error())
+	assert.True(errors.Is(err, ErrInvalidMetadataKey))
+	assert.False(IsRetryable(err))
+
+	err = &ValidationError{NsName: "ns", ServName: "serv", EndpName: "endp", Err: ErrIPv6NotSupported}
+	assert.Equal("cannot write endpoint ns/serv/endp: IPv6 addresses are not supported", err.Error())
+}
+
+func TestBrokerCapabilities(t *testing.T) {
+	ctx := context.Background()
+	assert := a.New(t)
+
+	// Registries that don't advertise their capabilities support everything
+	b, _ := NewBroker(AdaptV1(newFakeStruct()), MetadataPair{})
+	assert.Equal(DefaultCapabilities(), b.Capabilities())
+
+	f := newFakeStruct()
+	reg := &fakeCapsReg{
+		ServiceRegistryV2: AdaptV1(f),
+		caps:              Capabilities{ServMetadataLimits: MetadataLimits{MaxKeys: 1}},
+	}
+	b, _ = NewBroker(reg, MetadataPair{})
+
+	// Unsupported metadata are removed
+	_, err := b.ManageNs(ctx, &Namespace{Name: "ns", Metadata: map[string]string{"key": "val"}})
+	assert.NoError(err)
+	assert.Equal(map[string]string{b.opMetaPair.Key: b.opMetaPair.Value}, f.nsList["ns"].Metadata)
+
+	endpErrs, err := b.ManageServEndps(ctx, "ns", "serv", []*Endpoint{
+		{Name: "v4", NsName: "ns", ServName: "serv", Address: "10.10.10.10", Metadata: map[string]string{"key": "val"}},
+		{Name: "v6", NsName: "ns", ServName: "serv", Address: "2001:db8::1"},
+	})
+	assert.NoError(err)
+	assert.Equal(map[string]string{b.opMetaPair.Key: b.opMetaPair.Value}, f.endpList["v4"].Metadata)
+
+	// Unsupported data is not written and a typed error is returned
+	assert.Len(endpErrs, 1)
+	assert.True(errors.Is(endpErrs["v6"], ErrIPv6NotSupported))
+	assert.NotContains(f.endpList, "v6")
+
+	_, err = b.ManageServ(ctx, &Service{Name: "serv", NsName: "ns", Metadata: map[string]string{"key": "val"}})
+	assert.Equal(&ValidationError{NsName: "ns", ServName: "serv", Err: ErrTooManyMetadata}, err)
+	assert.Empty(f.servList)
+
+	// Contents of services are deleted first if writes are not
+	// transactional
+	f.servList["serv"] = &Service{Name: "serv", NsName: "ns", Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value}}
+	assert.NoError(b.RemoveServ(ctx, "ns", "serv", true))
+	assert.Equal([]string{"v4"}, f.deletedEndp)
+	assert.Equal([]string{"serv"}, f.deletedServ)
+
+	// Namespace metadata keys that are not supported are skipped, rather
+	// than preventing its services from being written
+	f = newFakeStruct()
+	reg = &fakeCapsReg{
+		ServiceRegistryV2: AdaptV1(f),
+		caps:              Capabilities{NsMetadata: true, NsMetadataLimits: MetadataLimits{KeyPattern: regexp.MustCompile(`^[a-z]+$`)}},
+	}
+	b, _ = NewBroker(reg, MetadataPair{})
+	nsMeta := map[string]string{"site": "eu", "example.com/site": "eu"}
+	assert.Equal([]string{"example.com/site"}, b.Capabilities().NsMetadataLimits.UnsupportedKeys(nsMeta))
+
+	_, _, err = b.SyncServ(ctx, &Namespace{Name: "ns", Metadata: nsMeta}, &Service{Name: "serv", NsName: "ns", Metadata: map[string]string{"key": "val"}}, nil)
+	assert.NoError(err)
+	assert.Equal(map[string]string{"site": "eu", b.opMetaPair.Key: b.opMetaPair.Value}, f.nsList["ns"].Metadata)
+	assert.Contains(f.servList, "serv")
+}
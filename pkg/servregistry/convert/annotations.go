@@ -0,0 +1,84 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import "strings"
+
+// This file contains the functions that read the annotations of a service
+// that are meant for the endpoints of one of its ports, e.g.
+//
+//	endpoint.cnwan.io/web.traffic-profile: gold
+//
+// which becomes the metadata traffic-profile: gold of the endpoints of port
+// web, and the annotations of a namespace that are meant for its services,
+// e.g.
+//
+//	defaults.cnwan.io/traffic-profile: standard
+//
+// which becomes the metadata traffic-profile: standard of its services,
+// unless they have their own with the same name, with or without a prefix.
+
+// hasPrefix returns whether the annotation has the prefix, i.e. it is meant
+// for other objects than the annotated one.
+func hasPrefix(key, prefix string) bool {
+	return prefix != "" && strings.HasPrefix(key, prefix+"/")
+}
+
+// endpointAnnotations returns the metadata of the endpoints of each port,
+// taken from the annotations with the prefix. Annotations whose name is not
+// made of the name of a port and a key are ignored.
+func endpointAnnotations(annotations map[string]string, prefix string) map[string]map[string]string {
+	byPort := map[string]map[string]string{}
+	for key, val := range annotations {
+		if !hasPrefix(key, prefix) {
+			continue
+		}
+
+		portAndKey := strings.SplitN(strings.TrimPrefix(key, prefix+"/"), ".", 2)
+		if len(portAndKey) != 2 || portAndKey[0] == "" || portAndKey[1] == "" {
+			continue
+		}
+
+		if _, exists := byPort[portAndKey[0]]; !exists {
+			byPort[portAndKey[0]] = map[string]string{}
+		}
+		byPort[portAndKey[0]][portAndKey[1]] = val
+	}
+
+	return byPort
+}
+
+// nsDefaults returns the default metadata of services, taken from the
+// annotations of their namespace with the prefix.
+func nsDefaults(annotations map[string]string, prefix string) map[string]string {
+	defaults := map[string]string{}
+	for key, val := range annotations {
+		if hasPrefix(key, prefix) && len(key) > len(prefix)+1 {
+			defaults[strings.TrimPrefix(key, prefix+"/")] = val
+		}
+	}
+
+	return defaults
+}
+
+// keyName returns the key without its prefix, if any, e.g. traffic-profile
+// for both traffic-profile and cnwan.io/traffic-profile.
+func keyName(key string) string {
+	return key[strings.LastIndex(key, "/")+1:]
+}
@@ -0,0 +1,129 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"testing"
+
+	a "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEndpointAnnotations(t *testing.T) {
+	annotations := map[string]string{
+		"endpoint.cnwan.io/web.traffic-profile":  "gold",
+		"endpoint.cnwan.io/web.weight":           "10",
+		"endpoint.cnwan.io/quic.traffic-profile": "silver",
+		"endpoint.cnwan.io/no-key":               "ignored",
+		"endpoint.cnwan.io/.no-port":             "ignored",
+		"other.io/web.traffic-profile":           "ignored",
+		"traffic-profile":                        "ignored",
+	}
+
+	assert := a.New(t)
+	assert.Equal(map[string]map[string]string{
+		"web":  {"traffic-profile": "gold", "weight": "10"},
+		"quic": {"traffic-profile": "silver"},
+	}, endpointAnnotations(annotations, "endpoint.cnwan.io"))
+	assert.Empty(endpointAnnotations(annotations, ""))
+}
+
+func TestConverterEndpointAnnotations(t *testing.T) {
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "serv",
+			Namespace: "ns",
+			Annotations: map[string]string{
+				"traffic-profile":                       "standard",
+				"endpoint.cnwan.io/web.traffic-profile": "gold",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			ExternalIPs: []string{"10.10.10.10"},
+			Ports: []corev1.ServicePort{
+				{Name: "web", Protocol: corev1.ProtocolTCP, Port: 80},
+				{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+			},
+		},
+	}
+	conv := &Converter{
+		Sources:                  []AddressSource{ExternalIPs()},
+		PortMetadata:             []string{ProtocolMetadataKey},
+		EndpointAnnotationPrefix: "endpoint.cnwan.io",
+	}
+
+	assert := a.New(t)
+	assert.Equal(map[string]string{"traffic-profile": "standard"}, conv.Service(nil, serv).Metadata)
+
+	endpList, err := conv.Endpoints(context.Background(), serv)
+	assert.NoError(err)
+	if assert.Len(endpList, 2) {
+		assert.Equal(map[string]string{"traffic-profile": "gold", ProtocolMetadataKey: "TCP"}, endpList[0].Metadata)
+		assert.Equal(map[string]string{ProtocolMetadataKey: "TCP"}, endpList[1].Metadata)
+	}
+
+	// Without a prefix, all annotations are metadata of the service
+	conv.EndpointAnnotationPrefix = ""
+	assert.Len(conv.Service(nil, serv).Metadata, 2)
+}
+
+func TestConverterNsDefaults(t *testing.T) {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "ns",
+			Annotations: map[string]string{
+				"site":                              "eu",
+				"defaults.cnwan.io/traffic-profile": "standard",
+				"defaults.cnwan.io/version":         "v0",
+				"defaults.cnwan.io/":                "ignored",
+			},
+		},
+	}
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "serv",
+			Namespace:   "ns",
+			Annotations: map[string]string{"version": "v1"},
+		},
+	}
+	conv := &Converter{
+		NsDefaultsPrefix: "defaults.cnwan.io",
+		MetadataRules:    []MetadataRule{{AddPrefix: "cnwan-"}},
+	}
+
+	assert := a.New(t)
+	assert.Equal(map[string]string{"site": "eu"}, conv.Namespace(ns).Metadata)
+
+	// Annotations of the service win and rules apply to the defaults as well
+	assert.Equal(map[string]string{"cnwan-traffic-profile": "standard", "cnwan-version": "v1"}, conv.Service(ns, serv).Metadata)
+
+	// Keys with a prefix override the defaults with the same name as well
+	conv.MetadataRules = nil
+	serv.Annotations = map[string]string{"cnwan.io/traffic-profile": "video", "defaults.cnwan.io/version": "v1"}
+	assert.Equal(map[string]string{"cnwan.io/traffic-profile": "video", "defaults.cnwan.io/version": "v1"}, conv.Service(ns, serv).Metadata)
+
+	// Defaults don't make services without metadata registered
+	serv.Annotations = nil
+	assert.Empty(conv.Service(ns, serv).Metadata)
+
+	conv.NsDefaultsPrefix = ""
+	assert.Len(conv.Namespace(ns).Metadata, 4)
+}
@@ -0,0 +1,321 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Address is an address where a service can be reached, which becomes an
+// endpoint in the service registry.
+type Address struct {
+	// Address is the IP address or hostname
+	Address string
+	// Port is the port where the service is reachable at the address
+	Port int32
+	// PortName, Protocol and AppProtocol are the name, the protocol, e.g.
+	// TCP, and the application protocol, e.g. https, of the port, if known
+	PortName    string
+	Protocol    string
+	AppProtocol string
+	// Metadata of the endpoint, e.g. the node it is running on
+	Metadata map[string]string
+
+	// protocolInKey is set on addresses that have the same address and port
+	// as a previous one, to tell them apart by protocol.
+	protocolInKey bool
+}
+
+// AddressSource returns the addresses of a service, e.g. its external IPs.
+//
+// It must return an empty list for services that it does not apply to,
+// e.g. because of their type, and an error only if the addresses could
+// not be retrieved.
+type AddressSource interface {
+	Addresses(ctx context.Context, serv *corev1.Service) ([]Address, error)
+}
+
+// AddressSourceFunc is a function that implements AddressSource.
+type AddressSourceFunc func(ctx context.Context, serv *corev1.Service) ([]Address, error)
+
+// Addresses calls the function itself.
+func (f AddressSourceFunc) Addresses(ctx context.Context, serv *corev1.Service) ([]Address, error) {
+	return f(ctx, serv)
+}
+
+// NamingStrategy gives a name to the endpoints of a service.
+//
+// Names must only depend on the service and the address, so that the same
+// endpoint always has the same name, even after a restart.
+type NamingStrategy interface {
+	EndpointName(servName string, addr Address) string
+}
+
+// NamingFunc is a function that implements NamingStrategy.
+type NamingFunc func(servName string, addr Address) string
+
+// EndpointName calls the function itself.
+func (f NamingFunc) EndpointName(servName string, addr Address) string {
+	return f(servName, addr)
+}
+
+// HashedName returns the name of the service followed by the first 10
+// characters of the SHA-256 of the address and port, e.g. for address
+// 10.10.10.10 and port 80 of service payroll:
+//
+//	payroll-b93049dffe
+//
+// This is the default naming strategy.
+func HashedName(servName string, addr Address) string {
+	return fmt.Sprintf("%s-%s", servName, addressHash(addr)[:defaultHashLength])
+}
+
+// addressHash returns the hex encoded SHA-256 of the address key.
+func addressHash(addr Address) string {
+	h := sha256.New()
+	h.Write([]byte(addressKey(addr)))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// addressKey returns the string that identifies an address, made of the
+// address and the port, e.g. 10.10.10.10-80.
+//
+// The protocol is appended only to tell apart ports that have the same
+// number as a previous port of the service, e.g. 10.10.10.10-53-TCP after
+// 10.10.10.10-53 for UDP, so that existing endpoints, including UDP and SCTP
+// ones, keep the names they always had.
+func addressKey(addr Address) string {
+	if !addr.protocolInKey {
+		return fmt.Sprintf("%s-%d", addr.Address, addr.Port)
+	}
+
+	return fmt.Sprintf("%s-%d-%s", addr.Address, addr.Port, addr.Protocol)
+}
+
+// Converter converts Kubernetes namespaces and services into the data of
+// the service registry.
+type Converter struct {
+	// Sources are the sources of the addresses of the endpoints. When the
+	// same address and port are returned by more sources, the first one is
+	// used.
+	Sources []AddressSource
+	// Naming gives a name to the endpoints. If nil, HashedName is used.
+	// If it implements ServiceNamingStrategy, all the endpoints of a
+	// service are named together.
+	Naming NamingStrategy
+	// PortMetadata are the keys of the metadata about the port and the
+	// address that are added to the endpoints, among PortMetadataKeys. If
+	// empty, none is added.
+	PortMetadata []string
+	// EndpointAnnotationPrefix, if not empty, is the prefix of the
+	// annotations of the service that are metadata of the endpoints of one
+	// of its ports rather than of the service, in the format
+	// <prefix>/<port-name>.<key>, e.g. endpoint.cnwan.io/web.traffic-profile.
+	EndpointAnnotationPrefix string
+	// NsDefaultsPrefix, if not empty, is the prefix of the annotations of
+	// the namespace that are default metadata of its services rather than
+	// metadata of the namespace, in the format <prefix>/<key>, e.g.
+	// defaults.cnwan.io/traffic-profile.
+	NsDefaultsPrefix string
+	// MetadataRules transform the metadata of services, in order, after
+	// the defaults of the namespace have been added. They are not applied
+	// to services that have no metadata, so that defaults don't make them
+	// registered.
+	MetadataRules []MetadataRule
+}
+
+// Convert returns the namespace, the service and the endpoints of the
+// service as they should appear in the service registry.
+//
+// Labels and annotations are used as metadata as they are, so they should be
+// filtered before calling this.
+func (c *Converter) Convert(ctx context.Context, ns *corev1.Namespace, serv *corev1.Service) (*sr.Namespace, *sr.Service, []*sr.Endpoint, error) {
+	if ns == nil {
+		return nil, nil, nil, sr.ErrNsNotProvided
+	}
+	if serv == nil {
+		return nil, nil, nil, sr.ErrServNotProvided
+	}
+
+	endpList, err := c.Endpoints(ctx, serv)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return c.Namespace(ns), c.Service(ns, serv), endpList, nil
+}
+
+// Namespace returns the namespace as it should appear in the service
+// registry.
+func (c *Converter) Namespace(ns *corev1.Namespace) *sr.Namespace {
+	metadata := map[string]string{}
+	for key, val := range ns.Annotations {
+		if !hasPrefix(key, c.NsDefaultsPrefix) {
+			metadata[key] = val
+		}
+	}
+
+	return &sr.Namespace{
+		Name:     ns.Name,
+		Metadata: metadata,
+	}
+}
+
+// Service returns the service as it should appear in the service registry.
+// The namespace is only used for the default metadata and can be nil.
+//
+// Its metadata are made of its labels and annotations: when a key is both a
+// label and an annotation, the annotation wins, as annotations are meant for
+// metadata while labels are often set for other reasons, e.g. selectors.
+// The defaults of the namespace are only added for keys that the service
+// does not have, regardless of their prefix: e.g. both traffic-profile and
+// cnwan.io/traffic-profile override the default traffic-profile.
+func (c *Converter) Service(ns *corev1.Namespace, serv *corev1.Service) *sr.Service {
+	metadata := copyMetadata(serv.Labels)
+	for key, val := range serv.Annotations {
+		if !hasPrefix(key, c.EndpointAnnotationPrefix) {
+			metadata[key] = val
+		}
+	}
+
+	// Defaults and rules are not applied to services without metadata, so
+	// that they don't make them registered.
+	if len(metadata) > 0 {
+		if ns != nil {
+			names := map[string]bool{}
+			for key := range metadata {
+				names[keyName(key)] = true
+			}
+
+			for key, val := range nsDefaults(ns.Annotations, c.NsDefaultsPrefix) {
+				if !names[keyName(key)] {
+					metadata[key] = val
+				}
+			}
+		}
+		metadata = applyRules(c.MetadataRules, metadata)
+	}
+
+	return &sr.Service{
+		Name:     serv.Name,
+		NsName:   serv.Namespace,
+		Metadata: metadata,
+	}
+}
+
+// Endpoints returns the endpoints of the service, with the addresses
+// returned by all sources.
+func (c *Converter) Endpoints(ctx context.Context, serv *corev1.Service) ([]*sr.Endpoint, error) {
+	naming := c.Naming
+	if naming == nil {
+		naming = NamingFunc(HashedName)
+	}
+
+	// The same address may be returned by more sources, e.g. an external
+	// IP that is also the IP of the load balancer.
+	dups := map[string]bool{}
+	protocols := map[string]string{}
+	addrs := []Address{}
+	for _, source := range c.Sources {
+		srcAddrs, err := source.Addresses(ctx, serv)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, addr := range srcAddrs {
+			// The first port with a number keeps the name it had before
+			// ports with different protocols were told apart.
+			key := addressKey(addr)
+			if protocol, exists := protocols[key]; !exists {
+				protocols[key] = addr.Protocol
+			} else if protocol != addr.Protocol {
+				addr.protocolInKey = true
+				key = addressKey(addr)
+			}
+
+			if dups[key] {
+				continue
+			}
+			dups[key] = true
+			addrs = append(addrs, addr)
+		}
+	}
+
+	var names []string
+	if servNaming, ok := naming.(ServiceNamingStrategy); ok {
+		names = servNaming.EndpointNames(serv.Name, addrs)
+	} else {
+		names = make([]string, len(addrs))
+		for i, addr := range addrs {
+			names[i] = naming.EndpointName(serv.Name, addr)
+		}
+	}
+
+	portAnnotations := endpointAnnotations(serv.Annotations, c.EndpointAnnotationPrefix)
+
+	// Strategies that don't implement ServiceNamingStrategy may give the
+	// same name to different addresses: only the first one is kept.
+	dupNames := map[string]bool{}
+	endpList := []*sr.Endpoint{}
+	for i, addr := range addrs {
+		if dupNames[names[i]] {
+			continue
+		}
+		dupNames[names[i]] = true
+
+		// Metadata set by the operator win over the ones from annotations.
+		metadata := copyMetadata(portAnnotations[addr.PortName])
+		for key, val := range addr.Metadata {
+			metadata[key] = val
+		}
+		portMeta := portMetadata(addr)
+		for _, key := range c.PortMetadata {
+			if val := portMeta[key]; val != "" {
+				metadata[key] = val
+			}
+		}
+
+		endpList = append(endpList, &sr.Endpoint{
+			Name:     names[i],
+			NsName:   serv.Namespace,
+			ServName: serv.Name,
+			Address:  addr.Address,
+			Port:     addr.Port,
+			Metadata: metadata,
+		})
+	}
+
+	return endpList, nil
+}
+
+func copyMetadata(metadata map[string]string) map[string]string {
+	newMeta := make(map[string]string, len(metadata))
+	for key, val := range metadata {
+		newMeta[key] = val
+	}
+
+	return newMeta
+}
@@ -0,0 +1,129 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	a "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHashedName(t *testing.T) {
+	assert := a.New(t)
+	assert.Equal("payroll-b93049dffe", HashedName("payroll", Address{Address: "10.10.10.10", Port: 80}))
+	assert.NotEqual(HashedName("payroll", Address{Address: "10.10.10.10", Port: 80}), HashedName("payroll", Address{Address: "10.10.10.10", Port: 8080}))
+
+	// The protocol only changes the name to tell apart ports with the same
+	// number
+	assert.Equal("payroll-b93049dffe", HashedName("payroll", Address{Address: "10.10.10.10", Port: 80, Protocol: "UDP"}))
+	assert.NotEqual("payroll-b93049dffe", HashedName("payroll", Address{Address: "10.10.10.10", Port: 80, Protocol: "UDP", protocolInKey: true}))
+}
+
+func TestConvert(t *testing.T) {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns", Annotations: map[string]string{"ns-key": "ns-val"}},
+	}
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "serv", Namespace: "ns", Annotations: map[string]string{"key": "val"}},
+		Spec: corev1.ServiceSpec{
+			Type:        corev1.ServiceTypeLoadBalancer,
+			ExternalIPs: []string{"10.10.10.10"},
+			Ports:       []corev1.ServicePort{{Port: 80}, {Port: 8080}},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+				{IP: "10.10.10.10"},
+				{IP: "11.11.11.11"},
+				{Hostname: "lb.example.com"},
+			}},
+		},
+	}
+	endp := func(addr string, port int32) *sr.Endpoint {
+		return &sr.Endpoint{
+			Name:     HashedName("serv", Address{Address: addr, Port: port}),
+			NsName:   "ns",
+			ServName: "serv",
+			Address:  addr,
+			Port:     port,
+			Metadata: map[string]string{},
+		}
+	}
+	failing := AddressSourceFunc(func(context.Context, *corev1.Service) ([]Address, error) {
+		return nil, fmt.Errorf("some error")
+	})
+
+	assert := a.New(t)
+	conv := &Converter{Sources: []AddressSource{ExternalIPs(), LoadBalancerIPs()}}
+	nsData, servData, endpList, err := conv.Convert(context.Background(), ns, serv)
+	assert.NoError(err)
+	assert.Equal(&sr.Namespace{Name: "ns", Metadata: map[string]string{"ns-key": "ns-val"}}, nsData)
+	assert.Equal(&sr.Service{Name: "serv", NsName: "ns", Metadata: map[string]string{"key": "val"}}, servData)
+	assert.Equal([]*sr.Endpoint{
+		endp("10.10.10.10", 80), endp("10.10.10.10", 8080),
+		endp("11.11.11.11", 80), endp("11.11.11.11", 8080),
+	}, endpList)
+
+	// Data must not be shared with the Kubernetes objects
+	servData.Metadata["other"] = "val"
+	assert.Len(serv.Annotations, 1)
+
+	conv.Naming = NamingFunc(func(servName string, addr Address) string {
+		return fmt.Sprintf("%s-%d", servName, addr.Port)
+	})
+	_, _, endpList, _ = conv.Convert(context.Background(), ns, serv)
+	if assert.Len(endpList, 2) {
+		assert.Equal("serv-80", endpList[0].Name)
+		assert.Equal("serv-8080", endpList[1].Name)
+	}
+
+	conv.Sources = append(conv.Sources, failing)
+	_, _, _, err = conv.Convert(context.Background(), ns, serv)
+	assert.Equal(fmt.Errorf("some error"), err)
+
+	_, _, _, err = conv.Convert(context.Background(), nil, serv)
+	assert.True(errors.Is(err, sr.ErrNsNotProvided))
+	_, _, _, err = conv.Convert(context.Background(), ns, nil)
+	assert.True(errors.Is(err, sr.ErrServNotProvided))
+}
+
+func TestConverterServiceLabels(t *testing.T) {
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "serv",
+			Namespace:   "ns",
+			Labels:      map[string]string{"tier": "gold", "version": "v1"},
+			Annotations: map[string]string{"version": "v2"},
+		},
+	}
+	conv := &Converter{}
+
+	assert := a.New(t)
+	servData := conv.Service(nil, serv)
+	assert.Equal(map[string]string{"tier": "gold", "version": "v2"}, servData.Metadata)
+
+	// Data must not be shared with the Kubernetes objects
+	servData.Metadata["tier"] = "silver"
+	assert.Equal("gold", serv.Labels["tier"])
+}
@@ -0,0 +1,26 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+// Package convert contains code that converts Kubernetes namespaces and
+// services into the namespaces, services and endpoints of the service
+// registry, so that service registries only need to store them.
+//
+// The addresses of the endpoints are taken from a list of AddressSource,
+// e.g. the external IPs of the service or the nodes for NodePort services,
+// and their names are given by a NamingStrategy.
+package convert
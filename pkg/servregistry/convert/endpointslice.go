@@ -0,0 +1,110 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// NodeNameMetadataKey and ZoneMetadataKey are the metadata keys of
+	// the node name and zone of the endpoints taken from EndpointSlices.
+	NodeNameMetadataKey string = "cnwan.io/node-name"
+	ZoneMetadataKey     string = "cnwan.io/zone"
+)
+
+// EndpointSlices returns the source of the addresses of ClusterIP and
+// headless services, i.e. the ready endpoints of their EndpointSlices, with
+// their node name and zone as metadata.
+func EndpointSlices(cli client.Reader) AddressSource {
+	return AddressSourceFunc(func(ctx context.Context, serv *corev1.Service) ([]Address, error) {
+		if serv.Spec.Type != corev1.ServiceTypeClusterIP {
+			return []Address{}, nil
+		}
+
+		var sliceList discoveryv1beta1.EndpointSliceList
+		if err := cli.List(ctx, &sliceList,
+			client.InNamespace(serv.Namespace),
+			client.MatchingLabels{discoveryv1beta1.LabelServiceName: serv.Name}); err != nil {
+			return nil, fmt.Errorf("could not get endpoints from endpoint slices: %w", err)
+		}
+
+		// The same endpoint may temporarily appear on more than one slice:
+		// duplicates are removed by the Converter.
+		addrs := []Address{}
+		for _, slice := range sliceList.Items {
+			if slice.AddressType == discoveryv1beta1.AddressTypeFQDN {
+				continue
+			}
+
+			for _, sliceEndp := range slice.Endpoints {
+				// As per documentation, a nil value should be interpreted
+				// as ready.
+				if sliceEndp.Conditions.Ready != nil && !*sliceEndp.Conditions.Ready {
+					continue
+				}
+
+				metadata := map[string]string{}
+				if nodeName := sliceEndp.Topology[corev1.LabelHostname]; nodeName != "" {
+					metadata[NodeNameMetadataKey] = nodeName
+				}
+				if zone := getEndpointZone(sliceEndp.Topology); zone != "" {
+					metadata[ZoneMetadataKey] = zone
+				}
+
+				for _, port := range slice.Ports {
+					if port.Port == nil {
+						continue
+					}
+
+					protocol := ""
+					if port.Protocol != nil {
+						protocol = string(*port.Protocol)
+					}
+
+					for _, addr := range sliceEndp.Addresses {
+						addrs = append(addrs, Address{
+							Address:     addr,
+							Port:        *port.Port,
+							PortName:    stringValue(port.Name),
+							Protocol:    protocol,
+							AppProtocol: stringValue(port.AppProtocol),
+							Metadata:    metadata,
+						})
+					}
+				}
+			}
+		}
+
+		return addrs, nil
+	})
+}
+
+func getEndpointZone(topology map[string]string) string {
+	if zone := topology[corev1.LabelZoneFailureDomainStable]; zone != "" {
+		return zone
+	}
+
+	return topology[corev1.LabelZoneFailureDomain]
+}
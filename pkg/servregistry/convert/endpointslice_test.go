@@ -0,0 +1,99 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"testing"
+
+	a "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEndpointSlices(t *testing.T) {
+	ready, notReady := true, false
+	port := int32(8080)
+	topology := map[string]string{
+		corev1.LabelHostname:                "node-1",
+		corev1.LabelZoneFailureDomainStable: "zone-a",
+	}
+	newSlice := func(name, servName string, endps ...discoveryv1beta1.Endpoint) *discoveryv1beta1.EndpointSlice {
+		return &discoveryv1beta1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ns",
+				Labels:    map[string]string{discoveryv1beta1.LabelServiceName: servName},
+			},
+			AddressType: discoveryv1beta1.AddressTypeIPv4,
+			Endpoints:   endps,
+			Ports:       []discoveryv1beta1.EndpointPort{{Port: &port}},
+		}
+	}
+
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme,
+		newSlice("serv-1", "serv",
+			discoveryv1beta1.Endpoint{
+				Addresses:  []string{"10.1.0.1"},
+				Conditions: discoveryv1beta1.EndpointConditions{Ready: &ready},
+				Topology:   topology,
+			},
+			discoveryv1beta1.Endpoint{
+				Addresses:  []string{"10.1.0.2"},
+				Conditions: discoveryv1beta1.EndpointConditions{Ready: &notReady},
+			},
+		),
+		newSlice("serv-2", "serv",
+			discoveryv1beta1.Endpoint{Addresses: []string{"10.1.0.1"}, Topology: topology},
+			discoveryv1beta1.Endpoint{Addresses: []string{"10.1.0.3"}},
+		),
+		newSlice("another-1", "another",
+			discoveryv1beta1.Endpoint{Addresses: []string{"10.1.0.4"}},
+		),
+	)
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "serv", Namespace: "ns"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: corev1.ClusterIPNone},
+	}
+
+	assert := a.New(t)
+	conv := &Converter{Sources: []AddressSource{EndpointSlices(cli)}}
+	endps, err := conv.Endpoints(context.Background(), serv)
+	assert.NoError(err)
+	if !assert.Len(endps, 2) {
+		return
+	}
+
+	for _, endp := range endps {
+		assert.Equal(port, endp.Port)
+		assert.Equal(HashedName("serv", Address{Address: endp.Address, Port: port}), endp.Name)
+
+		switch endp.Address {
+		case "10.1.0.1":
+			assert.Equal(map[string]string{NodeNameMetadataKey: "node-1", ZoneMetadataKey: "zone-a"}, endp.Metadata)
+		case "10.1.0.3":
+			assert.Empty(endp.Metadata)
+		default:
+			assert.Fail("unexpected endpoint", endp.Address)
+		}
+	}
+}
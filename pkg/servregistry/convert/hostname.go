@@ -0,0 +1,114 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// HostnameResolver resolves hostnames to their IP addresses.
+// *net.Resolver implements it.
+type HostnameResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// LoadBalancerHostnames returns the source of the addresses of the load
+// balancer ingresses of LoadBalancer services that have a hostname but no
+// IP, e.g. the ones of AWS load balancers.
+//
+// If resolve is true, hostnames are resolved with resolver, or
+// net.DefaultResolver if nil, and their addresses are returned. Otherwise,
+// the hostnames themselves are returned as addresses.
+//
+// In case a hostname cannot be resolved, an error is returned rather than
+// an empty list, so that the service is not removed from the service
+// registry because of a temporary DNS failure.
+func LoadBalancerHostnames(resolve bool, resolver HostnameResolver) AddressSource {
+	return AddressSourceFunc(func(ctx context.Context, serv *corev1.Service) ([]Address, error) {
+		if serv.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			return []Address{}, nil
+		}
+
+		hosts := []string{}
+		for _, hostname := range IngressHostnames(serv) {
+			if !resolve {
+				hosts = append(hosts, hostname)
+				continue
+			}
+
+			ips, err := resolveHostname(ctx, resolver, hostname)
+			if err != nil {
+				return nil, fmt.Errorf("could not get endpoints from load balancer hostnames: %w", err)
+			}
+			hosts = append(hosts, ips...)
+		}
+
+		return addressesWithPorts(hosts, serv.Spec.Ports), nil
+	})
+}
+
+// IngressHostnames returns the hostnames of the load balancer ingresses
+// that don't have an IP.
+func IngressHostnames(serv *corev1.Service) []string {
+	hostnames := []string{}
+	for _, ing := range serv.Status.LoadBalancer.Ingress {
+		if ing.IP == "" && ing.Hostname != "" {
+			hostnames = append(hostnames, ing.Hostname)
+		}
+	}
+
+	return hostnames
+}
+
+// resolveHostname returns the IPv4 and IPv6 addresses of the hostname,
+// sorted and without duplicates so that the same DNS answers always produce
+// the same endpoints.
+func resolveHostname(ctx context.Context, resolver HostnameResolver, hostname string) ([]string, error) {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	ipAddrs, err := resolver.LookupIPAddr(ctx, hostname)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve hostname %s: %w", hostname, err)
+	}
+	if len(ipAddrs) == 0 {
+		return nil, fmt.Errorf("hostname %s resolved to no addresses", hostname)
+	}
+
+	dups := map[string]bool{}
+	ips := []string{}
+	for _, ipAddr := range ipAddrs {
+		ip := ipAddr.IP.String()
+		if dups[ip] {
+			continue
+		}
+
+		ips = append(ips, ip)
+		dups[ip] = true
+	}
+	sort.Strings(ips)
+
+	return ips, nil
+}
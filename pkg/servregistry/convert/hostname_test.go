@@ -0,0 +1,105 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	a "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeResolver resolves hostnames with the addresses in its map.
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ips, exists := f[host]
+	if !exists {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	ipAddrs := []net.IPAddr{}
+	for _, ip := range ips {
+		ipAddrs = append(ipAddrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return ipAddrs, nil
+}
+
+func TestLoadBalancerHostnames(t *testing.T) {
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "serv", Namespace: "ns"},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+				{IP: "1.1.1.1"},
+				{Hostname: "lb.elb.amazonaws.com"},
+			}},
+		},
+	}
+	addr := func(host string) Address {
+		return Address{Address: host, Port: 80, PortName: "http", Protocol: "TCP"}
+	}
+
+	cases := []struct {
+		id       string
+		resolve  bool
+		resolver HostnameResolver
+		expRes   []Address
+		expErr   error
+	}{
+		{
+			id:     "register",
+			expRes: []Address{addr("lb.elb.amazonaws.com")},
+		},
+		{
+			id:       "resolve",
+			resolve:  true,
+			resolver: fakeResolver{"lb.elb.amazonaws.com": {"3.3.3.3", "2.2.2.2", "3.3.3.3", "2001:db8::1"}},
+			expRes:   []Address{addr("2.2.2.2"), addr("2001:db8::1"), addr("3.3.3.3")},
+		},
+		{
+			id:       "not-resolved",
+			resolve:  true,
+			resolver: fakeResolver{},
+			expErr: fmt.Errorf("could not get endpoints from load balancer hostnames: %w",
+				fmt.Errorf("could not resolve hostname lb.elb.amazonaws.com: %w", &net.DNSError{Err: "no such host", Name: "lb.elb.amazonaws.com", IsNotFound: true})),
+		},
+		{
+			id:       "no-addresses",
+			resolve:  true,
+			resolver: fakeResolver{"lb.elb.amazonaws.com": {}},
+			expErr: fmt.Errorf("could not get endpoints from load balancer hostnames: %w",
+				fmt.Errorf("hostname lb.elb.amazonaws.com resolved to no addresses")),
+		},
+	}
+
+	assert := a.New(t)
+	for _, currCase := range cases {
+		res, err := LoadBalancerHostnames(currCase.resolve, currCase.resolver).Addresses(context.Background(), serv)
+		assert.Equal(currCase.expErr, err, "case %s failed", currCase.id)
+		assert.Equal(currCase.expRes, res, "case %s failed", currCase.id)
+	}
+}
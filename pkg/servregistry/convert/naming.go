@@ -0,0 +1,168 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"sort"
+	"strings"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+)
+
+const (
+	// defaultHashLength is the number of characters of the hash used in
+	// the names of endpoints, unless it is needed to tell them apart.
+	defaultHashLength int = 10
+	// fallbackNamePrefix replaces the name of the service in the names of
+	// endpoints that would not be supported by the service registry
+	// otherwise.
+	fallbackNamePrefix string = "endp"
+)
+
+// ServiceNamingStrategy is implemented by naming strategies that name all
+// the endpoints of a service together, e.g. to make sure that no two of
+// them have the same name.
+type ServiceNamingStrategy interface {
+	NamingStrategy
+	// EndpointNames returns the names of the addresses, in the same order.
+	EndpointNames(servName string, addrs []Address) []string
+}
+
+// SafeNaming is a ServiceNamingStrategy that names endpoints as HashedName
+// does, while making sure that names are unique within a service and
+// supported by the service registry.
+//
+// When some addresses of a service have the same hashed name, the one that
+// comes first in lexicographic order, e.g. 10.0.0.1-80 before 10.0.0.2-80,
+// keeps it, while more characters of their hash are used for the others
+// until they are told apart. As this only depends on the addresses, the same
+// addresses always get the same names, even after a restart, and endpoints
+// that don't collide keep the name that HashedName gives them. Please note
+// that when a new address collides with an existing endpoint and comes
+// before it, the existing endpoint is renamed.
+//
+// When a name is longer than the limit of the service registry, the name of
+// the service is truncated. If the name still does not match the pattern of
+// the service registry, the name of the service is replaced by "endp".
+type SafeNaming struct {
+	// Limits are the limits of the names of endpoints in the service
+	// registry.
+	Limits sr.NameLimits
+
+	// hashLength overrides defaultHashLength, to test collisions.
+	hashLength int
+}
+
+// NewSafeNaming returns a SafeNaming with the limits of the names of
+// endpoints included in the capabilities of the service registry.
+func NewSafeNaming(caps sr.Capabilities) *SafeNaming {
+	return &SafeNaming{Limits: caps.EndpNameLimits}
+}
+
+// EndpointName returns the name of the address, without checking for
+// collisions with other addresses of the service.
+func (n *SafeNaming) EndpointName(servName string, addr Address) string {
+	return n.name(servName, addressHash(addr), n.minHashLength())
+}
+
+// EndpointNames returns the names of the addresses of the service, making
+// sure that different addresses have different names.
+func (n *SafeNaming) EndpointNames(servName string, addrs []Address) []string {
+	hashes := make([]string, len(addrs))
+	hashLengths := map[string]int{}
+	keys := map[string]string{}
+	for i, addr := range addrs {
+		hashes[i] = addressHash(addr)
+		hashLengths[hashes[i]] = n.minHashLength()
+		keys[hashes[i]] = addressKey(addr)
+	}
+
+	// All the hashes that produce the same name, except the one of the first
+	// address, are made longer at the same time, so that the result does not
+	// depend on the order of addresses.
+	for collided := true; collided; {
+		collided = false
+
+		byName := map[string][]string{}
+		for hash, length := range hashLengths {
+			name := n.name(servName, hash, length)
+			byName[name] = append(byName[name], hash)
+		}
+
+		for _, sameName := range byName {
+			if len(sameName) < 2 {
+				continue
+			}
+
+			sort.Slice(sameName, func(i, j int) bool {
+				return keys[sameName[i]] < keys[sameName[j]]
+			})
+			for _, hash := range sameName[1:] {
+				if hashLengths[hash] < n.maxHashLength(hash) {
+					hashLengths[hash]++
+					collided = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, len(addrs))
+	for i, hash := range hashes {
+		names[i] = n.name(servName, hash, hashLengths[hash])
+	}
+
+	return names
+}
+
+func (n *SafeNaming) minHashLength() int {
+	if n.hashLength > 0 {
+		return n.hashLength
+	}
+
+	return defaultHashLength
+}
+
+// maxHashLength returns the length of the hash beyond which names would be
+// too long, even with a single character of the name of the service.
+func (n *SafeNaming) maxHashLength(hash string) int {
+	if n.Limits.MaxLength > 0 && n.Limits.MaxLength-2 < len(hash) {
+		return n.Limits.MaxLength - 2
+	}
+
+	return len(hash)
+}
+
+func (n *SafeNaming) name(servName, hash string, hashLength int) string {
+	suffix := hash[:hashLength]
+
+	prefix := servName
+	if room := n.Limits.MaxLength - len(suffix) - 1; n.Limits.MaxLength > 0 && len(prefix) > room {
+		if room < 1 {
+			room = 1
+		}
+		prefix = strings.TrimRight(prefix[:room], "-.")
+	}
+
+	name := prefix + "-" + suffix
+	if prefix == "" || (n.Limits.Pattern != nil && !n.Limits.Pattern.MatchString(name)) {
+		name = fallbackNamePrefix + "-" + suffix
+	}
+
+	return name
+}
@@ -0,0 +1,154 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
+	a "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSafeNamingEndpointName(t *testing.T) {
+	addr := Address{Address: "10.10.10.10", Port: 80}
+	dnsLabel := regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+
+	cases := []struct {
+		id       string
+		limits   sr.NameLimits
+		servName string
+		expRes   string
+	}{
+		{
+			id:       "no-limits",
+			servName: "payroll",
+			expRes:   "payroll-b93049dffe",
+		},
+		{
+			id:       "within-limits",
+			limits:   sr.NameLimits{MaxLength: 63, Pattern: dnsLabel},
+			servName: "payroll",
+			expRes:   "payroll-b93049dffe",
+		},
+		{
+			id:       "too-long",
+			limits:   sr.NameLimits{MaxLength: 20},
+			servName: "payroll-service",
+			expRes:   "payroll-s-b93049dffe",
+		},
+		{
+			id:       "too-long-truncated-at-dash",
+			limits:   sr.NameLimits{MaxLength: 19},
+			servName: "payroll-service",
+			expRes:   "payroll-b93049dffe",
+		},
+		{
+			id:       "invalid",
+			limits:   sr.NameLimits{Pattern: dnsLabel},
+			servName: "Payroll",
+			expRes:   "endp-b93049dffe",
+		},
+	}
+
+	assert := a.New(t)
+	for _, currCase := range cases {
+		n := NewSafeNaming(sr.Capabilities{EndpNameLimits: currCase.limits})
+		assert.Equal(currCase.expRes, n.EndpointName(currCase.servName, addr), "case %s failed", currCase.id)
+		assert.Equal([]string{currCase.expRes}, n.EndpointNames(currCase.servName, []Address{addr}), "case %s failed", currCase.id)
+	}
+}
+
+func TestSafeNamingEndpointNames(t *testing.T) {
+	// With a single character of the hash there are only 16 names, so some
+	// of these addresses must collide.
+	addrs := []Address{}
+	for i := 0; i < 40; i++ {
+		addrs = append(addrs, Address{Address: fmt.Sprintf("10.0.0.%d", i), Port: 80})
+	}
+	n := &SafeNaming{Limits: sr.NameLimits{MaxLength: 63}, hashLength: 1}
+
+	servName := strings.Repeat("s", 63)
+	first := map[string]string{}
+	for _, addr := range addrs {
+		shortName := n.EndpointName(servName, addr)
+		if key, exists := first[shortName]; !exists || addressKey(addr) < key {
+			first[shortName] = addressKey(addr)
+		}
+	}
+
+	assert := a.New(t)
+	names := n.EndpointNames(servName, addrs)
+	unique := map[string]bool{}
+	for i, name := range names {
+		assert.LessOrEqual(len(name), 63)
+		unique[name] = true
+
+		// Only the first of the colliding addresses keeps its name
+		shortName := n.EndpointName(servName, addrs[i])
+		assert.Equal(first[shortName] == addressKey(addrs[i]), name == shortName, name)
+	}
+	assert.Len(unique, len(addrs))
+
+	// Names don't depend on the order of the addresses
+	reversed := make([]Address, len(addrs))
+	for i, addr := range addrs {
+		reversed[len(addrs)-1-i] = addr
+	}
+	reversedNames := n.EndpointNames(servName, reversed)
+	for i := range names {
+		assert.Equal(names[i], reversedNames[len(names)-1-i])
+	}
+
+	// The same address always has the same name
+	names = n.EndpointNames("serv", []Address{addrs[0], addrs[0]})
+	assert.Equal(names[0], names[1])
+}
+
+func TestConverterWithSafeNaming(t *testing.T) {
+	assert := a.New(t)
+
+	addrs := []Address{}
+	for i := 0; i < 20; i++ {
+		addrs = append(addrs, Address{Address: fmt.Sprintf("10.0.0.%d", i), Port: 80})
+	}
+	source := AddressSourceFunc(func(context.Context, *corev1.Service) ([]Address, error) {
+		return addrs, nil
+	})
+	serv := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "serv", Namespace: "ns"}}
+
+	// Colliding names drop addresses with naming strategies that name them
+	// one by one, but not with SafeNaming.
+	conv := &Converter{Sources: []AddressSource{source}, Naming: NamingFunc(func(servName string, addr Address) string {
+		return servName + "-" + addressHash(addr)[:1]
+	})}
+	endpList, err := conv.Endpoints(context.Background(), serv)
+	assert.NoError(err)
+	assert.Less(len(endpList), len(addrs))
+
+	conv.Naming = &SafeNaming{hashLength: 1}
+	endpList, err = conv.Endpoints(context.Background(), serv)
+	assert.NoError(err)
+	assert.Len(endpList, len(addrs))
+}
@@ -0,0 +1,106 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NodePorts returns the source of the addresses of services of type
+// NodePort, i.e. the addresses of the ready nodes selected by nodeSelector
+// with the node ports of the service.
+//
+// addrTypes are the types of node addresses to use, in order of
+// preference: the first type that a node has is the one that is used.
+func NodePorts(cli client.Reader, nodeSelector labels.Selector, addrTypes []corev1.NodeAddressType) AddressSource {
+	return AddressSourceFunc(func(ctx context.Context, serv *corev1.Service) ([]Address, error) {
+		if serv.Spec.Type != corev1.ServiceTypeNodePort {
+			return []Address{}, nil
+		}
+
+		var nodeList corev1.NodeList
+		if err := cli.List(ctx, &nodeList, client.MatchingLabelsSelector{Selector: nodeSelector}); err != nil {
+			return nil, fmt.Errorf("could not get endpoints from nodes: %w", err)
+		}
+
+		ips := []string{}
+		for _, node := range nodeList.Items {
+			if !IsNodeReady(&node) {
+				continue
+			}
+
+			ips = append(ips, NodeAddresses(&node, addrTypes)...)
+		}
+
+		addrs := []Address{}
+		for _, port := range serv.Spec.Ports {
+			if port.NodePort == 0 {
+				continue
+			}
+
+			for _, ip := range ips {
+				addrs = append(addrs, Address{
+					Address:     ip,
+					Port:        port.NodePort,
+					PortName:    port.Name,
+					Protocol:    string(port.Protocol),
+					AppProtocol: stringValue(port.AppProtocol),
+				})
+			}
+		}
+
+		return addrs, nil
+	})
+}
+
+// NodeAddresses returns the addresses of the first type in addrTypes that
+// the node has.
+func NodeAddresses(node *corev1.Node, addrTypes []corev1.NodeAddressType) []string {
+	for _, addrType := range addrTypes {
+		addrs := []string{}
+		for _, addr := range node.Status.Addresses {
+			if addr.Type == addrType && addr.Address != "" {
+				addrs = append(addrs, addr.Address)
+			}
+		}
+
+		if len(addrs) > 0 {
+			return addrs
+		}
+	}
+
+	return []string{}
+}
+
+// IsNodeReady returns whether the node is ready, i.e. whether its addresses
+// are used by NodePorts.
+func IsNodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
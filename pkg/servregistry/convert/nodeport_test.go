@@ -0,0 +1,96 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"testing"
+
+	a "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestNode(name string, nodeLabels map[string]string, ready bool, addrs ...corev1.NodeAddress) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels},
+		Status: corev1.NodeStatus{
+			Addresses:  addrs,
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+		},
+	}
+}
+
+func TestNodeAddresses(t *testing.T) {
+	node := newTestNode("node", nil, true,
+		corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+		corev1.NodeAddress{Type: corev1.NodeHostName, Address: "node"},
+	)
+
+	assert := a.New(t)
+	assert.Equal([]string{"10.0.0.1"}, NodeAddresses(node, []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP}))
+	assert.Empty(NodeAddresses(node, []corev1.NodeAddressType{corev1.NodeExternalIP}))
+
+	node.Status.Addresses = append(node.Status.Addresses, corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "1.1.1.1"})
+	assert.Equal([]string{"1.1.1.1"}, NodeAddresses(node, []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP}))
+	assert.Equal([]string{"10.0.0.1"}, NodeAddresses(node, []corev1.NodeAddressType{corev1.NodeInternalIP, corev1.NodeExternalIP}))
+}
+
+func TestNodePorts(t *testing.T) {
+	edgeLabels := map[string]string{"role": "edge"}
+	cli := fake.NewFakeClientWithScheme(scheme.Scheme,
+		newTestNode("edge-1", edgeLabels, true, corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "1.1.1.1"}),
+		newTestNode("edge-2", edgeLabels, false, corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "2.2.2.2"}),
+		newTestNode("core-1", nil, true, corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "3.3.3.3"}),
+	)
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "serv", Namespace: "ns"},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, NodePort: 30080},
+				{Name: "no-node-port", Port: 90},
+			},
+		},
+	}
+	sel, _ := labels.Parse("role=edge")
+	addrTypes := []corev1.NodeAddressType{corev1.NodeExternalIP}
+
+	assert := a.New(t)
+	addrs, err := NodePorts(cli, sel, addrTypes).Addresses(context.Background(), serv)
+	assert.NoError(err)
+	assert.Equal([]Address{{Address: "1.1.1.1", Port: 30080, PortName: "http", Protocol: "TCP"}}, addrs)
+
+	addrs, err = NodePorts(cli, labels.Everything(), addrTypes).Addresses(context.Background(), serv)
+	assert.NoError(err)
+	assert.Len(addrs, 2)
+
+	serv.Spec.Type = corev1.ServiceTypeLoadBalancer
+	addrs, err = NodePorts(cli, labels.Everything(), addrTypes).Addresses(context.Background(), serv)
+	assert.NoError(err)
+	assert.Empty(addrs)
+}
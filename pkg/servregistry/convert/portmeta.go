@@ -0,0 +1,79 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// PortNameMetadataKey, ProtocolMetadataKey and AppProtocolMetadataKey
+	// are the metadata keys of the name, the protocol and the application
+	// protocol of the port of endpoints.
+	PortNameMetadataKey    string = "cnwan.io/port-name"
+	ProtocolMetadataKey    string = "cnwan.io/protocol"
+	AppProtocolMetadataKey string = "cnwan.io/app-protocol"
+	// IPFamilyMetadataKey is the metadata key of the IP family of the
+	// address of endpoints, i.e. IPv4 or IPv6.
+	IPFamilyMetadataKey string = "cnwan.io/ip-family"
+)
+
+// PortMetadataKeys are the keys of the metadata about the port and the
+// address of endpoints, which can be added by the Converter.
+var PortMetadataKeys = []string{
+	PortNameMetadataKey,
+	ProtocolMetadataKey,
+	AppProtocolMetadataKey,
+	IPFamilyMetadataKey,
+}
+
+// portMetadata returns the metadata about the port and the address.
+// Values that are not known are empty.
+func portMetadata(addr Address) map[string]string {
+	return map[string]string{
+		PortNameMetadataKey:    addr.PortName,
+		ProtocolMetadataKey:    addr.Protocol,
+		AppProtocolMetadataKey: addr.AppProtocol,
+		IPFamilyMetadataKey:    ipFamily(addr.Address),
+	}
+}
+
+// ipFamily returns the IP family of the address, or an empty string if it
+// is a hostname.
+func ipFamily(address string) string {
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return string(corev1.IPv4Protocol)
+	default:
+		return string(corev1.IPv6Protocol)
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
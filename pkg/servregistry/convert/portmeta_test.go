@@ -0,0 +1,128 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+	"testing"
+
+	a "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIPFamily(t *testing.T) {
+	assert := a.New(t)
+	assert.Equal("IPv4", ipFamily("10.10.10.10"))
+	assert.Equal("IPv6", ipFamily("2001:db8::1"))
+	assert.Empty(ipFamily("lb.example.com"))
+}
+
+func TestConverterPortMetadata(t *testing.T) {
+	https := "https"
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "serv", Namespace: "ns"},
+		Spec: corev1.ServiceSpec{
+			ExternalIPs: []string{"10.10.10.10", "2001:db8::1"},
+			Ports: []corev1.ServicePort{
+				{Name: "web", Protocol: corev1.ProtocolTCP, AppProtocol: &https, Port: 443},
+				{Name: "quic", Protocol: corev1.ProtocolUDP, Port: 443},
+			},
+		},
+	}
+	assert := a.New(t)
+
+	// Port metadata are only added if allowed
+	conv := &Converter{Sources: []AddressSource{ExternalIPs()}}
+	endpList, err := conv.Endpoints(context.Background(), serv)
+	assert.NoError(err)
+	assert.Len(endpList, 4)
+	for _, endp := range endpList {
+		assert.Empty(endp.Metadata)
+	}
+
+	conv.PortMetadata = PortMetadataKeys
+	endpList, err = conv.Endpoints(context.Background(), serv)
+	assert.NoError(err)
+	metadata := map[string]map[string]string{}
+	for _, endp := range endpList {
+		metadata[endp.Name] = endp.Metadata
+	}
+	assert.Equal(map[string]map[string]string{
+		HashedName("serv", Address{Address: "10.10.10.10", Port: 443}): {
+			PortNameMetadataKey:    "web",
+			ProtocolMetadataKey:    "TCP",
+			AppProtocolMetadataKey: "https",
+			IPFamilyMetadataKey:    "IPv4",
+		},
+		HashedName("serv", Address{Address: "2001:db8::1", Port: 443}): {
+			PortNameMetadataKey:    "web",
+			ProtocolMetadataKey:    "TCP",
+			AppProtocolMetadataKey: "https",
+			IPFamilyMetadataKey:    "IPv6",
+		},
+		HashedName("serv", Address{Address: "10.10.10.10", Port: 443, Protocol: "UDP", protocolInKey: true}): {
+			PortNameMetadataKey: "quic",
+			ProtocolMetadataKey: "UDP",
+			IPFamilyMetadataKey: "IPv4",
+		},
+		HashedName("serv", Address{Address: "2001:db8::1", Port: 443, Protocol: "UDP", protocolInKey: true}): {
+			PortNameMetadataKey: "quic",
+			ProtocolMetadataKey: "UDP",
+			IPFamilyMetadataKey: "IPv6",
+		},
+	}, metadata)
+
+	conv.PortMetadata = []string{ProtocolMetadataKey}
+	endpList, _ = conv.Endpoints(context.Background(), serv)
+	for _, endp := range endpList {
+		assert.Len(endp.Metadata, 1)
+		assert.Contains(endp.Metadata, ProtocolMetadataKey)
+	}
+}
+
+func TestConverterEndpointNamesByProtocol(t *testing.T) {
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "serv", Namespace: "ns"},
+		Spec: corev1.ServiceSpec{
+			ExternalIPs: []string{"10.10.10.10"},
+			Ports:       []corev1.ServicePort{{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53}},
+		},
+	}
+	// This is how the endpoint was named before protocols were told apart
+	oldName := "serv-" + addressHash(Address{Address: "10.10.10.10", Port: 53})[:defaultHashLength]
+	conv := &Converter{Sources: []AddressSource{ExternalIPs()}}
+	assert := a.New(t)
+
+	// Existing UDP endpoints keep their names
+	endpList, err := conv.Endpoints(context.Background(), serv)
+	assert.NoError(err)
+	if assert.Len(endpList, 1) {
+		assert.Equal(oldName, endpList[0].Name)
+	}
+
+	// Ports added with the same number get a different name
+	serv.Spec.Ports = append(serv.Spec.Ports, corev1.ServicePort{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53})
+	endpList, err = conv.Endpoints(context.Background(), serv)
+	assert.NoError(err)
+	if assert.Len(endpList, 2) {
+		assert.Equal(oldName, endpList[0].Name)
+		assert.NotEqual(oldName, endpList[1].Name)
+	}
+}
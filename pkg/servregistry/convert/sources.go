@@ -0,0 +1,69 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ExternalIPs returns the source of the external IPs of services, with
+// their ports.
+func ExternalIPs() AddressSource {
+	return AddressSourceFunc(func(_ context.Context, serv *corev1.Service) ([]Address, error) {
+		return addressesWithPorts(serv.Spec.ExternalIPs, serv.Spec.Ports), nil
+	})
+}
+
+// LoadBalancerIPs returns the source of the IPs of the load balancer
+// ingresses of services, with their ports.
+//
+// Ingresses with a hostname and no IP are ignored: use
+// LoadBalancerHostnames for them.
+func LoadBalancerIPs() AddressSource {
+	return AddressSourceFunc(func(_ context.Context, serv *corev1.Service) ([]Address, error) {
+		ips := []string{}
+		for _, ing := range serv.Status.LoadBalancer.Ingress {
+			if ing.IP != "" {
+				ips = append(ips, ing.IP)
+			}
+		}
+
+		return addressesWithPorts(ips, serv.Spec.Ports), nil
+	})
+}
+
+// addressesWithPorts returns an address for each port of each host.
+func addressesWithPorts(hosts []string, ports []corev1.ServicePort) []Address {
+	addrs := []Address{}
+	for _, port := range ports {
+		for _, host := range hosts {
+			addrs = append(addrs, Address{
+				Address:     host,
+				Port:        port.Port,
+				PortName:    port.Name,
+				Protocol:    string(port.Protocol),
+				AppProtocol: stringValue(port.AppProtocol),
+			})
+		}
+	}
+
+	return addrs
+}
@@ -0,0 +1,116 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"sort"
+	"strings"
+)
+
+// MetadataRule transforms the metadata of a service, e.g. to give the keys
+// of its annotations the names that the consumers of the service registry
+// expect.
+//
+// Operations are performed in the same order as the fields: keys are
+// renamed, stripped of a prefix, given a prefix and lowercased, then values
+// are mapped and defaults are set, so that the last operations refer to the
+// new keys.
+type MetadataRule struct {
+	// RenameKeys maps keys to their new names, e.g. cnwan.io/traffic-profile
+	// to traffic-profile.
+	RenameKeys map[string]string
+	// StripPrefix is removed from the keys that start with it, e.g.
+	// cnwan.io/ makes cnwan.io/traffic-profile traffic-profile.
+	StripPrefix string
+	// AddPrefix is added to all keys.
+	AddPrefix string
+	// LowercaseKeys specifies whether keys should be lowercased.
+	LowercaseKeys bool
+	// MapValues maps the values of a key to new values, e.g. gold to
+	// premium. Values that are not in the lookup table are not changed.
+	MapValues map[string]map[string]string
+	// Defaults are the metadata to set when they are missing.
+	Defaults map[string]string
+}
+
+// Apply returns the metadata transformed by the rule.
+//
+// When more keys end up with the same name, the one that already had it is
+// kept or, if none of them did, the first one in alphabetical order, so that
+// the result is always the same.
+func (r MetadataRule) Apply(metadata map[string]string) map[string]string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	transformed := map[string]string{}
+	origKeys := map[string]string{}
+	for _, key := range keys {
+		newKey := r.transformKey(key)
+		if newKey == "" {
+			continue
+		}
+
+		if origKey, exists := origKeys[newKey]; exists && (origKey == newKey || key != newKey) {
+			continue
+		}
+		origKeys[newKey] = key
+
+		val := metadata[key]
+		if newVal, exists := r.MapValues[newKey][val]; exists {
+			val = newVal
+		}
+		transformed[newKey] = val
+	}
+
+	for key, val := range r.Defaults {
+		if _, exists := transformed[key]; !exists {
+			transformed[key] = val
+		}
+	}
+
+	return transformed
+}
+
+// transformKey returns the new name of the key.
+func (r MetadataRule) transformKey(key string) string {
+	if newKey, exists := r.RenameKeys[key]; exists {
+		key = newKey
+	}
+	if r.StripPrefix != "" {
+		key = strings.TrimPrefix(key, r.StripPrefix)
+	}
+	key = r.AddPrefix + key
+	if r.LowercaseKeys {
+		key = strings.ToLower(key)
+	}
+
+	return key
+}
+
+// applyRules applies the rules in order to the metadata.
+func applyRules(rules []MetadataRule, metadata map[string]string) map[string]string {
+	for _, rule := range rules {
+		metadata = rule.Apply(metadata)
+	}
+
+	return metadata
+}
@@ -0,0 +1,120 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package convert
+
+import (
+	"testing"
+
+	a "github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMetadataRuleApply(t *testing.T) {
+	cases := []struct {
+		id     string
+		rule   MetadataRule
+		meta   map[string]string
+		expRes map[string]string
+	}{
+		{
+			id:     "no-op",
+			meta:   map[string]string{"cnwan.io/traffic-profile": "gold"},
+			expRes: map[string]string{"cnwan.io/traffic-profile": "gold"},
+		},
+		{
+			id:     "rename",
+			rule:   MetadataRule{RenameKeys: map[string]string{"cnwan.io/traffic-profile": "profile"}},
+			meta:   map[string]string{"cnwan.io/traffic-profile": "gold", "version": "v1"},
+			expRes: map[string]string{"profile": "gold", "version": "v1"},
+		},
+		{
+			id:     "strip-and-add-prefix",
+			rule:   MetadataRule{StripPrefix: "cnwan.io/", AddPrefix: "k8s-"},
+			meta:   map[string]string{"cnwan.io/traffic-profile": "gold", "version": "v1"},
+			expRes: map[string]string{"k8s-traffic-profile": "gold", "k8s-version": "v1"},
+		},
+		{
+			id:     "strip-whole-key",
+			rule:   MetadataRule{StripPrefix: "cnwan.io/"},
+			meta:   map[string]string{"cnwan.io/": "empty"},
+			expRes: map[string]string{},
+		},
+		{
+			id:     "lowercase",
+			rule:   MetadataRule{LowercaseKeys: true},
+			meta:   map[string]string{"Traffic-Profile": "Gold"},
+			expRes: map[string]string{"traffic-profile": "Gold"},
+		},
+		{
+			id: "map-values-of-new-keys",
+			rule: MetadataRule{
+				StripPrefix: "cnwan.io/",
+				MapValues:   map[string]map[string]string{"traffic-profile": {"gold": "premium"}},
+			},
+			meta:   map[string]string{"cnwan.io/traffic-profile": "gold", "tier": "gold"},
+			expRes: map[string]string{"traffic-profile": "premium", "tier": "gold"},
+		},
+		{
+			id:     "defaults",
+			rule:   MetadataRule{Defaults: map[string]string{"traffic-profile": "standard", "version": "v0"}},
+			meta:   map[string]string{"version": "v1"},
+			expRes: map[string]string{"traffic-profile": "standard", "version": "v1"},
+		},
+		{
+			id:     "collision-keeps-unchanged-key",
+			rule:   MetadataRule{StripPrefix: "cnwan.io/"},
+			meta:   map[string]string{"a.io/x": "no", "cnwan.io/version": "v1", "version": "v2"},
+			expRes: map[string]string{"a.io/x": "no", "version": "v2"},
+		},
+		{
+			id:     "collision-keeps-first-key",
+			rule:   MetadataRule{LowercaseKeys: true},
+			meta:   map[string]string{"VERSION": "v1", "Version": "v2"},
+			expRes: map[string]string{"version": "v1"},
+		},
+	}
+
+	assert := a.New(t)
+	for _, currCase := range cases {
+		assert.Equal(currCase.expRes, currCase.rule.Apply(currCase.meta), "case %s failed", currCase.id)
+	}
+}
+
+func TestConverterMetadataRules(t *testing.T) {
+	rules := []MetadataRule{
+		{StripPrefix: "cnwan.io/"},
+		{Defaults: map[string]string{"traffic-profile": "standard"}},
+	}
+	conv := &Converter{MetadataRules: rules}
+	serv := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "serv",
+			Namespace:   "ns",
+			Annotations: map[string]string{"cnwan.io/version": "v1"},
+		},
+	}
+
+	assert := a.New(t)
+	assert.Equal(map[string]string{"version": "v1", "traffic-profile": "standard"}, conv.Service(nil, serv).Metadata)
+
+	// Defaults don't make services without metadata registered
+	serv.Annotations = nil
+	assert.Empty(conv.Service(nil, serv).Metadata)
+}
@@ -0,0 +1,186 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package servregistry
+
+import (
+	"context"
+	"fmt"
+)
+
+// This file contains functions that detect and repair differences between
+// the data in Kubernetes and the data in the service registry, i.e. when
+// the service registry has been modified by someone else or an event has
+// been missed.
+// These functions belong to a ServiceRegistryBroker, defined in
+// broker.go
+
+// DriftAction is the action performed on an object of the service registry
+// to repair its drift.
+type DriftAction string
+
+const (
+	// DriftCreated means that the object was missing from the service
+	// registry and has been created.
+	DriftCreated DriftAction = "created"
+	// DriftUpdated means that the object had different data in the service
+	// registry and has been updated.
+	DriftUpdated DriftAction = "updated"
+	// DriftDeleted means that the object was not supposed to be in the
+	// service registry and has been deleted.
+	DriftDeleted DriftAction = "deleted"
+)
+
+// DriftFix describes an object of the service registry that has been
+// repaired.
+type DriftFix struct {
+	// Action is the action performed on the object
+	Action DriftAction
+	// NsName is the name of the namespace
+	NsName string
+	// ServName is the name of the service, empty if the object is a
+	// namespace
+	ServName string
+	// EndpName is the name of the endpoint, empty if the object is a
+	// namespace or a service
+	EndpName string
+}
+
+// String returns a human readable description of the fix.
+func (f DriftFix) String() string {
+	switch {
+	case f.ServName == "":
+		return fmt.Sprintf("%s namespace %s", f.Action, f.NsName)
+	case f.EndpName == "":
+		return fmt.Sprintf("%s service %s/%s", f.Action, f.NsName, f.ServName)
+	default:
+		return fmt.Sprintf("%s endpoint %s/%s/%s", f.Action, f.NsName, f.ServName, f.EndpName)
+	}
+}
+
+// IsOwned returns whether the metadata contain the metadata pair that marks
+// an object as owned by the operator.
+func (b *Broker) IsOwned(metadata map[string]string) bool {
+	by, exists := metadata[b.opMetaPair.Key]
+	return exists && by == b.opMetaPair.Value
+}
+
+// ListOwnedNs returns the namespaces in the service registry that are owned
+// by the operator.
+func (b *Broker) ListOwnedNs(ctx context.Context) ([]*Namespace, error) {
+	if b.Reg == nil {
+		return nil, ErrServRegNotProvided
+	}
+
+	nsList, err := b.Reg.ListNs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := []*Namespace{}
+	for _, ns := range nsList {
+		if b.IsOwned(ns.Metadata) {
+			owned = append(owned, ns)
+		}
+	}
+
+	return owned, nil
+}
+
+// ListOwnedServ returns the services of the provided namespace that are
+// owned by the operator.
+func (b *Broker) ListOwnedServ(ctx context.Context, nsName string) ([]*Service, error) {
+	if b.Reg == nil {
+		return nil, ErrServRegNotProvided
+	}
+
+	if len(nsName) == 0 {
+		return nil, ErrNsNameNotProvided
+	}
+
+	defer b.rLockNs(nsName)()
+
+	servList, err := b.Reg.ListServ(ctx, nsName)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := []*Service{}
+	for _, serv := range servList {
+		if b.IsOwned(serv.Metadata) {
+			owned = append(owned, serv)
+		}
+	}
+
+	return owned, nil
+}
+
+// SyncServ reflects the namespace, the service and its endpoints to the
+// service registry, just like ManageNs, ManageServ and ManageServEndps do,
+// but it also returns the objects that had to be repaired because they
+// did not match the provided data.
+//
+// Endpoints that could not be repaired are not included in the fixes, and
+// their errors are returned in the second value just like ManageServEndps
+// does.
+func (b *Broker) SyncServ(ctx context.Context, nsData *Namespace, servData *Service, endpsData []*Endpoint) (fixes []DriftFix, endpErrs map[string]error, err error) {
+	if b.Reg == nil {
+		return nil, nil, ErrServRegNotProvided
+	}
+
+	// -- Validate
+	if nsData == nil {
+		return nil, nil, ErrNsNotProvided
+	}
+
+	if servData == nil {
+		return nil, nil, ErrServNotProvided
+	}
+
+	// -- Do stuff
+	fixes = []DriftFix{}
+
+	// The Manage functions report what they wrote, so that differences are
+	// found with the same reads and under the same locks as the writes.
+	_, nsAction, err := b.manageNs(ctx, nsData)
+	if err != nil {
+		return nil, nil, err
+	}
+	if nsAction != "" {
+		fixes = append(fixes, DriftFix{Action: nsAction, NsName: nsData.Name})
+	}
+
+	_, servAction, err := b.manageServ(ctx, servData)
+	if err != nil {
+		return fixes, nil, err
+	}
+	if servAction != "" {
+		fixes = append(fixes, DriftFix{Action: servAction, NsName: servData.NsName, ServName: servData.Name})
+	}
+
+	endpErrs, endpActions, err := b.manageServEndps(ctx, nsData.Name, servData.Name, endpsData)
+	if err != nil {
+		return fixes, nil, err
+	}
+
+	for endpName, action := range endpActions {
+		fixes = append(fixes, DriftFix{Action: action, NsName: nsData.Name, ServName: servData.Name, EndpName: endpName})
+	}
+
+	return fixes, endpErrs, nil
+}
@@ -0,0 +1,185 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package servregistry
+
+import (
+	"context"
+	"testing"
+
+	a "github.com/stretchr/testify/assert"
+)
+
+func TestListOwned(t *testing.T) {
+	assert := a.New(t)
+	f := newFakeStruct()
+	b, _ := NewBroker(AdaptV1(f), MetadataPair{})
+
+	f.nsList["owned"] = &Namespace{Name: "owned", Metadata: map[string]string{defOpKey: defOpVal}}
+	f.nsList["not-owned"] = &Namespace{Name: "not-owned", Metadata: map[string]string{}}
+	f.servList["owned"] = &Service{Name: "owned", NsName: "owned", Metadata: map[string]string{defOpKey: defOpVal}}
+	f.servList["not-owned"] = &Service{Name: "not-owned", NsName: "owned", Metadata: map[string]string{defOpKey: "someone-else"}}
+
+	nsList, err := b.ListOwnedNs(context.Background())
+	assert.NoError(err)
+	if assert.Len(nsList, 1) {
+		assert.Equal("owned", nsList[0].Name)
+	}
+
+	servList, err := b.ListOwnedServ(context.Background(), "owned")
+	assert.NoError(err)
+	if assert.Len(servList, 1) {
+		assert.Equal("owned", servList[0].Name)
+	}
+
+	_, err = b.ListOwnedServ(context.Background(), "")
+	assert.Equal(ErrNsNameNotProvided, err)
+
+	b.Reg = nil
+	_, err = b.ListOwnedNs(context.Background())
+	assert.Equal(ErrServRegNotProvided, err)
+}
+
+func TestSyncServ(t *testing.T) {
+	nsName, servName := "ns", "serv"
+	ownedMeta := func(meta map[string]string) map[string]string {
+		m := map[string]string{defOpKey: defOpVal}
+		for k, v := range meta {
+			m[k] = v
+		}
+		return m
+	}
+	data := func() (*Namespace, *Service, []*Endpoint) {
+		return &Namespace{Name: nsName, Metadata: map[string]string{}},
+			&Service{Name: servName, NsName: nsName, Metadata: map[string]string{"key": "val"}},
+			[]*Endpoint{
+				{Name: "one", NsName: nsName, ServName: servName, Address: "10.10.10.10", Port: 80, Metadata: map[string]string{}},
+				{Name: "two", NsName: nsName, ServName: servName, Address: "10.10.10.11", Port: 80, Metadata: map[string]string{}},
+			}
+	}
+
+	cases := []struct {
+		id       string
+		prepare  func(f *fakeServReg)
+		expFixes []DriftFix
+	}{
+		{
+			id: "everything-missing",
+			expFixes: []DriftFix{
+				{Action: DriftCreated, NsName: nsName},
+				{Action: DriftCreated, NsName: nsName, ServName: servName},
+				{Action: DriftCreated, NsName: nsName, ServName: servName, EndpName: "one"},
+				{Action: DriftCreated, NsName: nsName, ServName: servName, EndpName: "two"},
+			},
+		},
+		{
+			id: "no-drift",
+			prepare: func(f *fakeServReg) {
+				f.nsList[nsName] = &Namespace{Name: nsName, Metadata: ownedMeta(nil)}
+				f.servList[servName] = &Service{Name: servName, NsName: nsName, Metadata: ownedMeta(map[string]string{"key": "val"})}
+				f.endpList["one"] = &Endpoint{Name: "one", NsName: nsName, ServName: servName, Address: "10.10.10.10", Port: 80, Metadata: ownedMeta(nil)}
+				f.endpList["two"] = &Endpoint{Name: "two", NsName: nsName, ServName: servName, Address: "10.10.10.11", Port: 80, Metadata: ownedMeta(nil)}
+			},
+			expFixes: []DriftFix{},
+		},
+		{
+			id: "modified-and-extra",
+			prepare: func(f *fakeServReg) {
+				f.nsList[nsName] = &Namespace{Name: nsName, Metadata: ownedMeta(nil)}
+				f.servList[servName] = &Service{Name: servName, NsName: nsName, Metadata: ownedMeta(map[string]string{"key": "changed"})}
+				f.endpList["one"] = &Endpoint{Name: "one", NsName: nsName, ServName: servName, Address: "10.10.10.10", Port: 8080, Metadata: ownedMeta(nil)}
+				f.endpList["two"] = &Endpoint{Name: "two", NsName: nsName, ServName: servName, Address: "10.10.10.11", Port: 80, Metadata: ownedMeta(nil)}
+				f.endpList["three"] = &Endpoint{Name: "three", NsName: nsName, ServName: servName, Address: "10.10.10.12", Port: 80, Metadata: ownedMeta(nil)}
+				f.endpList["not-owned"] = &Endpoint{Name: "not-owned", NsName: nsName, ServName: servName, Address: "10.10.10.13", Port: 80, Metadata: map[string]string{}}
+			},
+			expFixes: []DriftFix{
+				{Action: DriftUpdated, NsName: nsName, ServName: servName},
+				{Action: DriftUpdated, NsName: nsName, ServName: servName, EndpName: "one"},
+				{Action: DriftDeleted, NsName: nsName, ServName: servName, EndpName: "three"},
+			},
+		},
+	}
+
+	assert := a.New(t)
+	for _, currCase := range cases {
+		f := newFakeStruct()
+		b, _ := NewBroker(AdaptV1(f), MetadataPair{})
+		if currCase.prepare != nil {
+			currCase.prepare(f)
+		}
+
+		nsData, servData, endpsData := data()
+		fixes, endpErrs, err := b.SyncServ(context.Background(), nsData, servData, endpsData)
+		assert.NoError(err, "case %s failed", currCase.id)
+		assert.ElementsMatch(currCase.expFixes, fixes, "case %s failed", currCase.id)
+		for endpName, endpErr := range endpErrs {
+			assert.Equal("not-owned", endpName, "case %s failed", currCase.id)
+			assert.Equal(ErrEndpNotOwnedByOp, endpErr, "case %s failed", currCase.id)
+		}
+
+		// Nothing should be left to repair
+		nsData, servData, endpsData = data()
+		fixes, _, err = b.SyncServ(context.Background(), nsData, servData, endpsData)
+		assert.NoError(err, "case %s failed", currCase.id)
+		assert.Empty(fixes, "case %s failed", currCase.id)
+	}
+}
+
+func TestDriftFixString(t *testing.T) {
+	assert := a.New(t)
+	assert.Equal("created namespace ns", DriftFix{Action: DriftCreated, NsName: "ns"}.String())
+	assert.Equal("updated service ns/serv", DriftFix{Action: DriftUpdated, NsName: "ns", ServName: "serv"}.String())
+	assert.Equal("deleted endpoint ns/serv/endp", DriftFix{Action: DriftDeleted, NsName: "ns", ServName: "serv", EndpName: "endp"}.String())
+}
+
+// readCountingReg counts the reads sent to the service registry.
+type readCountingReg struct {
+	ServiceRegistryV2
+	reads int
+}
+
+func (r *readCountingReg) GetNs(ctx context.Context, name string) (*Namespace, error) {
+	r.reads++
+	return r.ServiceRegistryV2.GetNs(ctx, name)
+}
+
+func (r *readCountingReg) GetServ(ctx context.Context, nsName, servName string) (*Service, error) {
+	r.reads++
+	return r.ServiceRegistryV2.GetServ(ctx, nsName, servName)
+}
+
+func (r *readCountingReg) ListEndp(ctx context.Context, nsName, servName string) ([]*Endpoint, error) {
+	r.reads++
+	return r.ServiceRegistryV2.ListEndp(ctx, nsName, servName)
+}
+
+func TestSyncServReads(t *testing.T) {
+	f := newFakeStruct()
+	f.nsList["ns"] = &Namespace{Name: "ns", Metadata: map[string]string{defOpKey: defOpVal}}
+	f.servList["serv"] = &Service{Name: "serv", NsName: "ns", Metadata: map[string]string{defOpKey: defOpVal}}
+	reg := &readCountingReg{ServiceRegistryV2: AdaptV1(f)}
+	b, _ := NewBroker(reg, MetadataPair{})
+
+	assert := a.New(t)
+	fixes, _, err := b.SyncServ(context.Background(), &Namespace{Name: "ns"}, &Service{Name: "serv", NsName: "ns"}, nil)
+	assert.NoError(err)
+	assert.Empty(fixes)
+
+	// The namespace, the service and its endpoints are only read once
+	assert.Equal(3, reg.reads)
+}
@@ -18,6 +18,8 @@
 
 package servregistry
 
+import "context"
+
 // This file contains functions that perform operations on endpoints,
 // such as create/update/delete.
 // These functions belong to a ServiceRegistryBroker, defined in
@@ -40,26 +42,34 @@ package servregistry
 // This is because endpoints must all belong to the same service.
 //
 // For example: updates the metadata, address and/or of the endpoints.
-func (b *Broker) ManageServEndps(nsName, servName string, endpsData []*Endpoint) (endpErrs map[string]error, err error) {
+func (b *Broker) ManageServEndps(ctx context.Context, nsName, servName string, endpsData []*Endpoint) (endpErrs map[string]error, err error) {
+	endpErrs, _, err = b.manageServEndps(ctx, nsName, servName, endpsData)
+	return
+}
+
+// manageServEndps does what ManageServEndps does and also returns the
+// actions performed on the endpoints, keyed by endpoint name. Endpoints that
+// have not been written, either because they didn't need to or because of an
+// error, are not included.
+func (b *Broker) manageServEndps(ctx context.Context, nsName, servName string, endpsData []*Endpoint) (endpErrs map[string]error, actions map[string]DriftAction, err error) {
 	// endpsData: data of the endpoints in Kubernetes (latest update)
 	// regEndps: data of the endpoints currently in the service registry
 
 	if b.Reg == nil {
-		return nil, ErrServRegNotProvided
+		return nil, nil, ErrServRegNotProvided
 	}
 
 	// -- Validate
 	if len(nsName) == 0 {
-		return nil, ErrNsNameNotProvided
+		return nil, nil, ErrNsNameNotProvided
 	}
 
 	if len(servName) == 0 {
-		return nil, ErrServNameNotProvided
+		return nil, nil, ErrServNameNotProvided
 	}
 
 	// -- Init
-	b.lock.Lock()
-	defer b.lock.Unlock()
+	defer b.lockServ(nsName, servName)()
 	l := b.log.WithName("ManageServEndps").WithValues("serv-name", servName, "ns-name", nsName)
 
 	// -- Do stuff
@@ -73,11 +83,25 @@ func (b *Broker) ManageServEndps(nsName, servName string, endpsData []*Endpoint)
 		}
 		endpsMap[endp.Name].Metadata[b.opMetaPair.Key] = b.opMetaPair.Value
 	}
+	b.adaptEndps(endpsData)
 	endpErrs = map[string]error{}
+	actions = map[string]DriftAction{}
+
+	// Endpoints that are not supported by the service registry are neither
+	// written nor removed from it.
+	invalid := map[string]bool{}
+	for endpName, endpData := range endpsMap {
+		if verr := b.validateEndp(endpData); verr != nil {
+			l.WithValues("endp-name", endpName).Error(verr, "endpoint is not supported by the service registry")
+			endpErrs[endpName] = verr
+			invalid[endpName] = true
+			delete(endpsMap, endpName)
+		}
+	}
 
 	// Check what changed
 	var listRegEndps []*Endpoint
-	listRegEndps, err = b.Reg.ListEndp(nsName, servName)
+	listRegEndps, err = b.Reg.ListEndp(ctx, nsName, servName)
 	if err != nil {
 		return
 	}
@@ -88,6 +112,10 @@ func (b *Broker) ManageServEndps(nsName, servName string, endpsData []*Endpoint)
 
 		l := l.WithValues("endp-name", regEndp.Name)
 
+		if invalid[regEndp.Name] {
+			continue
+		}
+
 		endpData, exists := endpsMap[regEndp.Name]
 
 		if owner, ownerExists := regEndp.Metadata[b.opMetaPair.Key]; owner != b.opMetaPair.Value || !ownerExists {
@@ -102,13 +130,14 @@ func (b *Broker) ManageServEndps(nsName, servName string, endpsData []*Endpoint)
 
 			// This endpoint is not in the k8s service.
 			// We gotta delete this from the service registry.
-			delErr := b.Reg.DeleteEndp(nsName, servName, regEndp.Name)
+			delErr := b.Reg.DeleteEndp(ctx, nsName, servName, regEndp.Name)
 
 			if delErr != nil {
 				l.Error(delErr, "error while deleting endpoint from service registry")
 				endpErrs[regEndp.Name] = delErr
 			} else {
 				l.V(0).Info("endpoint deleted from service registry")
+				actions[regEndp.Name] = DriftDeleted
 			}
 
 			continue
@@ -118,7 +147,7 @@ func (b *Broker) ManageServEndps(nsName, servName string, endpsData []*Endpoint)
 		// We gotta check if the k8s one is different.
 		if endpData.Address != regEndp.Address || endpData.Port != regEndp.Port ||
 			!b.deepEqualMetadata(endpData.Metadata, regEndp.Metadata) {
-			_, updErr := b.Reg.UpdateEndp(endpData)
+			_, updErr := b.Reg.UpdateEndp(ctx, endpData)
 
 			if updErr != nil {
 				l.Error(updErr, "error while updating endpoint in service registry")
@@ -126,6 +155,7 @@ func (b *Broker) ManageServEndps(nsName, servName string, endpsData []*Endpoint)
 
 			} else {
 				l.V(0).Info("endpoint updated in service registry")
+				actions[regEndp.Name] = DriftUpdated
 			}
 		}
 
@@ -137,7 +167,7 @@ func (b *Broker) ManageServEndps(nsName, servName string, endpsData []*Endpoint)
 	for _, endpData := range endpsMap {
 		l := l.WithValues("endp-name", endpData.Name)
 
-		_, createErr := b.Reg.CreateEndp(endpData)
+		_, createErr := b.Reg.CreateEndp(ctx, endpData)
 		if createErr != nil {
 			l.Error(createErr, "error while creating endpoint in service registry")
 			endpErrs[endpData.Name] = createErr
@@ -145,6 +175,7 @@ func (b *Broker) ManageServEndps(nsName, servName string, endpsData []*Endpoint)
 		}
 
 		l.V(0).Info("endpoint created in service registry")
+		actions[endpData.Name] = DriftCreated
 	}
 
 	return
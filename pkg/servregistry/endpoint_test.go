@@ -19,6 +19,7 @@
 package servregistry
 
 import (
+	"context"
 	"testing"
 
 	a "github.com/stretchr/testify/assert"
@@ -28,11 +29,11 @@ func TestManageServEndps(t *testing.T) {
 	// prepare
 	nsName, servName := "ns", "serv"
 	var f *fakeServReg
-	b, _ := NewBroker(f, MetadataPair{})
+	b, _ := NewBroker(AdaptV1(f), MetadataPair{})
 
 	resetFake := func() {
 		f = newFakeStruct()
-		b.Reg = f
+		b.Reg = AdaptV1(f)
 	}
 
 	resetFake()
@@ -44,24 +45,24 @@ func TestManageServEndps(t *testing.T) {
 
 		// no service registry provided
 		b.Reg = nil
-		regEndps, err := b.ManageServEndps(nsName, servName, nil)
+		regEndps, err := b.ManageServEndps(context.Background(), nsName, servName, nil)
 		assert.Empty(regEndps)
 		assert.Equal(ErrServRegNotProvided, err)
 
 		// ns name is not specified
-		b.Reg = f
-		regEndps, err = b.ManageServEndps("", servName, nil)
+		b.Reg = AdaptV1(f)
+		regEndps, err = b.ManageServEndps(context.Background(), "", servName, nil)
 		assert.Empty(regEndps)
 		assert.Equal(ErrNsNameNotProvided, err)
 
 		// serv name is not specified
-		regEndps, err = b.ManageServEndps(nsName, "", nil)
+		regEndps, err = b.ManageServEndps(context.Background(), nsName, "", nil)
 		assert.Empty(regEndps)
 		assert.Equal(ErrServNameNotProvided, err)
 
 		// error while getting list of endpoints
 		f.endpList = map[string]*Endpoint{"list-error": {}}
-		regEndps, err = b.ManageServEndps(nsName, servName, []*Endpoint{})
+		regEndps, err = b.ManageServEndps(context.Background(), nsName, servName, []*Endpoint{})
 		assert.Empty(regEndps)
 		assert.Error(err)
 
@@ -82,7 +83,7 @@ func TestManageServEndps(t *testing.T) {
 		f.endpList[oneNotOwned.Name] = oneNotOwned
 		f.endpList[twoNotOwned.Name] = twoNotOwned
 
-		endpErrs, err := b.ManageServEndps(nsName, servName, []*Endpoint{oneChange, twoChange})
+		endpErrs, err := b.ManageServEndps(context.Background(), nsName, servName, []*Endpoint{oneChange, twoChange})
 		assert.NoError(err)
 		assert.Len(endpErrs, 2)
 		assert.Equal(ErrEndpNotOwnedByOp, endpErrs[oneNotOwned.Name])
@@ -104,7 +105,7 @@ func TestManageServEndps(t *testing.T) {
 		f.endpList[oneOwned.Name] = oneOwned
 		f.endpList[errored.Name] = errored
 
-		endpErrs, err := b.ManageServEndps(nsName, servName, []*Endpoint{})
+		endpErrs, err := b.ManageServEndps(context.Background(), nsName, servName, []*Endpoint{})
 		assert.NoError(err)
 		assert.Len(endpErrs, 1)
 		// assert that the error was indeed thrown by DeleteEndp and not by someone else
@@ -152,7 +153,7 @@ func TestManageServEndps(t *testing.T) {
 		f.endpList[por.Name] = por
 		f.endpList[errored.Name] = errored
 
-		endpErrs, err := b.ManageServEndps(nsName, servName, []*Endpoint{metadChange, adrChange, porChange, erroredChange, no})
+		endpErrs, err := b.ManageServEndps(context.Background(), nsName, servName, []*Endpoint{metadChange, adrChange, porChange, erroredChange, no})
 		assert.NoError(err)
 		assert.Len(endpErrs, 1)
 		_, expErr := f.UpdateEndp(&Endpoint{Name: "update-error"})
@@ -185,7 +186,7 @@ func TestManageServEndps(t *testing.T) {
 		f.endpList[exists.Name] = exists
 		f.endpList[del.Name] = del
 
-		endpErrs, err := b.ManageServEndps(nsName, servName, []*Endpoint{create, createNil, createErr, exists})
+		endpErrs, err := b.ManageServEndps(context.Background(), nsName, servName, []*Endpoint{create, createNil, createErr, exists})
 		assert.NoError(err)
 		assert.Len(endpErrs, 1)
 		_, expErr := f.CreateEndp(&Endpoint{Name: "create-error"})
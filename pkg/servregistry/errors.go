@@ -18,7 +18,10 @@
 
 package servregistry
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 var (
 	// ErrServRegNotProvided is returned when the broker has no service
@@ -67,4 +70,75 @@ var (
 	ErrEndpNameNotProvided error = errors.New("endpoint name not provided")
 	// ErrEndpNotProvided is returned when the endpoint is missing, i.e. is nil
 	ErrEndpNotProvided error = errors.New("endpoint is empty")
+	// ErrTooManyMetadata is returned when an object has more metadata than
+	// the service registry supports
+	ErrTooManyMetadata error = errors.New("too many metadata")
+	// ErrMetadataKeyTooLong is returned when a metadata key is longer than
+	// the service registry supports
+	ErrMetadataKeyTooLong error = errors.New("metadata key is too long")
+	// ErrMetadataValueTooLong is returned when a metadata value is longer
+	// than the service registry supports
+	ErrMetadataValueTooLong error = errors.New("metadata value is too long")
+	// ErrMetadataTooLarge is returned when the metadata of an object are
+	// larger than the service registry supports
+	ErrMetadataTooLarge error = errors.New("metadata are too large")
+	// ErrInvalidMetadataKey is returned when a metadata key contains
+	// characters that are not supported by the service registry
+	ErrInvalidMetadataKey error = errors.New("invalid metadata key")
+	// ErrIPv6NotSupported is returned when an endpoint has an IPv6 address
+	// but the service registry does not support it
+	ErrIPv6NotSupported error = errors.New("IPv6 addresses are not supported")
+	// ErrEndpNameTooLong is returned when the name of an endpoint is longer
+	// than the service registry supports
+	ErrEndpNameTooLong error = errors.New("endpoint name is too long")
+	// ErrInvalidEndpName is returned when the name of an endpoint contains
+	// characters that are not supported by the service registry
+	ErrInvalidEndpName error = errors.New("invalid endpoint name")
 )
+
+// IsRetryable returns whether the operation that returned the provided error
+// can be retried.
+//
+// Errors caused by invalid data or by objects not owned by the operator are
+// permanent, as retrying the operation would return the same error.
+// Every other error, e.g. a timeout or a temporary failure of the service
+// registry, is considered retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	for _, permErr := range []error{
+		ErrServRegNotProvided,
+		ErrServNotProvided,
+		ErrServNoMetadata,
+		ErrNsNotProvided,
+		ErrServNameNotProvided,
+		ErrNsNameNotProvided,
+		ErrNoEndpoints,
+		ErrNsNotEmpty,
+		ErrServNotEmpty,
+		ErrNsNotOwnedServs,
+		ErrServNotOwnedEndps,
+		ErrNsNotOwnedByOp,
+		ErrServNotOwnedByOp,
+		ErrEndpNotOwnedByOp,
+		ErrEndpNameNotProvided,
+		ErrEndpNotProvided,
+		ErrTooManyMetadata,
+		ErrMetadataKeyTooLong,
+		ErrMetadataValueTooLong,
+		ErrMetadataTooLarge,
+		ErrInvalidMetadataKey,
+		ErrIPv6NotSupported,
+		ErrEndpNameTooLong,
+		ErrInvalidEndpName,
+		context.Canceled,
+	} {
+		if errors.Is(err, permErr) {
+			return false
+		}
+	}
+
+	return true
+}
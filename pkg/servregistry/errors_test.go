@@ -0,0 +1,51 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package servregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	a "github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err    error
+		expRes bool
+	}{
+		{err: nil},
+		{err: ErrServNotOwnedByOp},
+		{err: fmt.Errorf("wrapped: %w", ErrEndpNotOwnedByOp)},
+		{err: ErrNsNameNotProvided},
+		{err: context.Canceled},
+		{err: &ValidationError{NsName: "ns", Err: ErrTooManyMetadata}},
+		{err: ErrTimeOutExpired, expRes: true},
+		{err: ErrNotFound, expRes: true},
+		{err: context.DeadlineExceeded, expRes: true},
+		{err: errors.New("connection refused"), expRes: true},
+	}
+
+	assert := a.New(t)
+	for _, currCase := range cases {
+		assert.Equal(currCase.expRes, IsRetryable(currCase.err), currCase.err)
+	}
+}
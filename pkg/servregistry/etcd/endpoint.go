@@ -28,7 +28,7 @@ import (
 // GetEndp returns the endpoint, if it exists.
 //
 // Read the documentation for this method on servregistry's package.
-func (e *EtcdServReg) GetEndp(nsName, servName, endpName string) (*sr.Endpoint, error) {
+func (e *EtcdServReg) GetEndp(ctx context.Context, nsName, servName, endpName string) (*sr.Endpoint, error) {
 	key, err := KeyFromServiceRegistryObject(&sr.Endpoint{
 		NsName: nsName, ServName: servName, Name: endpName,
 	})
@@ -36,7 +36,7 @@ func (e *EtcdServReg) GetEndp(nsName, servName, endpName string) (*sr.Endpoint,
 		return nil, err
 	}
 
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	endp, err := e.getOne(ctx, key)
@@ -50,13 +50,13 @@ func (e *EtcdServReg) GetEndp(nsName, servName, endpName string) (*sr.Endpoint,
 // ListServ returns a list of services inside the provided namespace.
 //
 // Read the documentation for this method on servregistry's package.
-func (e *EtcdServReg) ListEndp(nsName, servName string) (endpList []*sr.Endpoint, err error) {
+func (e *EtcdServReg) ListEndp(ctx context.Context, nsName, servName string) (endpList []*sr.Endpoint, err error) {
 	key, keyErr := KeyFromServiceRegistryObject(&sr.Service{NsName: nsName, Name: servName})
 	if keyErr != nil {
 		return nil, keyErr
 	}
 
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	err = e.getList(ctx, key, func(item []byte) {
@@ -77,8 +77,8 @@ func (e *EtcdServReg) ListEndp(nsName, servName string) (endpList []*sr.Endpoint
 // CreateEndp creates the endpoint.
 //
 // Read the documentation for this method on servregistry's package.
-func (e *EtcdServReg) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+func (e *EtcdServReg) CreateEndp(ctx context.Context, endp *sr.Endpoint) (*sr.Endpoint, error) {
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	if err := e.put(ctx, endp, false); err != nil {
@@ -89,8 +89,8 @@ func (e *EtcdServReg) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 }
 
 // UpdateEndp updates the endpoint.
-func (e *EtcdServReg) UpdateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+func (e *EtcdServReg) UpdateEndp(ctx context.Context, endp *sr.Endpoint) (*sr.Endpoint, error) {
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	if err := e.put(ctx, endp, true); err != nil {
@@ -103,7 +103,7 @@ func (e *EtcdServReg) UpdateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 // DeleteEndp deletes the endpoint.
 //
 // Read the documentation for this method on servregistry's package.
-func (e *EtcdServReg) DeleteEndp(nsName, servName, endpName string) error {
+func (e *EtcdServReg) DeleteEndp(ctx context.Context, nsName, servName, endpName string) error {
 	key, err := KeyFromServiceRegistryObject(&sr.Endpoint{
 		NsName: nsName, ServName: servName, Name: endpName,
 	})
@@ -111,7 +111,7 @@ func (e *EtcdServReg) DeleteEndp(nsName, servName, endpName string) error {
 		return err
 	}
 
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	return e.delete(ctx, key)
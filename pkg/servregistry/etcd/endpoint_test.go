@@ -34,9 +34,7 @@ import (
 
 func TestGetEndp(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	unknownErr := fmt.Errorf("unknwon")
 	endp := &sr.Endpoint{
 		NsName:   "namespace-name",
@@ -99,7 +97,7 @@ func TestGetEndp(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		res, err := e.GetEndp(currCase.nsName, currCase.servName, currCase.name)
+		res, err := e.GetEndp(context.Background(), currCase.nsName, currCase.servName, currCase.name)
 		errRes := a.Equal(currCase.expRes, res)
 
 		if currCase.expErr == unknownErr {
@@ -117,7 +115,7 @@ func TestGetEndp(t *testing.T) {
 func TestListEndp(t *testing.T) {
 	a := assert.New(t)
 	unknErr := fmt.Errorf("unknown")
-	e := &EtcdServReg{mainCtx: context.Background()}
+	e := &EtcdServReg{}
 	endp := &sr.Endpoint{
 		NsName:   "namespace-name",
 		ServName: "service-name",
@@ -188,7 +186,7 @@ func TestListEndp(t *testing.T) {
 		}
 
 		var errErr bool
-		res, err := e.ListEndp(currCase.nsName, currCase.servName)
+		res, err := e.ListEndp(context.Background(), currCase.nsName, currCase.servName)
 
 		errRes := a.Equal(currCase.expRes, res)
 		if currCase.expErr == unknErr {
@@ -205,9 +203,7 @@ func TestListEndp(t *testing.T) {
 
 func TestCreateEndp(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	unknownErr := fmt.Errorf("unknwon")
 	endp := &sr.Endpoint{
 		NsName:   "namespace-name",
@@ -312,7 +308,7 @@ func TestCreateEndp(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		res, err := e.CreateEndp(currCase.endp)
+		res, err := e.CreateEndp(context.Background(), currCase.endp)
 		errRes := a.Equal(currCase.expRes, res)
 
 		if currCase.expErr == unknownErr {
@@ -329,9 +325,7 @@ func TestCreateEndp(t *testing.T) {
 
 func TestUpdateEndp(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	unknownErr := fmt.Errorf("unknwon")
 	endp := &sr.Endpoint{
 		NsName:   "namespace-name",
@@ -436,7 +430,7 @@ func TestUpdateEndp(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		res, err := e.UpdateEndp(currCase.endp)
+		res, err := e.UpdateEndp(context.Background(), currCase.endp)
 		errRes := a.Equal(currCase.expRes, res)
 
 		if currCase.expErr == unknownErr {
@@ -453,9 +447,7 @@ func TestUpdateEndp(t *testing.T) {
 
 func TestDeleteEndp(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	txn := &fakeTXN{}
 	txn._if = func(cs ...clientv3.Cmp) clientv3.Txn {
 		return txn
@@ -520,7 +512,7 @@ func TestDeleteEndp(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		err := e.DeleteEndp(currCase.nsName, currCase.servName, currCase.endpName)
+		err := e.DeleteEndp(context.Background(), currCase.nsName, currCase.servName, currCase.endpName)
 		if currCase.expErr == unknErr {
 			errErr = a.Error(err)
 		} else {
@@ -19,7 +19,6 @@
 package etcd
 
 import (
-	"context"
 	"fmt"
 	"os"
 
@@ -29,7 +28,7 @@ import (
 // This example shows how to start the etcd service registry
 // without a custom global prefix. This means that default one will be used
 // (/service-registry/)
-func ExampleNewServiceRegistryWithEtcd() {
+func ExampleNewServiceRegistryWithEtcdV2() {
 	clientConfig := clientv3.Config{
 		Endpoints: []string{
 			"10.11.12.13:2379",
@@ -42,26 +41,21 @@ func ExampleNewServiceRegistryWithEtcd() {
 		os.Exit(1)
 	}
 
-	mainCtx, canc := context.WithCancel(context.Background())
-
-	// NewServiceRegistryWithEtcd returns an error only when the client is
+	// NewServiceRegistryWithEtcdV2 returns an error only when the client is
 	// nil: this is not our case and that's why we do not check the error
 	// here.
-	servreg := NewServiceRegistryWithEtcd(mainCtx, cli, nil)
+	servreg := NewServiceRegistryWithEtcdV2(cli, nil)
 
 	// Do something with the service registry...
 	_ = servreg
 
 	// Do other stuf...
-
-	// Cancel the context
-	canc()
 }
 
 // This example shows how to start the etcd service registry
 // with a custom global prefix. As it is shown, you can even use
 // multiple slashes.
-func ExampleNewServiceRegistryWithEtcd_withPrefix() {
+func ExampleNewServiceRegistryWithEtcdV2_withPrefix() {
 	clientConfig := clientv3.Config{
 		Endpoints: []string{
 			"10.11.12.13:2379",
@@ -75,26 +69,21 @@ func ExampleNewServiceRegistryWithEtcd_withPrefix() {
 		os.Exit(1)
 	}
 
-	mainCtx, canc := context.WithCancel(context.Background())
-
-	// NewServiceRegistryWithEtcd returns an error only when the client is
+	// NewServiceRegistryWithEtcdV2 returns an error only when the client is
 	// nil: this is not our case and that's why we do not check the error
 	// here.
-	servreg := NewServiceRegistryWithEtcd(mainCtx, cli, &prefix)
+	servreg := NewServiceRegistryWithEtcdV2(cli, &prefix)
 
 	// Do something with the service registry...
 	_ = servreg
 
 	// Do other stuf...
-
-	// Cancel the context
-	canc()
 }
 
 // This example shows how to start the etcd service registry
 // with a no prefix.
 // Actually, only "/" will be used in that case.
-func ExampleNewServiceRegistryWithEtcd_withEmptyPrefix() {
+func ExampleNewServiceRegistryWithEtcdV2_withEmptyPrefix() {
 	clientConfig := clientv3.Config{
 		Endpoints: []string{
 			"10.11.12.13:2379",
@@ -108,18 +97,13 @@ func ExampleNewServiceRegistryWithEtcd_withEmptyPrefix() {
 		os.Exit(1)
 	}
 
-	mainCtx, canc := context.WithCancel(context.Background())
-
-	// NewServiceRegistryWithEtcd returns an error only when the client is
+	// NewServiceRegistryWithEtcdV2 returns an error only when the client is
 	// nil: this is not our case and that's why we do not check the error
 	// here.
-	servreg := NewServiceRegistryWithEtcd(mainCtx, cli, &prefix)
+	servreg := NewServiceRegistryWithEtcdV2(cli, &prefix)
 
 	// Do something with the service registry...
 	_ = servreg
 
 	// Do other stuf...
-
-	// Cancel the context
-	canc()
 }
@@ -28,13 +28,13 @@ import (
 // GetNs returns the namespace if exists.
 //
 // Read the documentation for this method on servregistry's package.
-func (e *EtcdServReg) GetNs(name string) (*sr.Namespace, error) {
+func (e *EtcdServReg) GetNs(ctx context.Context, name string) (*sr.Namespace, error) {
 	key := KeyFromNames(name)
 	if !key.IsValid() {
 		return nil, sr.ErrNsNameNotProvided
 	}
 
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	ns, err := e.getOne(ctx, key)
@@ -48,8 +48,8 @@ func (e *EtcdServReg) GetNs(name string) (*sr.Namespace, error) {
 // ListNs returns a list of all namespaces.
 //
 // Read the documentation for this method on servregistry's package.
-func (e *EtcdServReg) ListNs() (nsList []*sr.Namespace, err error) {
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+func (e *EtcdServReg) ListNs(ctx context.Context) (nsList []*sr.Namespace, err error) {
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	err = e.getList(ctx, nil, func(item []byte) {
@@ -70,8 +70,8 @@ func (e *EtcdServReg) ListNs() (nsList []*sr.Namespace, err error) {
 // CreateNs creates the namespace.
 //
 // Read the documentation for this method on servregistry's package.
-func (e *EtcdServReg) CreateNs(ns *sr.Namespace) (*sr.Namespace, error) {
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+func (e *EtcdServReg) CreateNs(ctx context.Context, ns *sr.Namespace) (*sr.Namespace, error) {
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	if err := e.put(ctx, ns, false); err != nil {
@@ -84,8 +84,8 @@ func (e *EtcdServReg) CreateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 // UpdateNs updates the namespace.
 //
 // Read the documentation for this method on servregistry's package.
-func (e *EtcdServReg) UpdateNs(ns *sr.Namespace) (*sr.Namespace, error) {
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+func (e *EtcdServReg) UpdateNs(ctx context.Context, ns *sr.Namespace) (*sr.Namespace, error) {
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	if err := e.put(ctx, ns, true); err != nil {
@@ -98,13 +98,13 @@ func (e *EtcdServReg) UpdateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 // DeleteNs deletes the namespace.
 //
 // Read the documentation for this method on servregistry's package.
-func (e *EtcdServReg) DeleteNs(name string) error {
+func (e *EtcdServReg) DeleteNs(ctx context.Context, name string) error {
 	key := KeyFromNames(name)
 	if !key.IsValid() {
 		return sr.ErrNsNameNotProvided
 	}
 
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	return e.delete(ctx, key)
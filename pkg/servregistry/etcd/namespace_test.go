@@ -33,9 +33,7 @@ import (
 
 func TestGetNs(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	unknownErr := fmt.Errorf("unknwon")
 	ns := &sr.Namespace{
 		Name:     "namespace-name",
@@ -82,7 +80,7 @@ func TestGetNs(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		res, err := e.GetNs(currCase.name)
+		res, err := e.GetNs(context.Background(), currCase.name)
 		errRes := a.Equal(currCase.expRes, res)
 
 		if currCase.expErr == unknownErr {
@@ -100,7 +98,7 @@ func TestGetNs(t *testing.T) {
 func TestListNs(t *testing.T) {
 	a := assert.New(t)
 	unknErr := fmt.Errorf("unknown")
-	e := &EtcdServReg{mainCtx: context.Background()}
+	e := &EtcdServReg{}
 	ns := &sr.Namespace{
 		Name: "namespace-name",
 		Metadata: map[string]string{
@@ -152,7 +150,7 @@ func TestListNs(t *testing.T) {
 		}
 
 		var errErr bool
-		res, err := e.ListNs()
+		res, err := e.ListNs(context.Background())
 
 		errRes := a.Equal(currCase.expRes, res)
 		if currCase.expErr == unknErr {
@@ -169,9 +167,7 @@ func TestListNs(t *testing.T) {
 
 func TestCreateNs(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	unknownErr := fmt.Errorf("unknwon")
 	ns := &sr.Namespace{
 		Name:     "namespace-name",
@@ -231,7 +227,7 @@ func TestCreateNs(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		res, err := e.CreateNs(currCase.ns)
+		res, err := e.CreateNs(context.Background(), currCase.ns)
 		errRes := a.Equal(currCase.expRes, res)
 
 		if currCase.expErr == unknownErr {
@@ -248,9 +244,7 @@ func TestCreateNs(t *testing.T) {
 
 func TestUpdateNs(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	unknownErr := fmt.Errorf("unknwon")
 	ns := &sr.Namespace{
 		Name:     "namespace-name",
@@ -310,7 +304,7 @@ func TestUpdateNs(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		res, err := e.UpdateNs(currCase.ns)
+		res, err := e.UpdateNs(context.Background(), currCase.ns)
 		errRes := a.Equal(currCase.expRes, res)
 
 		if currCase.expErr == unknownErr {
@@ -327,9 +321,7 @@ func TestUpdateNs(t *testing.T) {
 
 func TestDeleteNs(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	txn := &fakeTXN{}
 	txn._if = func(cs ...clientv3.Cmp) clientv3.Txn {
 		return txn
@@ -377,7 +369,7 @@ func TestDeleteNs(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		err := e.DeleteNs(currCase.name)
+		err := e.DeleteNs(context.Background(), currCase.name)
 		if currCase.expErr == unknErr {
 			errErr = a.Error(err)
 		} else {
@@ -26,13 +26,13 @@ import (
 )
 
 // GetServ returns the service if exists.
-func (e *EtcdServReg) GetServ(nsName, servName string) (*sr.Service, error) {
+func (e *EtcdServReg) GetServ(ctx context.Context, nsName, servName string) (*sr.Service, error) {
 	key, err := KeyFromServiceRegistryObject(&sr.Service{NsName: nsName, Name: servName})
 	if err != nil {
 		return nil, err
 	}
 
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	serv, err := e.getOne(ctx, key)
@@ -44,12 +44,12 @@ func (e *EtcdServReg) GetServ(nsName, servName string) (*sr.Service, error) {
 }
 
 // ListServ returns a list of services inside the provided namespace.
-func (e *EtcdServReg) ListServ(nsName string) (servList []*sr.Service, err error) {
+func (e *EtcdServReg) ListServ(ctx context.Context, nsName string) (servList []*sr.Service, err error) {
 	if !KeyFromNames(nsName).IsValid() {
 		return nil, sr.ErrNsNameNotProvided
 	}
 
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	err = e.getList(ctx, KeyFromNames(nsName), func(item []byte) {
@@ -68,8 +68,8 @@ func (e *EtcdServReg) ListServ(nsName string) (servList []*sr.Service, err error
 }
 
 // CreateServ creates the service.
-func (e *EtcdServReg) CreateServ(serv *sr.Service) (*sr.Service, error) {
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+func (e *EtcdServReg) CreateServ(ctx context.Context, serv *sr.Service) (*sr.Service, error) {
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	if err := e.put(ctx, serv, false); err != nil {
@@ -80,8 +80,8 @@ func (e *EtcdServReg) CreateServ(serv *sr.Service) (*sr.Service, error) {
 }
 
 // UpdateServ updates the service.
-func (e *EtcdServReg) UpdateServ(serv *sr.Service) (*sr.Service, error) {
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+func (e *EtcdServReg) UpdateServ(ctx context.Context, serv *sr.Service) (*sr.Service, error) {
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	if err := e.put(ctx, serv, true); err != nil {
@@ -92,13 +92,13 @@ func (e *EtcdServReg) UpdateServ(serv *sr.Service) (*sr.Service, error) {
 }
 
 // DeleteServ deletes the service.
-func (e *EtcdServReg) DeleteServ(nsName, servName string) error {
+func (e *EtcdServReg) DeleteServ(ctx context.Context, nsName, servName string) error {
 	key, err := KeyFromServiceRegistryObject(&sr.Service{NsName: nsName, Name: servName})
 	if err != nil {
 		return err
 	}
 
-	ctx, canc := context.WithTimeout(e.mainCtx, defaultTimeout)
+	ctx, canc := context.WithTimeout(ctx, defaultTimeout)
 	defer canc()
 
 	return e.delete(ctx, key)
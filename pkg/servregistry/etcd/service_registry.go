@@ -20,8 +20,6 @@ package etcd
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"path"
 	"time"
@@ -31,33 +29,30 @@ import (
 	clientv3 "go.etcd.io/etcd/client/v3"
 	namespace "go.etcd.io/etcd/client/v3/namespace"
 	"gopkg.in/yaml.v3"
-	corev1 "k8s.io/api/core/v1"
 )
 
 const (
 	defaultPrefix string = "/service-registry/"
-	// timeout used when sending requests
-	// TODO: make this configurable or include context explicitly on each
-	// method (best way)
+	// timeout used when sending requests, if the context provided to the
+	// method expires later than this
 	defaultTimeout time.Duration = time.Duration(15) * time.Second
 )
 
 // EtcdServReg is a wrap around an etcd client that allows you to perform
 // service registry operations on etcd, such as storing, updating, deleting
 // or retrieving a namespace, service, or endpoint.
-// It is an implementation of ServiceRegistry defined in
+// It is an implementation of ServiceRegistryV2 defined in
 // https://github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry.
 type EtcdServReg struct {
-	cli     *clientv3.Client
-	kv      clientv3.KV
-	prefix  string
-	mainCtx context.Context
+	cli    *clientv3.Client
+	kv     clientv3.KV
+	prefix string
 }
 
-// NewServiceRegistryWithEtcd returns an instance of ServiceRegistry as defined
+// NewServiceRegistryWithEtcdV2 returns an instance of ServiceRegistryV2 as defined
 // by  with
 // ETCD as a backend.
-// https://pkg.go.dev/github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry#ServiceRegistry.
+// https://pkg.go.dev/github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry#ServiceRegistryV2.
 //
 // If prefix is not nil, all data will be prefixed with the value you set on
 // prefix, for example:
@@ -74,92 +69,32 @@ type EtcdServReg struct {
 //
 // Be careful with this value as it can potentially overwrite existing data.
 //
-// If context is not nil, it will be used as the main context upon which all
-// queries to etcd will be based on.
-//
 // This method returns an error only if the client provided to it is nil.
-func NewServiceRegistryWithEtcd(ctx context.Context, cli *clientv3.Client, prefix *string) *EtcdServReg {
+func NewServiceRegistryWithEtcdV2(cli *clientv3.Client, prefix *string) *EtcdServReg {
 	// Use the default prefix (/service-registry),
 	// unless the prefix is not nil, in which case we use that one.
 	pref := parsePrefix(prefix)
 
 	return &EtcdServReg{
-		cli:     cli,
-		kv:      namespace.NewKV(cli.KV, pref),
-		prefix:  pref,
-		mainCtx: ctx,
+		cli:    cli,
+		kv:     namespace.NewKV(cli.KV, pref),
+		prefix: pref,
 	}
 }
 
-func (e *EtcdServReg) ExtractData(ns *corev1.Namespace, serv *corev1.Service) (*sr.Namespace, *sr.Service, []*sr.Endpoint, error) {
-	// NOTE: on future versions, this function will be removed from service
-	// registry and moved to the broker instead: it's not this package's job
-	// to convert structs.
-	if ns == nil {
-		return nil, nil, nil, sr.ErrNsNotProvided
-	}
-	if serv == nil {
-		return nil, nil, nil, sr.ErrServNotProvided
-	}
-
-	// Parse the namespace
-	namespaceData := &sr.Namespace{
-		Name:     ns.Name,
-		Metadata: ns.Annotations,
-	}
-	if namespaceData.Metadata == nil {
-		namespaceData.Metadata = map[string]string{}
-	}
-
-	// Parse the service
-	// NOTE: we put metadata on the service in service directory,
-	// not on the endpoints
-	serviceData := &sr.Service{
-		Name:     serv.Name,
-		NsName:   ns.Name,
-		Metadata: serv.Annotations,
-	}
-	if serviceData.Metadata == nil {
-		serviceData.Metadata = map[string]string{}
-	}
-
-	// Get the endpoints from the service
-	// First, build the ips
-	ips := []string{}
-	// TODO: check if Spec is nil
-	// TODO: check if ExternalIPS is not nil
-	// ^ this will be performed on the new version of ExternalData
-	ips = append(ips, serv.Spec.ExternalIPs...)
-
-	// Get data from load balancers
-	for _, ing := range serv.Status.LoadBalancer.Ingress {
-		ips = append(ips, ing.IP)
-	}
-
-	endpointsData := []*sr.Endpoint{}
-	for _, port := range serv.Spec.Ports {
-		for _, ip := range ips {
-
-			// Create an hashed name for this
-			toBeHashed := fmt.Sprintf("%s-%d", ip, port.Port)
-			h := sha256.New()
-			h.Write([]byte(toBeHashed))
-			hash := hex.EncodeToString(h.Sum(nil))
-
-			// Only take the first 10 characters of the hashed name
-			name := fmt.Sprintf("%s-%s", serv.Name, hash[:10])
-			endpointsData = append(endpointsData, &sr.Endpoint{
-				Name:     name,
-				NsName:   namespaceData.Name,
-				ServName: serviceData.Name,
-				Address:  ip,
-				Port:     port.Port,
-				Metadata: map[string]string{},
-			})
-		}
-	}
+// NewServiceRegistryWithEtcd returns the same as NewServiceRegistryWithEtcdV2.
+//
+// Deprecated: use NewServiceRegistryWithEtcdV2 instead. The context is
+// ignored, as the one passed to each method is used instead.
+func NewServiceRegistryWithEtcd(ctx context.Context, cli *clientv3.Client, prefix *string) *EtcdServReg {
+	return NewServiceRegistryWithEtcdV2(cli, prefix)
+}
 
-	return namespaceData, serviceData, endpointsData, nil
+// Capabilities returns what etcd supports, i.e. everything: data is stored
+// as YAML with no limits other than the size of a request, and namespaces
+// and services are deleted together with their contents in a transaction.
+func (e *EtcdServReg) Capabilities() sr.Capabilities {
+	return sr.DefaultCapabilities()
 }
 
 func (e *EtcdServReg) getOne(ctx context.Context, key *KeyBuilder) (interface{}, error) {
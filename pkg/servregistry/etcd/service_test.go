@@ -34,9 +34,7 @@ import (
 
 func TestGetServ(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	unknownErr := fmt.Errorf("unknwon")
 	serv := &sr.Service{
 		NsName:   "namespace-name",
@@ -90,7 +88,7 @@ func TestGetServ(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		res, err := e.GetServ(currCase.nsName, currCase.name)
+		res, err := e.GetServ(context.Background(), currCase.nsName, currCase.name)
 		errRes := a.Equal(currCase.expRes, res)
 
 		if currCase.expErr == unknownErr {
@@ -108,7 +106,7 @@ func TestGetServ(t *testing.T) {
 func TestListServ(t *testing.T) {
 	a := assert.New(t)
 	unknErr := fmt.Errorf("unknown")
-	e := &EtcdServReg{mainCtx: context.Background()}
+	e := &EtcdServReg{}
 	serv := &sr.Service{
 		NsName: "namespace-name",
 		Name:   "service-name",
@@ -169,7 +167,7 @@ func TestListServ(t *testing.T) {
 		}
 
 		var errErr bool
-		res, err := e.ListServ(currCase.nsName)
+		res, err := e.ListServ(context.Background(), currCase.nsName)
 
 		errRes := a.Equal(currCase.expRes, res)
 		if currCase.expErr == unknErr {
@@ -185,9 +183,7 @@ func TestListServ(t *testing.T) {
 }
 func TestCreateServ(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	unknownErr := fmt.Errorf("unknwon")
 	serv := &sr.Service{
 		NsName:   "namespace-name",
@@ -284,7 +280,7 @@ func TestCreateServ(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		res, err := e.CreateServ(currCase.serv)
+		res, err := e.CreateServ(context.Background(), currCase.serv)
 		errRes := a.Equal(currCase.expRes, res)
 
 		if currCase.expErr == unknownErr {
@@ -301,9 +297,7 @@ func TestCreateServ(t *testing.T) {
 
 func TestUpdateServ(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	unknownErr := fmt.Errorf("unknwon")
 	serv := &sr.Service{
 		NsName:   "namespace-name",
@@ -400,7 +394,7 @@ func TestUpdateServ(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		res, err := e.UpdateServ(currCase.serv)
+		res, err := e.UpdateServ(context.Background(), currCase.serv)
 		errRes := a.Equal(currCase.expRes, res)
 
 		if currCase.expErr == unknownErr {
@@ -417,9 +411,7 @@ func TestUpdateServ(t *testing.T) {
 
 func TestDeleteServ(t *testing.T) {
 	a := assert.New(t)
-	e := &EtcdServReg{
-		mainCtx: context.Background(),
-	}
+	e := &EtcdServReg{}
 	txn := &fakeTXN{}
 	txn._if = func(cs ...clientv3.Cmp) clientv3.Txn {
 		return txn
@@ -475,7 +467,7 @@ func TestDeleteServ(t *testing.T) {
 		e.kv = f
 
 		var errErr bool
-		err := e.DeleteServ(currCase.nsName, currCase.servName)
+		err := e.DeleteServ(context.Background(), currCase.nsName, currCase.servName)
 		if currCase.expErr == unknErr {
 			errErr = a.Error(err)
 		} else {
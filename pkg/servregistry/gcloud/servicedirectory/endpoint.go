@@ -30,14 +30,14 @@ import (
 )
 
 // GetEndp returns the endpoint if exists.
-func (s *Handler) GetEndp(nsName, servName, endpName string) (*sr.Endpoint, error) {
+func (s *Handler) GetEndp(ctx context.Context, nsName, servName, endpName string) (*sr.Endpoint, error) {
 	// -- Init
 	if err := s.checkNames(&nsName, &servName, &endpName); err != nil {
 		return nil, err
 	}
 
 	endpPath := s.getResourcePath(servDirPath{namespace: nsName, service: servName, endpoint: endpName})
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	sdEndp, err := s.Client.GetEndpoint(ctx, &sdpb.GetEndpointRequest{Name: endpPath})
@@ -46,6 +46,8 @@ func (s *Handler) GetEndp(nsName, servName, endpName string) (*sr.Endpoint, erro
 			Name:     endpName,
 			NsName:   nsName,
 			ServName: servName,
+			Address:  sdEndp.Address,
+			Port:     sdEndp.Port,
 			Metadata: sdEndp.Annotations,
 		}
 		if endp.Metadata == nil {
@@ -59,13 +61,13 @@ func (s *Handler) GetEndp(nsName, servName, endpName string) (*sr.Endpoint, erro
 }
 
 // ListServ returns a list of services inside the provided namespace.
-func (s *Handler) ListEndp(nsName, servName string) (endpList []*sr.Endpoint, err error) {
+func (s *Handler) ListEndp(ctx context.Context, nsName, servName string) (endpList []*sr.Endpoint, err error) {
 	// -- Init
 	if err := s.checkNames(&nsName, &servName, nil); err != nil {
 		return nil, err
 	}
 	l := s.Log.WithName("ListEndp").WithValues("ns-name", nsName, "serv-name", servName)
-	ctx, canc := context.WithTimeout(s.Context, time.Minute)
+	ctx, canc := context.WithTimeout(ctx, time.Minute)
 	defer canc()
 
 	req := &sdpb.ListEndpointsRequest{
@@ -100,6 +102,8 @@ func (s *Handler) ListEndp(nsName, servName string) (endpList []*sr.Endpoint, er
 			Name:     splitName[len(splitName)-1],
 			ServName: servName,
 			NsName:   nsName,
+			Address:  nextEndp.Address,
+			Port:     nextEndp.Port,
 			Metadata: nextEndp.Annotations,
 		}
 		if endp.Metadata == nil {
@@ -113,7 +117,7 @@ func (s *Handler) ListEndp(nsName, servName string) (endpList []*sr.Endpoint, er
 }
 
 // CreateEndp creates the endpoint.
-func (s *Handler) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
+func (s *Handler) CreateEndp(ctx context.Context, endp *sr.Endpoint) (*sr.Endpoint, error) {
 	// -- Init
 	if endp == nil {
 		return nil, sr.ErrEndpNotProvided
@@ -122,7 +126,7 @@ func (s *Handler) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 		return nil, err
 	}
 
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	endpToCreate := &sdpb.Endpoint{
@@ -152,7 +156,7 @@ func (s *Handler) CreateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 }
 
 // UpdateEndp updates the endpoint.
-func (s *Handler) UpdateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
+func (s *Handler) UpdateEndp(ctx context.Context, endp *sr.Endpoint) (*sr.Endpoint, error) {
 	// -- Init
 	if endp == nil {
 		return nil, sr.ErrEndpNotProvided
@@ -162,7 +166,7 @@ func (s *Handler) UpdateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 	}
 
 	endpPath := s.getResourcePath(servDirPath{namespace: endp.NsName, service: endp.ServName, endpoint: endp.Name})
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	endpToUpd := &sdpb.Endpoint{
@@ -188,13 +192,13 @@ func (s *Handler) UpdateEndp(endp *sr.Endpoint) (*sr.Endpoint, error) {
 }
 
 // DeleteEndp deletes the endpoint.
-func (s *Handler) DeleteEndp(nsName, servName, endpName string) error {
+func (s *Handler) DeleteEndp(ctx context.Context, nsName, servName, endpName string) error {
 	// -- Init
 	if err := s.checkNames(&nsName, &servName, &endpName); err != nil {
 		return err
 	}
 
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	req := &sdpb.DeleteEndpointRequest{
@@ -19,6 +19,7 @@
 package servicedirectory
 
 import (
+	"context"
 	"testing"
 
 	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
@@ -33,17 +34,17 @@ func TestGetEndp(t *testing.T) {
 	testErr := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		regEndp, err := s.GetEndp(nsName, servName, "get-error")
+		regEndp, err := s.GetEndp(context.Background(), nsName, servName, "get-error")
 		assert.Nil(regEndp)
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
-		regEndp, err = s.GetEndp(nsName, servName, "timeout-error")
+		regEndp, err = s.GetEndp(context.Background(), nsName, servName, "timeout-error")
 		assert.Nil(regEndp)
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
-		regEndp, err = s.GetEndp(nsName, servName, "get-not-found")
+		regEndp, err = s.GetEndp(context.Background(), nsName, servName, "get-not-found")
 		assert.Nil(regEndp)
 		assert.Equal(sr.ErrNotFound, err)
 	}
@@ -52,11 +53,13 @@ func TestGetEndp(t *testing.T) {
 	testOk := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		regEndp, err := s.GetEndp(nsName, servName, endpName)
+		regEndp, err := s.GetEndp(context.Background(), nsName, servName, endpName)
 		assert.NotNil(regEndp)
 		assert.NoError(err)
 		assert.NotContains(regEndp.Name, "/")
 		assert.Equal(regEndp.NsName, nsName)
+		assert.Equal("10.10.10.10", regEndp.Address)
+		assert.Equal(int32(8080), regEndp.Port)
 	}
 
 	testErr(t)
@@ -72,24 +75,24 @@ func TestCreateEndp(t *testing.T) {
 	testErr := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		regEndp, err := s.CreateEndp(nil)
+		regEndp, err := s.CreateEndp(context.Background(), nil)
 		assert.Nil(regEndp)
 		assert.Equal(sr.ErrEndpNotProvided, err)
 
 		req.Name = "create-error"
-		regEndp, err = s.CreateEndp(req)
+		regEndp, err = s.CreateEndp(context.Background(), req)
 		assert.Nil(regEndp)
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
 		req.Name = "timeout-error"
-		regEndp, err = s.CreateEndp(req)
+		regEndp, err = s.CreateEndp(context.Background(), req)
 		assert.Nil(regEndp)
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
 		req.Name = "create-exists"
-		regEndp, err = s.CreateEndp(req)
+		regEndp, err = s.CreateEndp(context.Background(), req)
 		assert.Nil(regEndp)
 		assert.Equal(sr.ErrAlreadyExists, err)
 	}
@@ -99,7 +102,7 @@ func TestCreateEndp(t *testing.T) {
 		assert := a.New(tt)
 
 		req.Name = endpName
-		regEndp, err := s.CreateEndp(req)
+		regEndp, err := s.CreateEndp(context.Background(), req)
 		assert.NotNil(regEndp)
 		assert.NoError(err)
 		assert.NotContains(regEndp.Name, "/")
@@ -120,24 +123,24 @@ func TestUpdateEndp(t *testing.T) {
 	testErr := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		regEndp, err := s.UpdateEndp(nil)
+		regEndp, err := s.UpdateEndp(context.Background(), nil)
 		assert.Nil(regEndp)
 		assert.Equal(sr.ErrEndpNotProvided, err)
 
 		req.Name = "update-error"
-		regEndp, err = s.UpdateEndp(req)
+		regEndp, err = s.UpdateEndp(context.Background(), req)
 		assert.Nil(regEndp)
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
 		req.Name = "timeout-error"
-		regEndp, err = s.UpdateEndp(req)
+		regEndp, err = s.UpdateEndp(context.Background(), req)
 		assert.Nil(regEndp)
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
 		req.Name = "update-not-found"
-		regEndp, err = s.UpdateEndp(req)
+		regEndp, err = s.UpdateEndp(context.Background(), req)
 		assert.Nil(regEndp)
 		assert.Equal(sr.ErrNotFound, err)
 	}
@@ -147,7 +150,7 @@ func TestUpdateEndp(t *testing.T) {
 		assert := a.New(tt)
 
 		req.Name = endpName
-		regEndp, err := s.UpdateEndp(req)
+		regEndp, err := s.UpdateEndp(context.Background(), req)
 		assert.NotNil(regEndp)
 		assert.NoError(err)
 		assert.NotContains(regEndp.Name, "/")
@@ -166,15 +169,15 @@ func TestDeleteEndp(t *testing.T) {
 	testErr := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		err := s.DeleteEndp(nsName, servName, "delete-error")
+		err := s.DeleteEndp(context.Background(), nsName, servName, "delete-error")
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
-		err = s.DeleteEndp(nsName, servName, "timeout-error")
+		err = s.DeleteEndp(context.Background(), nsName, servName, "timeout-error")
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
-		err = s.DeleteEndp(nsName, servName, "delete-not-found")
+		err = s.DeleteEndp(context.Background(), nsName, servName, "delete-not-found")
 		assert.Equal(sr.ErrNotFound, err)
 	}
 
@@ -182,7 +185,7 @@ func TestDeleteEndp(t *testing.T) {
 	testOk := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		err := s.DeleteEndp(nsName, servName, endpName)
+		err := s.DeleteEndp(context.Background(), nsName, servName, endpName)
 		assert.NoError(err)
 	}
 
@@ -39,7 +39,6 @@ func getFakeHandler() *Handler {
 	return &Handler{
 		ProjectID:     "project",
 		DefaultRegion: "us",
-		Context:       context.Background(),
 		Client:        &fakeRegClient{},
 		Log:           zap.New(zap.UseDevMode(true)),
 	}
@@ -209,7 +208,7 @@ func (f *fakeRegClient) GetEndpoint(ctx context.Context, req *sdpb.GetEndpointRe
 		return nil, status.Error(codes.DeadlineExceeded, codes.DeadlineExceeded.String())
 	}
 
-	return &sdpb.Endpoint{Name: "one/two/three/four/five/six/seven/eight/nine/" + req.Name}, nil
+	return &sdpb.Endpoint{Name: "one/two/three/four/five/six/seven/eight/nine/" + req.Name, Address: "10.10.10.10", Port: 8080}, nil
 }
 
 func (f *fakeRegClient) CreateEndpoint(ctx context.Context, req *sdpb.CreateEndpointRequest, opts ...gax.CallOption) (*sdpb.Endpoint, error) {
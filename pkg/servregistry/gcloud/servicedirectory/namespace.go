@@ -29,14 +29,14 @@ import (
 )
 
 // GetNs returns the namespace if exists.
-func (s *Handler) GetNs(name string) (*sr.Namespace, error) {
+func (s *Handler) GetNs(ctx context.Context, name string) (*sr.Namespace, error) {
 	// -- Init
 	if err := s.checkNames(&name, nil, nil); err != nil {
 		return nil, err
 	}
 
 	nsPath := s.getResourcePath(servDirPath{namespace: name})
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	sdNs, err := s.Client.GetNamespace(ctx, &sdpb.GetNamespaceRequest{Name: nsPath})
@@ -56,10 +56,10 @@ func (s *Handler) GetNs(name string) (*sr.Namespace, error) {
 }
 
 // ListNs returns a list of all namespaces.
-func (s *Handler) ListNs() ([]*sr.Namespace, error) {
+func (s *Handler) ListNs(ctx context.Context) ([]*sr.Namespace, error) {
 	// -- Init
 	l := s.Log.WithName("ListNs")
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	req := &sdpb.ListNamespacesRequest{
@@ -105,7 +105,7 @@ func (s *Handler) ListNs() ([]*sr.Namespace, error) {
 }
 
 // CreateNs creates the namespace.
-func (s *Handler) CreateNs(ns *sr.Namespace) (*sr.Namespace, error) {
+func (s *Handler) CreateNs(ctx context.Context, ns *sr.Namespace) (*sr.Namespace, error) {
 	// -- Init
 	if ns == nil {
 		return nil, sr.ErrNsNotProvided
@@ -114,7 +114,7 @@ func (s *Handler) CreateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 		return nil, err
 	}
 
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	nsToCreate := &sdpb.Namespace{
@@ -140,7 +140,7 @@ func (s *Handler) CreateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 }
 
 // UpdateNs updates the namespace.
-func (s *Handler) UpdateNs(ns *sr.Namespace) (*sr.Namespace, error) {
+func (s *Handler) UpdateNs(ctx context.Context, ns *sr.Namespace) (*sr.Namespace, error) {
 	// -- Init
 	if ns == nil {
 		return nil, sr.ErrNsNotProvided
@@ -149,7 +149,7 @@ func (s *Handler) UpdateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 		return nil, err
 	}
 
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	nsToUpd := &sdpb.Namespace{
@@ -173,13 +173,13 @@ func (s *Handler) UpdateNs(ns *sr.Namespace) (*sr.Namespace, error) {
 }
 
 // DeleteNs deletes the namespace.
-func (s *Handler) DeleteNs(name string) error {
+func (s *Handler) DeleteNs(ctx context.Context, name string) error {
 	// -- Init
 	if err := s.checkNames(&name, nil, nil); err != nil {
 		return err
 	}
 
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	req := &sdpb.DeleteNamespaceRequest{
@@ -19,6 +19,7 @@
 package servicedirectory
 
 import (
+	"context"
 	"testing"
 
 	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
@@ -33,17 +34,17 @@ func TestGetNs(t *testing.T) {
 	testErr := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		regNs, err := s.GetNs("get-error")
+		regNs, err := s.GetNs(context.Background(), "get-error")
 		assert.Nil(regNs)
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
-		regNs, err = s.GetNs("timeout-error")
+		regNs, err = s.GetNs(context.Background(), "timeout-error")
 		assert.Nil(regNs)
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
-		regNs, err = s.GetNs("get-not-found")
+		regNs, err = s.GetNs(context.Background(), "get-not-found")
 		assert.Nil(regNs)
 		assert.Equal(sr.ErrNotFound, err)
 	}
@@ -52,7 +53,7 @@ func TestGetNs(t *testing.T) {
 	testOk := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		regNs, err := s.GetNs(nsName)
+		regNs, err := s.GetNs(context.Background(), nsName)
 		assert.NotNil(regNs)
 		assert.NoError(err)
 		assert.NotContains(regNs.Name, "/")
@@ -72,19 +73,19 @@ func TestCreateNs(t *testing.T) {
 		assert := a.New(tt)
 
 		ns.Name = "create-error"
-		regNs, err := s.CreateNs(ns)
+		regNs, err := s.CreateNs(context.Background(), ns)
 		assert.Nil(regNs)
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
 		ns.Name = "timeout-error"
-		regNs, err = s.CreateNs(ns)
+		regNs, err = s.CreateNs(context.Background(), ns)
 		assert.Nil(regNs)
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
 		ns.Name = "create-exists"
-		regNs, err = s.CreateNs(ns)
+		regNs, err = s.CreateNs(context.Background(), ns)
 		assert.Nil(regNs)
 		assert.Equal(sr.ErrAlreadyExists, err)
 	}
@@ -94,7 +95,7 @@ func TestCreateNs(t *testing.T) {
 		assert := a.New(tt)
 
 		ns.Name = nsName
-		regNs, err := s.CreateNs(ns)
+		regNs, err := s.CreateNs(context.Background(), ns)
 		assert.NotNil(regNs)
 		assert.NoError(err)
 		assert.NotContains(regNs.Name, "/")
@@ -114,19 +115,19 @@ func TestUpdateNs(t *testing.T) {
 		assert := a.New(tt)
 
 		ns.Name = "update-error"
-		regNs, err := s.UpdateNs(ns)
+		regNs, err := s.UpdateNs(context.Background(), ns)
 		assert.Nil(regNs)
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
 		ns.Name = "timeout-error"
-		regNs, err = s.UpdateNs(ns)
+		regNs, err = s.UpdateNs(context.Background(), ns)
 		assert.Nil(regNs)
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
 		ns.Name = "update-not-found"
-		regNs, err = s.UpdateNs(ns)
+		regNs, err = s.UpdateNs(context.Background(), ns)
 		assert.Nil(regNs)
 		assert.Equal(sr.ErrNotFound, err)
 	}
@@ -136,7 +137,7 @@ func TestUpdateNs(t *testing.T) {
 		assert := a.New(tt)
 
 		ns.Name = nsName
-		regNs, err := s.UpdateNs(ns)
+		regNs, err := s.UpdateNs(context.Background(), ns)
 		assert.NotNil(regNs)
 		assert.NoError(err)
 		assert.NotContains(regNs.Name, "/")
@@ -154,15 +155,15 @@ func TestDeleteNs(t *testing.T) {
 	testErr := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		err := s.DeleteNs("delete-error")
+		err := s.DeleteNs(context.Background(), "delete-error")
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
-		err = s.DeleteNs("timeout-error")
+		err = s.DeleteNs(context.Background(), "timeout-error")
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
-		err = s.DeleteNs("delete-not-found")
+		err = s.DeleteNs(context.Background(), "delete-not-found")
 		assert.Equal(sr.ErrNotFound, err)
 	}
 
@@ -170,7 +171,7 @@ func TestDeleteNs(t *testing.T) {
 	testOk := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		err := s.DeleteNs(nsName)
+		err := s.DeleteNs(context.Background(), nsName)
 		assert.NoError(err)
 	}
 
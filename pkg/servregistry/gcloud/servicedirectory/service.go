@@ -30,14 +30,14 @@ import (
 )
 
 // GetServ returns the service if exists.
-func (s *Handler) GetServ(nsName, servName string) (*sr.Service, error) {
+func (s *Handler) GetServ(ctx context.Context, nsName, servName string) (*sr.Service, error) {
 	// -- Init
 	if err := s.checkNames(&nsName, &servName, nil); err != nil {
 		return nil, err
 	}
 
 	servPath := s.getResourcePath(servDirPath{namespace: nsName, service: servName})
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	sdServ, err := s.Client.GetService(ctx, &sdpb.GetServiceRequest{Name: servPath})
@@ -57,14 +57,24 @@ func (s *Handler) GetServ(nsName, servName string) (*sr.Service, error) {
 	return nil, castStatusToErr(err)
 }
 
+// GetServID returns the full resource name of the service, e.g.
+// projects/my-project/locations/us-east1/namespaces/ns/services/serv.
+func (s *Handler) GetServID(ctx context.Context, nsName, servName string) (string, error) {
+	if err := s.checkNames(&nsName, &servName, nil); err != nil {
+		return "", err
+	}
+
+	return s.getResourcePath(servDirPath{namespace: nsName, service: servName}), nil
+}
+
 // ListServ returns a list of services inside the provided namespace.
-func (s *Handler) ListServ(nsName string) (servList []*sr.Service, err error) {
+func (s *Handler) ListServ(ctx context.Context, nsName string) (servList []*sr.Service, err error) {
 	// -- Init
 	if err := s.checkNames(&nsName, nil, nil); err != nil {
 		return nil, err
 	}
 	l := s.Log.WithName("ListServ").WithValues("ns-name", nsName)
-	ctx, canc := context.WithTimeout(s.Context, time.Minute)
+	ctx, canc := context.WithTimeout(ctx, time.Minute)
 	defer canc()
 
 	req := &sdpb.ListServicesRequest{
@@ -111,7 +121,7 @@ func (s *Handler) ListServ(nsName string) (servList []*sr.Service, err error) {
 }
 
 // CreateServ creates the service.
-func (s *Handler) CreateServ(serv *sr.Service) (*sr.Service, error) {
+func (s *Handler) CreateServ(ctx context.Context, serv *sr.Service) (*sr.Service, error) {
 	// -- Init
 	if serv == nil {
 		return nil, sr.ErrServNotProvided
@@ -120,7 +130,7 @@ func (s *Handler) CreateServ(serv *sr.Service) (*sr.Service, error) {
 		return nil, err
 	}
 
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	servToCreate := &sdpb.Service{
@@ -148,7 +158,7 @@ func (s *Handler) CreateServ(serv *sr.Service) (*sr.Service, error) {
 }
 
 // UpdateServ updates the service.
-func (s *Handler) UpdateServ(serv *sr.Service) (*sr.Service, error) {
+func (s *Handler) UpdateServ(ctx context.Context, serv *sr.Service) (*sr.Service, error) {
 	// -- Init
 	if serv == nil {
 		return nil, sr.ErrServNotProvided
@@ -157,7 +167,7 @@ func (s *Handler) UpdateServ(serv *sr.Service) (*sr.Service, error) {
 		return nil, err
 	}
 
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	servToUpd := &sdpb.Service{
@@ -181,13 +191,13 @@ func (s *Handler) UpdateServ(serv *sr.Service) (*sr.Service, error) {
 }
 
 // DeleteServ deletes the service.
-func (s *Handler) DeleteServ(nsName, servName string) error {
+func (s *Handler) DeleteServ(ctx context.Context, nsName, servName string) error {
 	// -- Init
 	if err := s.checkNames(&nsName, &servName, nil); err != nil {
 		return err
 	}
 
-	ctx, canc := context.WithTimeout(s.Context, defTimeout)
+	ctx, canc := context.WithTimeout(ctx, defTimeout)
 	defer canc()
 
 	req := &sdpb.DeleteServiceRequest{
@@ -20,21 +20,41 @@ package servicedirectory
 
 import (
 	"context"
-	"crypto/sha256"
-	"fmt"
+	"regexp"
 	"time"
 
 	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
 	"github.com/go-logr/logr"
-	corev1 "k8s.io/api/core/v1"
 )
 
 const (
 	defTimeout time.Duration = 30 * time.Second
+
+	// Limits of labels, used as metadata of namespaces.
+	maxLabels           int = 64
+	maxLabelKeyLength   int = 63
+	maxLabelValueLength int = 63
+
+	// Limits of annotations, used as metadata of services and endpoints.
+	maxServAnnotationsSize int = 2000
+	maxEndpAnnotationsSize int = 512
+
+	// maxEndpNameLength is the maximum length of the ID of endpoints.
+	maxEndpNameLength int = 63
+)
+
+var (
+	labelKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+	// annotationKeyPattern is the same as Kubernetes annotations: an
+	// optional DNS subdomain prefix followed by a name.
+	annotationKeyPattern = regexp.MustCompile(`^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?[A-Za-z0-9]([-_.A-Za-z0-9]{0,61}[A-Za-z0-9])?$`)
+	// endpNamePattern is the pattern of the ID of endpoints, i.e. a DNS
+	// label that starts with a letter.
+	endpNamePattern = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
 )
 
 // Handler is a wrapper for Service Directory that exposes its methods in a
-// sort of "universal" way through the ServiceRegistry interface.
+// sort of "universal" way through the ServiceRegistryV2 interface.
 type Handler struct {
 	// ProjectID where ServiceDirectory is enabled.
 	ProjectID string
@@ -44,74 +64,40 @@ type Handler struct {
 	// Log to use.
 	Log logr.Logger
 	// Context to use for requests.
-	// TODO: remove this in favor of explicit context for each call?
+	//
+	// Deprecated: it is ignored, as the context passed to each method is
+	// used instead.
 	Context context.Context
 	// Client to wrap around.
 	Client regClient
 }
 
-func (s *Handler) ExtractData(ns *corev1.Namespace, serv *corev1.Service) (namespaceData *sr.Namespace, serviceData *sr.Service, endpointsData []*sr.Endpoint, err error) {
-	if ns == nil {
-		err = sr.ErrNsNotProvided
-		return
-	}
-	if serv == nil {
-		err = sr.ErrServNotProvided
-		return
-	}
-
-	// Parse the namespace
-	namespaceData = &sr.Namespace{
-		Name:     ns.Name,
-		Metadata: ns.Annotations,
-	}
-	if namespaceData.Metadata == nil {
-		namespaceData.Metadata = map[string]string{}
-	}
-
-	// Parse the service
-	// NOTE: we put metadata on the service in service directory,
-	// not on the endpoints
-	serviceData = &sr.Service{
-		Name:     serv.Name,
-		NsName:   ns.Name,
-		Metadata: serv.Annotations,
-	}
-	if serviceData.Metadata == nil {
-		serviceData.Metadata = map[string]string{}
-	}
-
-	// Get the endpoints from the service
-	// First, build the ips
-	ips := []string{}
-	ips = append(ips, serv.Spec.ExternalIPs...)
-
-	// Get data from load balancers
-	for _, ing := range serv.Status.LoadBalancer.Ingress {
-		ips = append(ips, ing.IP)
+// Capabilities returns what Service Directory supports. Metadata of
+// namespaces are stored as labels and metadata of services and endpoints
+// as annotations, which have different limits.
+func (s *Handler) Capabilities() sr.Capabilities {
+	return sr.Capabilities{
+		NsMetadata:   true,
+		EndpMetadata: true,
+		NsMetadataLimits: sr.MetadataLimits{
+			MaxKeys:        maxLabels,
+			MaxKeyLength:   maxLabelKeyLength,
+			MaxValueLength: maxLabelValueLength,
+			KeyPattern:     labelKeyPattern,
+		},
+		ServMetadataLimits: sr.MetadataLimits{
+			MaxSize:    maxServAnnotationsSize,
+			KeyPattern: annotationKeyPattern,
+		},
+		EndpMetadataLimits: sr.MetadataLimits{
+			MaxSize:    maxEndpAnnotationsSize,
+			KeyPattern: annotationKeyPattern,
+		},
+		EndpNameLimits: sr.NameLimits{
+			MaxLength: maxEndpNameLength,
+			Pattern:   endpNamePattern,
+		},
+		IPv6:                true,
+		TransactionalWrites: true,
 	}
-
-	for _, port := range serv.Spec.Ports {
-		for _, ip := range ips {
-
-			// Create an hashed name for this
-			toBeHashed := fmt.Sprintf("%s-%d", ip, port.Port)
-			h := sha256.New()
-			h.Write([]byte(toBeHashed))
-			hash := fmt.Sprintf("%x", h.Sum(nil))
-
-			// Only take the first 10 characters of the hashed name
-			name := fmt.Sprintf("%s-%s", serv.Name, hash[:10])
-			endpointsData = append(endpointsData, &sr.Endpoint{
-				Name:     name,
-				NsName:   namespaceData.Name,
-				ServName: serviceData.Name,
-				Address:  ip,
-				Port:     port.Port,
-				Metadata: map[string]string{},
-			})
-		}
-	}
-
-	return
 }
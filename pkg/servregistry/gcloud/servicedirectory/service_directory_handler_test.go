@@ -22,89 +22,27 @@ import (
 	"strings"
 	"testing"
 
-	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
 	a "github.com/stretchr/testify/assert"
-	corev1 "k8s.io/api/core/v1"
-	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func TestExtractData(t *testing.T) {
-	nsName, servName := "ns", "serv"
-	s := &Handler{}
-	ips := []string{"10.10.10.10", "11.11.11.11"}
-	ports := []int32{3333, 4444}
-	nsToTest := &corev1.Namespace{
-		ObjectMeta: v1.ObjectMeta{
-			Name: nsName,
-			Annotations: map[string]string{
-				"key": "val",
-			},
-		},
-	}
-	servToTest := &corev1.Service{
-		ObjectMeta: v1.ObjectMeta{
-			Name:      servName,
-			Namespace: nsName,
-			Annotations: map[string]string{
-				"key": "val",
-			},
-		},
-		Spec: corev1.ServiceSpec{
-			ExternalIPs: []string{ips[0], ips[1]},
-			Ports: []corev1.ServicePort{
-				{
-					Port: ports[0],
-					Name: "3333",
-				},
-				{
-					Port: ports[1],
-					Name: "4444",
-				},
-			},
-		},
+func TestAnnotationKeyPattern(t *testing.T) {
+	cases := []struct {
+		key    string
+		expRes bool
+	}{
+		{key: "key", expRes: true},
+		{key: "example.com/name", expRes: true},
+		{key: "example.com/Name_with.dots-1", expRes: true},
+		{key: "owner", expRes: true},
+		{key: ""},
+		{key: "-key"},
+		{key: "Example.com/name"},
+		{key: "example.com/name/other"},
+		{key: "example.com/" + strings.Repeat("a", 64)},
 	}
 
 	assert := a.New(t)
-
-	ns, serv, endp, err := s.ExtractData(nsToTest, nil)
-	assert.Nil(ns)
-	assert.Nil(serv)
-	assert.Nil(endp)
-	assert.Equal(sr.ErrServNotProvided, err)
-
-	ns, serv, endp, err = s.ExtractData(nil, servToTest)
-	assert.Nil(ns)
-	assert.Nil(serv)
-	assert.Nil(endp)
-	assert.Equal(sr.ErrNsNotProvided, err)
-
-	ns, serv, endp, err = s.ExtractData(nsToTest, servToTest)
-	assert.NotNil(ns)
-	assert.NotNil(serv)
-	assert.NotNil(endp)
-	assert.NoError(err)
-	assert.Equal(&sr.Namespace{
-		Name:     nsName,
-		Metadata: nsToTest.Annotations,
-	}, ns)
-	assert.Equal(&sr.Service{
-		Name:     servName,
-		NsName:   nsName,
-		Metadata: servToTest.Annotations,
-	}, serv)
-	assert.Len(endp, 4)
-	for _, e := range endp {
-		assert.Contains(ips, e.Address)
-		assert.Contains(ports, e.Port)
-		assert.Empty(e.Metadata)
-		assert.Equal(nsName, e.NsName)
-		assert.Equal(servName, e.ServName)
-
-		if !strings.HasPrefix(e.Name, servName+"-") {
-			assert.Fail("endpoint name is incorrect. Should start with", servName, "but is", e.Name)
-		}
-
-		suffix := e.Name[len(servName)+1:]
-		assert.Len(suffix, 10)
+	for _, currCase := range cases {
+		assert.Equal(currCase.expRes, annotationKeyPattern.MatchString(currCase.key), currCase.key)
 	}
 }
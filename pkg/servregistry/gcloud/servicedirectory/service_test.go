@@ -19,6 +19,7 @@
 package servicedirectory
 
 import (
+	"context"
 	"testing"
 
 	sr "github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry"
@@ -33,17 +34,17 @@ func TestGetServ(t *testing.T) {
 	testErr := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		regServ, err := s.GetServ(nsName, "get-error")
+		regServ, err := s.GetServ(context.Background(), nsName, "get-error")
 		assert.Nil(regServ)
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
-		regServ, err = s.GetServ(nsName, "timeout-error")
+		regServ, err = s.GetServ(context.Background(), nsName, "timeout-error")
 		assert.Nil(regServ)
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
-		regServ, err = s.GetServ(nsName, "get-not-found")
+		regServ, err = s.GetServ(context.Background(), nsName, "get-not-found")
 		assert.Nil(regServ)
 		assert.Equal(sr.ErrNotFound, err)
 	}
@@ -52,7 +53,7 @@ func TestGetServ(t *testing.T) {
 	testOk := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		regServ, err := s.GetServ(nsName, servName)
+		regServ, err := s.GetServ(context.Background(), nsName, servName)
 		assert.NotNil(regServ)
 		assert.NoError(err)
 		assert.NotContains(regServ.Name, "/")
@@ -72,24 +73,24 @@ func TestCreateServ(t *testing.T) {
 	testErr := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		regServ, err := s.CreateServ(nil)
+		regServ, err := s.CreateServ(context.Background(), nil)
 		assert.Nil(regServ)
 		assert.Equal(sr.ErrServNotProvided, err)
 
 		req.Name = "create-error"
-		regServ, err = s.CreateServ(req)
+		regServ, err = s.CreateServ(context.Background(), req)
 		assert.Nil(regServ)
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
 		req.Name = "timeout-error"
-		regServ, err = s.CreateServ(req)
+		regServ, err = s.CreateServ(context.Background(), req)
 		assert.Nil(regServ)
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
 		req.Name = "create-exists"
-		regServ, err = s.CreateServ(req)
+		regServ, err = s.CreateServ(context.Background(), req)
 		assert.Nil(regServ)
 		assert.Equal(sr.ErrAlreadyExists, err)
 	}
@@ -99,7 +100,7 @@ func TestCreateServ(t *testing.T) {
 		assert := a.New(tt)
 
 		req.Name = servName
-		regServ, err := s.CreateServ(req)
+		regServ, err := s.CreateServ(context.Background(), req)
 		assert.NotNil(regServ)
 		assert.NoError(err)
 		assert.NotContains(regServ.Name, "/")
@@ -119,24 +120,24 @@ func TestUpdateServ(t *testing.T) {
 	testErr := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		regServ, err := s.UpdateServ(nil)
+		regServ, err := s.UpdateServ(context.Background(), nil)
 		assert.Nil(regServ)
 		assert.Equal(sr.ErrServNotProvided, err)
 
 		req.Name = "update-error"
-		regServ, err = s.UpdateServ(req)
+		regServ, err = s.UpdateServ(context.Background(), req)
 		assert.Nil(regServ)
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
 		req.Name = "timeout-error"
-		regServ, err = s.UpdateServ(req)
+		regServ, err = s.UpdateServ(context.Background(), req)
 		assert.Nil(regServ)
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
 		req.Name = "update-not-found"
-		regServ, err = s.UpdateServ(req)
+		regServ, err = s.UpdateServ(context.Background(), req)
 		assert.Nil(regServ)
 		assert.Equal(sr.ErrNotFound, err)
 	}
@@ -146,7 +147,7 @@ func TestUpdateServ(t *testing.T) {
 		assert := a.New(tt)
 
 		req.Name = servName
-		regServ, err := s.UpdateServ(req)
+		regServ, err := s.UpdateServ(context.Background(), req)
 		assert.NotNil(regServ)
 		assert.NoError(err)
 		assert.NotContains(regServ.Name, "/")
@@ -165,15 +166,15 @@ func TestDeleteServ(t *testing.T) {
 	testErr := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		err := s.DeleteServ(nsName, "delete-error")
+		err := s.DeleteServ(context.Background(), nsName, "delete-error")
 		assert.Error(err)
 		assert.NotEqual(sr.ErrTimeOutExpired, err)
 		assert.NotEqual(sr.ErrNotFound, err)
 
-		err = s.DeleteServ(nsName, "timeout-error")
+		err = s.DeleteServ(context.Background(), nsName, "timeout-error")
 		assert.Equal(sr.ErrTimeOutExpired, err)
 
-		err = s.DeleteServ(nsName, "delete-not-found")
+		err = s.DeleteServ(context.Background(), nsName, "delete-not-found")
 		assert.Equal(sr.ErrNotFound, err)
 	}
 
@@ -181,10 +182,23 @@ func TestDeleteServ(t *testing.T) {
 	testOk := func(tt *testing.T) {
 		assert := a.New(tt)
 
-		err := s.DeleteServ(nsName, servName)
+		err := s.DeleteServ(context.Background(), nsName, servName)
 		assert.NoError(err)
 	}
 
 	testErr(t)
 	testOk(t)
 }
+
+func TestGetServID(t *testing.T) {
+	s := getFakeHandler()
+	assert := a.New(t)
+
+	id, err := s.GetServID(context.Background(), "ns", "")
+	assert.Empty(id)
+	assert.Equal(sr.ErrServNameNotProvided, err)
+
+	id, err = s.GetServID(context.Background(), "ns", "serv")
+	assert.NoError(err)
+	assert.Equal("projects/project/locations/us/namespaces/ns/services/serv", id)
+}
@@ -0,0 +1,120 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package servregistry
+
+import "sync"
+
+// This file contains the locks used by the Broker to make sure that the
+// same object is never changed by two operations at the same time, while
+// operations on different objects can run in parallel.
+
+// keyedLock is a set of read/write locks identified by a key. Locks are
+// created when needed and removed when nobody is using them anymore.
+//
+// The zero value is ready to be used.
+type keyedLock struct {
+	lock  sync.Mutex
+	locks map[string]*refLock
+}
+
+type refLock struct {
+	sync.RWMutex
+	refs int
+}
+
+// Lock locks the key for writing and returns the function to unlock it.
+func (k *keyedLock) Lock(key string) (unlock func()) {
+	l := k.acquire(key)
+	l.Lock()
+
+	return func() {
+		l.Unlock()
+		k.release(key)
+	}
+}
+
+// RLock locks the key for reading and returns the function to unlock it.
+func (k *keyedLock) RLock(key string) (unlock func()) {
+	l := k.acquire(key)
+	l.RLock()
+
+	return func() {
+		l.RUnlock()
+		k.release(key)
+	}
+}
+
+func (k *keyedLock) acquire(key string) *refLock {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if k.locks == nil {
+		k.locks = map[string]*refLock{}
+	}
+
+	l, exists := k.locks[key]
+	if !exists {
+		l = &refLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+
+	return l
+}
+
+func (k *keyedLock) release(key string) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	l := k.locks[key]
+	l.refs--
+	if l.refs == 0 {
+		delete(k.locks, key)
+	}
+}
+
+// lockNs locks the namespace for operations that change it or that may
+// remove its services, e.g. its deletion. No operation on the namespace or
+// its services can run until it is unlocked.
+func (b *Broker) lockNs(nsName string) (unlock func()) {
+	return b.nsLocks.Lock(nsName)
+}
+
+// rLockNs locks the namespace for operations that only read it. Other
+// operations that read it or that change its services can run at the same
+// time.
+func (b *Broker) rLockNs(nsName string) (unlock func()) {
+	return b.nsLocks.RLock(nsName)
+}
+
+// lockServ locks the service for operations that change it or its
+// endpoints. Its namespace is locked for reading, so that it cannot be
+// removed in the meantime, while services of the same namespace can still
+// be changed at the same time.
+//
+// The namespace is always locked before the service, to prevent deadlocks.
+func (b *Broker) lockServ(nsName, servName string) (unlock func()) {
+	unlockNs := b.rLockNs(nsName)
+	unlockServ := b.servLocks.Lock(nsName + "/" + servName)
+
+	return func() {
+		unlockServ()
+		unlockNs()
+	}
+}
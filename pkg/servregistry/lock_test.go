@@ -0,0 +1,111 @@
+// Copyright © 2022 Cisco
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// All rights reserved.
+
+package servregistry
+
+import (
+	"testing"
+	"time"
+
+	a "github.com/stretchr/testify/assert"
+)
+
+// locked returns whether lock could not be acquired within a short time.
+func locked(lock func() func()) bool {
+	acquired := make(chan func())
+	go func() {
+		acquired <- lock()
+	}()
+
+	select {
+	case unlock := <-acquired:
+		unlock()
+		return false
+	case <-time.After(50 * time.Millisecond):
+		// Wait for it anyway, so that it does not stay locked.
+		go func() {
+			(<-acquired)()
+		}()
+		return true
+	}
+}
+
+func TestBrokerLocks(t *testing.T) {
+	cases := []struct {
+		id     string
+		hold   func(b *Broker) func()
+		try    func(b *Broker) func()
+		expRes bool
+	}{
+		{
+			id:     "same-service",
+			hold:   func(b *Broker) func() { return b.lockServ("ns", "serv") },
+			try:    func(b *Broker) func() { return b.lockServ("ns", "serv") },
+			expRes: true,
+		},
+		{
+			id:   "same-namespace-other-service",
+			hold: func(b *Broker) func() { return b.lockServ("ns", "serv") },
+			try:  func(b *Broker) func() { return b.lockServ("ns", "other") },
+		},
+		{
+			id:   "other-namespace-same-service",
+			hold: func(b *Broker) func() { return b.lockServ("ns", "serv") },
+			try:  func(b *Broker) func() { return b.lockServ("other", "serv") },
+		},
+		{
+			id:     "namespace-while-service",
+			hold:   func(b *Broker) func() { return b.lockServ("ns", "serv") },
+			try:    func(b *Broker) func() { return b.lockNs("ns") },
+			expRes: true,
+		},
+		{
+			id:     "service-while-namespace",
+			hold:   func(b *Broker) func() { return b.lockNs("ns") },
+			try:    func(b *Broker) func() { return b.lockServ("ns", "serv") },
+			expRes: true,
+		},
+		{
+			id:   "read-namespace-while-service",
+			hold: func(b *Broker) func() { return b.lockServ("ns", "serv") },
+			try:  func(b *Broker) func() { return b.rLockNs("ns") },
+		},
+		{
+			id:   "other-namespace",
+			hold: func(b *Broker) func() { return b.lockNs("ns") },
+			try:  func(b *Broker) func() { return b.lockNs("other") },
+		},
+	}
+
+	assert := a.New(t)
+	for _, currCase := range cases {
+		b := &Broker{}
+		unlock := currCase.hold(b)
+		res := locked(func() func() { return currCase.try(b) })
+		unlock()
+
+		assert.Equal(currCase.expRes, res, "case %s failed", currCase.id)
+	}
+
+	// Locks are removed when they are not used anymore
+	b := &Broker{}
+	b.lockServ("ns", "serv")()
+	b.lockNs("ns")()
+	assert.Empty(b.nsLocks.locks)
+	assert.Empty(b.servLocks.locks)
+}
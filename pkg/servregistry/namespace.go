@@ -18,6 +18,8 @@
 
 package servregistry
 
+import "context"
+
 // This file contains functions that perform operations on namespaces,
 // such as create/update/delete.
 // These functions belong to a ServiceRegistryBroker, defined in
@@ -28,36 +30,48 @@ package servregistry
 //
 // For example: create a namespace in service registry or update it
 // properly.
-func (b *Broker) ManageNs(nsData *Namespace) (regNs *Namespace, err error) {
+func (b *Broker) ManageNs(ctx context.Context, nsData *Namespace) (regNs *Namespace, err error) {
+	regNs, _, err = b.manageNs(ctx, nsData)
+	return
+}
+
+// manageNs does what ManageNs does and also returns the action performed on
+// the namespace, or an empty action if nothing has been written.
+func (b *Broker) manageNs(ctx context.Context, nsData *Namespace) (regNs *Namespace, action DriftAction, err error) {
 	// nsData: data of the namespace in Kubernetes (latest state)
 	// regNs: data of the namespace currently in the service registry
 
 	if b.Reg == nil {
-		return nil, ErrServRegNotProvided
+		return nil, "", ErrServRegNotProvided
 	}
 
 	// -- Validate
 	if nsData == nil {
-		return nil, ErrNsNotProvided
+		return nil, "", ErrNsNotProvided
 	}
 
 	if len(nsData.Name) == 0 {
-		return nil, ErrNsNameNotProvided
+		return nil, "", ErrNsNameNotProvided
 	}
 
 	// -- Init
-	b.lock.Lock()
-	defer b.lock.Unlock()
+	defer b.lockNs(nsData.Name)()
 	if nsData.Metadata == nil {
 		nsData.Metadata = map[string]string{}
 	}
 	nsData.Metadata[b.opMetaPair.Key] = b.opMetaPair.Value
+	b.adaptNs(nsData)
 	l := b.log.WithName("ManageNs").WithValues("ns-name", nsData.Name)
 
+	if err = b.validateNs(nsData); err != nil {
+		l.Error(err, "namespace is not supported by the service registry")
+		return nil, "", err
+	}
+
 	// -- Do stuff
 	l.V(1).Info("going to load namespace from service registry")
 
-	regNs, err = b.Reg.GetNs(nsData.Name)
+	regNs, err = b.Reg.GetNs(ctx, nsData.Name)
 	if err != nil {
 		if err != ErrNotFound {
 			l.Error(err, "error occurred while getting namespace from service registry")
@@ -67,14 +81,14 @@ func (b *Broker) ManageNs(nsData *Namespace) (regNs *Namespace, err error) {
 		// If you're here, it means that the namespace does not exist.
 		// Let's create it.
 		l.V(1).Info("namespace does not exist in service registry, going to create it")
-		regNs, err = b.Reg.CreateNs(nsData)
+		regNs, err = b.Reg.CreateNs(ctx, nsData)
 		if err != nil {
 			l.Error(err, "error occurred while creating namespace in service registry")
 			return
 		}
 
 		l.V(0).Info("namespace created correctly")
-		regNs = nsData
+		regNs, action = nsData, DriftCreated
 	}
 
 	if by, exists := regNs.Metadata[b.opMetaPair.Key]; by != b.opMetaPair.Value || !exists {
@@ -86,11 +100,12 @@ func (b *Broker) ManageNs(nsData *Namespace) (regNs *Namespace, err error) {
 
 	if !b.deepEqualMetadata(nsData.Metadata, regNs.Metadata) {
 		l.V(1).Info("namespace metadata need to be updated")
-		regNs, err = b.Reg.UpdateNs(nsData)
+		regNs, err = b.Reg.UpdateNs(ctx, nsData)
 		if err != nil {
 			l.Error(err, "error while trying to update namespace in service registry")
-			return nil, err
+			return nil, "", err
 		}
+		action = DriftUpdated
 	}
 
 	return
@@ -106,7 +121,7 @@ func (b *Broker) ManageNs(nsData *Namespace) (regNs *Namespace, err error) {
 // endpoints not owned by the cnwan operator!
 //
 // For example: it checks if the namespace is actually owned by us.
-func (b *Broker) RemoveNs(nsName string, forceNotEmpty bool) (err error) {
+func (b *Broker) RemoveNs(ctx context.Context, nsName string, forceNotEmpty bool) (err error) {
 	if b.Reg == nil {
 		return ErrServRegNotProvided
 	}
@@ -117,15 +132,14 @@ func (b *Broker) RemoveNs(nsName string, forceNotEmpty bool) (err error) {
 	}
 
 	// -- Init
-	b.lock.Lock()
-	defer b.lock.Unlock()
+	defer b.lockNs(nsName)()
 	l := b.log.WithName("RemoveNs").WithValues("ns-name", nsName)
 
 	// -- Do stuff
 	l.V(1).Info("going to remove namespace from service registry")
 
 	// Load the namespace first
-	regNs, err := b.Reg.GetNs(nsName)
+	regNs, err := b.Reg.GetNs(ctx, nsName)
 	if err != nil {
 		if err != ErrNotFound {
 			l.Error(err, "error occurred while removing namespace from service registry")
@@ -140,7 +154,7 @@ func (b *Broker) RemoveNs(nsName string, forceNotEmpty bool) (err error) {
 
 	// Is it empty?
 	l.V(1).Info("checking if namespace is empty before deleting")
-	listServ, err := b.Reg.ListServ(nsName)
+	listServ, err := b.Reg.ListServ(ctx, nsName)
 	if err != nil {
 		return
 	}
@@ -168,7 +182,7 @@ func (b *Broker) RemoveNs(nsName string, forceNotEmpty bool) (err error) {
 		// services singularly
 		l.V(0).Info("namespace contains services not owned by the operator and will not be removed from service registry")
 		for _, servName := range servs {
-			if delErr := b.Reg.DeleteServ(nsName, servName); delErr != nil {
+			if delErr := b.deleteServ(ctx, nsName, servName); delErr != nil {
 				l.WithValues("serv-name", servName).Error(delErr, "error while deleting service from service registry")
 			}
 		}
@@ -183,7 +197,7 @@ func (b *Broker) RemoveNs(nsName string, forceNotEmpty bool) (err error) {
 		return ErrNsNotOwnedByOp
 	}
 
-	err = b.Reg.DeleteNs(nsName)
+	err = b.deleteNs(ctx, nsName, servs)
 	if err != nil {
 		l.Error(err, "error while deleting namespace from service registry")
 	}
@@ -191,3 +205,18 @@ func (b *Broker) RemoveNs(nsName string, forceNotEmpty bool) (err error) {
 	l.V(0).Info("namespace deleted from service registry successfully")
 	return
 }
+
+// deleteNs deletes the namespace from the service registry. If the service
+// registry does not delete the contents of a namespace together with it,
+// the provided services are deleted first.
+func (b *Broker) deleteNs(ctx context.Context, nsName string, servNames []string) error {
+	if !b.caps.TransactionalWrites {
+		for _, servName := range servNames {
+			if err := b.deleteServ(ctx, nsName, servName); err != nil && err != ErrNotFound {
+				return err
+			}
+		}
+	}
+
+	return b.Reg.DeleteNs(ctx, nsName)
+}
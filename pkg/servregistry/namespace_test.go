@@ -19,6 +19,7 @@
 package servregistry
 
 import (
+	"context"
 	"testing"
 
 	a "github.com/stretchr/testify/assert"
@@ -27,11 +28,11 @@ import (
 func TestManageNs(t *testing.T) {
 	// prepare
 	var f *fakeServReg
-	b, _ := NewBroker(f, MetadataPair{})
+	b, _ := NewBroker(AdaptV1(f), MetadataPair{})
 
 	resetFake := func() {
 		f = newFakeStruct()
-		b.Reg = f
+		b.Reg = AdaptV1(f)
 	}
 
 	resetFake()
@@ -47,18 +48,18 @@ func TestManageNs(t *testing.T) {
 
 		// no service registry provided
 		b.Reg = nil
-		regNs, err = b.ManageNs(nil)
+		regNs, err = b.ManageNs(context.Background(), nil)
 		assert.Nil(regNs)
 		assert.Equal(ErrServRegNotProvided, err)
 
 		// namespace is nil
-		b.Reg = f
-		regNs, err = b.ManageNs(nil)
+		b.Reg = AdaptV1(f)
+		regNs, err = b.ManageNs(context.Background(), nil)
 		assert.Nil(regNs)
 		assert.Equal(ErrNsNotProvided, err)
 
 		// namespace has no name
-		regNs, err = b.ManageNs(invalidNsData)
+		regNs, err = b.ManageNs(context.Background(), invalidNsData)
 		assert.Nil(regNs)
 		assert.Equal(ErrNsNameNotProvided, err)
 
@@ -71,7 +72,7 @@ func TestManageNs(t *testing.T) {
 		defer resetFake()
 		assert := a.New(tt)
 
-		regNs, err := b.ManageNs(&Namespace{Name: "get-error"})
+		regNs, err := b.ManageNs(context.Background(), &Namespace{Name: "get-error"})
 		assert.Nil(regNs)
 		assert.Error(err)
 		assert.NotEqual(ErrNsNameNotProvided, err)
@@ -95,11 +96,11 @@ func TestManageNs(t *testing.T) {
 		f.nsList[one.Name] = one
 		f.nsList[two.Name] = two
 
-		regNs, err := b.ManageNs(oneChange)
+		regNs, err := b.ManageNs(context.Background(), oneChange)
 		assert.Equal(one, regNs)
 		assert.NoError(err)
 
-		regNs, err = b.ManageNs(twoChange)
+		regNs, err = b.ManageNs(context.Background(), twoChange)
 		assert.Equal(two, regNs)
 		assert.NoError(err)
 
@@ -117,7 +118,7 @@ func TestManageNs(t *testing.T) {
 		changeErr := &Namespace{Name: "update-error", Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val-1"}}
 		f.nsList[shouldErr.Name] = shouldErr
 
-		regNs, err := b.ManageNs(changeErr)
+		regNs, err := b.ManageNs(context.Background(), changeErr)
 		assert.Nil(regNs)
 		assert.Error(err)
 
@@ -126,7 +127,7 @@ func TestManageNs(t *testing.T) {
 		changeOk := &Namespace{Name: "update", Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val-1"}}
 		f.nsList[shouldOk.Name] = shouldOk
 
-		regNs, err = b.ManageNs(changeOk)
+		regNs, err = b.ManageNs(context.Background(), changeOk)
 		assert.Equal(changeOk, regNs)
 		assert.NoError(err)
 
@@ -141,13 +142,13 @@ func TestManageNs(t *testing.T) {
 		// should return nil because an error in creating
 		// (this also happens if someone else creates this)
 		create := &Namespace{Name: "create-error", Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val"}}
-		regNs, err := b.ManageNs(create)
+		regNs, err := b.ManageNs(context.Background(), create)
 		assert.Nil(regNs)
 		assert.Error(err)
 
 		// no error so it should return the created
 		create = &Namespace{Name: "create", Metadata: map[string]string{"key": "val"}}
-		regNs, err = b.ManageNs(create)
+		regNs, err = b.ManageNs(context.Background(), create)
 		assert.Equal(create.Name, regNs.Name)
 		assert.Equal(map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val"}, regNs.Metadata)
 		assert.NoError(err)
@@ -166,11 +167,11 @@ func TestRemoveNs(t *testing.T) {
 	// prepare
 	nsName := "ns"
 	var f *fakeServReg
-	b, _ := NewBroker(f, MetadataPair{})
+	b, _ := NewBroker(AdaptV1(f), MetadataPair{})
 
 	resetFake := func() {
 		f = newFakeStruct()
-		b.Reg = f
+		b.Reg = AdaptV1(f)
 	}
 
 	resetFake()
@@ -182,12 +183,12 @@ func TestRemoveNs(t *testing.T) {
 
 		// no service registry provided
 		b.Reg = nil
-		err := b.RemoveNs(nsName, false)
+		err := b.RemoveNs(context.Background(), nsName, false)
 		assert.Equal(ErrServRegNotProvided, err)
 
 		// namespace name not provided
-		b.Reg = f
-		err = b.RemoveNs("", false)
+		b.Reg = AdaptV1(f)
+		err = b.RemoveNs(context.Background(), "", false)
 		assert.Equal(ErrNsNameNotProvided, err)
 		assert.Empty(f.deletedNs)
 	}
@@ -197,7 +198,7 @@ func TestRemoveNs(t *testing.T) {
 		defer resetFake()
 		assert := a.New(tt)
 
-		err := b.RemoveNs("get-error", false)
+		err := b.RemoveNs(context.Background(), "get-error", false)
 		assert.Error(err)
 		assert.NotEqual(ErrNsNameNotProvided, err)
 		assert.NotEqual(ErrNsNotProvided, err)
@@ -216,10 +217,10 @@ func TestRemoveNs(t *testing.T) {
 		f.nsList[one.Name] = one
 		f.nsList[two.Name] = two
 
-		err := b.RemoveNs(one.Name, false)
+		err := b.RemoveNs(context.Background(), one.Name, false)
 		assert.Equal(ErrNsNotOwnedByOp, err)
 
-		err = b.RemoveNs(two.Name, false)
+		err = b.RemoveNs(context.Background(), two.Name, false)
 		assert.Equal(ErrNsNotOwnedByOp, err)
 		assert.Empty(f.deletedNs)
 	}
@@ -231,20 +232,20 @@ func TestRemoveNs(t *testing.T) {
 
 		// when it doesn't exist, we just log but return no error
 		// because it doesn't change anything for us
-		err := b.RemoveNs("doesnt-exist", false)
+		err := b.RemoveNs(context.Background(), "doesnt-exist", false)
 		assert.NoError(err)
 
 		// unknown error
 		toDel := &Namespace{Name: "delete-error", Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val"}}
 		f.nsList[toDel.Name] = toDel
-		err = b.RemoveNs("delete-error", false)
+		err = b.RemoveNs(context.Background(), "delete-error", false)
 		assert.NotEqual(ErrServRegNotProvided, err)
 		assert.NotEqual(ErrNsNameNotProvided, err)
 
 		// successful
 		present := &Namespace{Name: "owned", Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val"}}
 		f.nsList[present.Name] = present
-		err = b.RemoveNs("owned", false)
+		err = b.RemoveNs(context.Background(), "owned", false)
 		assert.NoError(err)
 		assert.Len(f.deletedNs, 1)
 	}
@@ -274,7 +275,7 @@ func TestRemoveNs(t *testing.T) {
 
 		// error in listing
 		f.servList["list-error"] = &Service{}
-		err := b.RemoveNs(nsDel.Name, false)
+		err := b.RemoveNs(context.Background(), nsDel.Name, false)
 		assert.Error(err)
 		delete(f.servList, "list-error")
 
@@ -282,12 +283,12 @@ func TestRemoveNs(t *testing.T) {
 		f.servList["one"] = oneOwned
 		f.servList["two"] = twoOwned
 		f.servList["three"] = threeNotOwned
-		err = b.RemoveNs(nsDel.Name, false)
+		err = b.RemoveNs(context.Background(), nsDel.Name, false)
 		assert.Empty(f.deletedServ)
 		assert.Empty(f.deletedNs)
 		assert.Equal(ErrNsNotEmpty, err)
 
-		err = b.RemoveNs(nsDel.Name, true)
+		err = b.RemoveNs(context.Background(), nsDel.Name, true)
 		assert.Len(f.deletedServ, 2)
 		assert.Empty(f.deletedNs)
 		assert.Equal(ErrNsNotOwnedServs, err)
@@ -299,7 +300,7 @@ func TestRemoveNs(t *testing.T) {
 		f.nsList[shouldErr.Name] = shouldErr
 		f.deletedServ = []string{}
 		f.deletedNs = []string{}
-		err = b.RemoveNs(shouldErr.Name, true)
+		err = b.RemoveNs(context.Background(), shouldErr.Name, true)
 		assert.Error(err)
 	}
 
@@ -18,6 +18,8 @@
 
 package servregistry
 
+import "context"
+
 // This file contains functions that perform operations on services,
 // such as create/update/delete.
 // These functions belong to a ServiceRegistryBroker, defined in
@@ -28,7 +30,14 @@ package servregistry
 //
 // For example: create a service in service registry or update it
 // properly.
-func (b *Broker) ManageServ(servData *Service) (regServ *Service, err error) {
+func (b *Broker) ManageServ(ctx context.Context, servData *Service) (regServ *Service, err error) {
+	regServ, _, err = b.manageServ(ctx, servData)
+	return
+}
+
+// manageServ does what ManageServ does and also returns the action performed
+// on the service, or an empty action if nothing has been written.
+func (b *Broker) manageServ(ctx context.Context, servData *Service) (regServ *Service, action DriftAction, err error) {
 	// servData: data of the service in Kubernetes (latest update)
 	// regServ: data of the service currently in the service registry
 
@@ -38,25 +47,24 @@ func (b *Broker) ManageServ(servData *Service) (regServ *Service, err error) {
 	// since it is easier to understand and make it better later.
 
 	if b.Reg == nil {
-		return nil, ErrServRegNotProvided
+		return nil, "", ErrServRegNotProvided
 	}
 
 	// -- Validate
 	if servData == nil {
-		return nil, ErrServNotProvided
+		return nil, "", ErrServNotProvided
 	}
 
 	if len(servData.Name) == 0 {
-		return nil, ErrServNameNotProvided
+		return nil, "", ErrServNameNotProvided
 	}
 
 	if len(servData.NsName) == 0 {
-		return nil, ErrNsNameNotProvided
+		return nil, "", ErrNsNameNotProvided
 	}
 
 	// -- Init
-	b.lock.Lock()
-	defer b.lock.Unlock()
+	defer b.lockServ(servData.NsName, servData.Name)()
 	if servData.Metadata == nil {
 		servData.Metadata = map[string]string{}
 	}
@@ -67,10 +75,15 @@ func (b *Broker) ManageServ(servData *Service) (regServ *Service, err error) {
 
 	l := b.log.WithName("ManageServ").WithValues("serv-name", servData.Name)
 
+	if err = b.validateServ(servData); err != nil {
+		l.Error(err, "service is not supported by the service registry")
+		return nil, "", err
+	}
+
 	// -- Do stuff
 	l.V(1).Info("going to load service from service registry")
 
-	regServ, err = b.Reg.GetServ(servData.NsName, servData.Name)
+	regServ, err = b.Reg.GetServ(ctx, servData.NsName, servData.Name)
 	if err != nil {
 		if err != ErrNotFound {
 			l.Error(err, "error occurred while getting service from service registry")
@@ -80,14 +93,14 @@ func (b *Broker) ManageServ(servData *Service) (regServ *Service, err error) {
 		// If you're here, it means that the service does not exist.
 		// Let's create it.
 		l.V(1).Info("service does not exist in service registry, going to create it")
-		regServ, err = b.Reg.CreateServ(servData)
+		regServ, err = b.Reg.CreateServ(ctx, servData)
 		if err != nil {
 			l.Error(err, "error occurred while creating service in service registry")
 			return
 		}
 
 		l.V(0).Info("service created correctly")
-		regServ = servData
+		regServ, action = servData, DriftCreated
 	}
 
 	if by, exists := regServ.Metadata[b.opMetaPair.Key]; by != b.opMetaPair.Value || !exists {
@@ -99,11 +112,12 @@ func (b *Broker) ManageServ(servData *Service) (regServ *Service, err error) {
 
 	if !b.deepEqualMetadata(servData.Metadata, regServ.Metadata) {
 		l.V(1).Info("service metadata need to be updated")
-		regServ, err = b.Reg.UpdateServ(servData)
+		regServ, err = b.Reg.UpdateServ(ctx, servData)
 		if err != nil {
 			l.Error(err, "error while trying to update service in service registry")
-			return nil, err
+			return nil, "", err
 		}
+		action = DriftUpdated
 	}
 
 	return
@@ -117,30 +131,44 @@ func (b *Broker) ManageServ(servData *Service) (regServ *Service, err error) {
 // will not be deleted.
 //
 // For example: it checks if the service is actually owned by us.
-func (b *Broker) RemoveServ(nsName, servName string, forceNotEmpty bool) (err error) {
+func (b *Broker) RemoveServ(ctx context.Context, nsName, servName string, forceNotEmpty bool) (err error) {
+	_, err = b.removeServ(ctx, nsName, servName, forceNotEmpty)
+	return
+}
+
+// DeregisterServ removes the service and its endpoints from the service
+// registry, just like RemoveServ does with forceNotEmpty, but it also returns
+// whether the service has been deleted. This is false, with no error, if
+// the service was not in the service registry.
+func (b *Broker) DeregisterServ(ctx context.Context, nsName, servName string) (removed bool, err error) {
+	return b.removeServ(ctx, nsName, servName, true)
+}
+
+// removeServ does what RemoveServ does and also returns whether the service
+// has been deleted.
+func (b *Broker) removeServ(ctx context.Context, nsName, servName string, forceNotEmpty bool) (removed bool, err error) {
 	if b.Reg == nil {
-		return ErrServRegNotProvided
+		return false, ErrServRegNotProvided
 	}
 
 	// -- Validate
 	if len(nsName) == 0 {
-		return ErrNsNameNotProvided
+		return false, ErrNsNameNotProvided
 	}
 
 	if len(servName) == 0 {
-		return ErrServNameNotProvided
+		return false, ErrServNameNotProvided
 	}
 
 	// -- Init
-	b.lock.Lock()
-	defer b.lock.Unlock()
+	defer b.lockServ(nsName, servName)()
 	l := b.log.WithName("RemoveServ").WithValues("serv-name", servName)
 
 	// -- Do stuff
 	l.V(1).Info("going to remove service from service registry")
 
 	// Load the service first
-	regServ, err := b.Reg.GetServ(nsName, servName)
+	regServ, err := b.Reg.GetServ(ctx, nsName, servName)
 	if err != nil {
 		if err != ErrNotFound {
 			l.Error(err, "error occurred while removing service from service registry")
@@ -150,19 +178,19 @@ func (b *Broker) RemoveServ(nsName, servName string, forceNotEmpty bool) (err er
 		// If you're here, it means that the servce does not exist.
 		// This doesn't change anything for us.
 		l.V(0).Info("servce does not exist in service registry, going to stop here")
-		return nil
+		return false, nil
 	}
 
 	// Is it empty?
 	l.V(1).Info("checking if service is empty before deleting")
-	listEndp, err := b.Reg.ListEndp(nsName, servName)
+	listEndp, err := b.Reg.ListEndp(ctx, nsName, servName)
 	if err != nil {
 		return
 	}
 
 	if len(listEndp) > 0 && !forceNotEmpty {
 		l.V(0).Info("service is not empty and will not be deleted from service registry")
-		return ErrServNotEmpty
+		return false, ErrServNotEmpty
 	}
 
 	l.V(0).Info("service is not empty: checking if it can be removed")
@@ -182,26 +210,47 @@ func (b *Broker) RemoveServ(nsName, servName string, forceNotEmpty bool) (err er
 		// endpoints singularly
 		l.V(0).Info("service contains endpoints not owned by the operator and will not be removed from service registry")
 		for _, endpName := range endps {
-			if delErr := b.Reg.DeleteEndp(nsName, servName, endpName); delErr != nil {
+			if delErr := b.Reg.DeleteEndp(ctx, nsName, servName, endpName); delErr != nil {
 				l.WithValues("endp-name", endpName).Error(delErr, "error while deleting endpoint from service registry")
 			}
 		}
 
-		return ErrServNotOwnedEndps
+		return false, ErrServNotOwnedEndps
 	}
 
 	if by, exists := regServ.Metadata[b.opMetaPair.Key]; by != b.opMetaPair.Value || !exists {
 		// If the service is not owned (as in, managed by) us, then it's
 		// better not to touch it.
 		l.V(0).Info("WARNING: service is not owned by the operator and will not be removed from service registry")
-		return ErrServNotOwnedByOp
+		return false, ErrServNotOwnedByOp
 	}
 
-	err = b.Reg.DeleteServ(nsName, servName)
+	err = b.deleteServ(ctx, nsName, servName)
 	if err != nil {
 		l.Error(err, "error while deleting service from service registry")
+		return
 	}
 
 	l.V(0).Info("service deleted from service registry successfully")
-	return
+	return true, nil
+}
+
+// deleteServ deletes the service from the service registry. If the service
+// registry does not delete the contents of a service together with it, its
+// endpoints are deleted first.
+func (b *Broker) deleteServ(ctx context.Context, nsName, servName string) error {
+	if !b.caps.TransactionalWrites {
+		endps, err := b.Reg.ListEndp(ctx, nsName, servName)
+		if err != nil {
+			return err
+		}
+
+		for _, endp := range endps {
+			if err := b.Reg.DeleteEndp(ctx, nsName, servName, endp.Name); err != nil && err != ErrNotFound {
+				return err
+			}
+		}
+	}
+
+	return b.Reg.DeleteServ(ctx, nsName, servName)
 }
@@ -18,12 +18,13 @@
 
 package servregistry
 
-import (
-	corev1 "k8s.io/api/core/v1"
-)
+import "context"
 
 // ServiceRegistry is an interface containing functions that are implemented
 // by a service registry.
+//
+// Deprecated: implement ServiceRegistryV2 instead, so that requests can be
+// cancelled. Use AdaptV1 to use an existing implementation with the Broker.
 type ServiceRegistry interface {
 	// GetNs returns the namespace if exists.
 	GetNs(name string) (*Namespace, error)
@@ -55,8 +56,50 @@ type ServiceRegistry interface {
 	UpdateEndp(endp *Endpoint) (*Endpoint, error)
 	// DeleteEndp deletes the endpoint.
 	DeleteEndp(nsName, servName, endpName string) error
-	// ExtractData extracts relevant data from the provided Kubernetes namespace and service
-	// and returns a namespace, service and an array of endpoints with data relevant to this
-	// specific service registry.
-	ExtractData(ns *corev1.Namespace, serv *corev1.Service) (*Namespace, *Service, []*Endpoint, error)
+}
+
+// ServiceRegistryV2 is an interface containing functions that are implemented
+// by a service registry.
+//
+// All functions that send requests to the service registry accept a context,
+// which must be used to stop the request as soon as it is cancelled or its
+// deadline expires.
+type ServiceRegistryV2 interface {
+	// GetNs returns the namespace if exists.
+	GetNs(ctx context.Context, name string) (*Namespace, error)
+	// ListNs returns a list of all namespaces.
+	ListNs(ctx context.Context) ([]*Namespace, error)
+	// CreateNs creates the namespace.
+	CreateNs(ctx context.Context, ns *Namespace) (*Namespace, error)
+	// UpdateNs updates the namespace.
+	UpdateNs(ctx context.Context, ns *Namespace) (*Namespace, error)
+	// DeleteNs deletes the namespace.
+	DeleteNs(ctx context.Context, name string) error
+	// GetServ returns the service if exists.
+	GetServ(ctx context.Context, nsName, servName string) (*Service, error)
+	// ListServ returns a list of services inside the provided namespace.
+	ListServ(ctx context.Context, nsName string) ([]*Service, error)
+	// CreateServ creates the service.
+	CreateServ(ctx context.Context, serv *Service) (*Service, error)
+	// UpdateServ updates the service.
+	UpdateServ(ctx context.Context, serv *Service) (*Service, error)
+	// DeleteServ deletes the service.
+	DeleteServ(ctx context.Context, nsName, servName string) error
+	// GetEndp returns the endpoint if exists.
+	GetEndp(ctx context.Context, nsName, servName, endpName string) (*Endpoint, error)
+	// ListEndp returns a list of endpoints belonging to the provided namespace and service.
+	ListEndp(ctx context.Context, nsName, servName string) ([]*Endpoint, error)
+	// CreateEndp creates the endpoint.
+	CreateEndp(ctx context.Context, endp *Endpoint) (*Endpoint, error)
+	// UpdateEndp updates the endpoint.
+	UpdateEndp(ctx context.Context, endp *Endpoint) (*Endpoint, error)
+	// DeleteEndp deletes the endpoint.
+	DeleteEndp(ctx context.Context, nsName, servName, endpName string) error
+}
+
+// ServiceIDGetter is implemented by service registries that identify
+// services with an ID of their own, e.g. a resource name or an ARN.
+type ServiceIDGetter interface {
+	// GetServID returns the ID of the service in the service registry.
+	GetServID(ctx context.Context, nsName, servName string) (string, error)
 }
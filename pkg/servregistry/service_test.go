@@ -19,6 +19,7 @@
 package servregistry
 
 import (
+	"context"
 	"testing"
 
 	a "github.com/stretchr/testify/assert"
@@ -28,11 +29,11 @@ func TestManageServ(t *testing.T) {
 	// prepare
 	nsName, servName := "ns", "serv"
 	var f *fakeServReg
-	b, _ := NewBroker(f, MetadataPair{})
+	b, _ := NewBroker(AdaptV1(f), MetadataPair{})
 
 	resetFake := func() {
 		f = newFakeStruct()
-		b.Reg = f
+		b.Reg = AdaptV1(f)
 	}
 
 	resetFake()
@@ -48,24 +49,24 @@ func TestManageServ(t *testing.T) {
 
 		// no service registry provided
 		b.Reg = nil
-		regServ, err = b.ManageServ(nil)
+		regServ, err = b.ManageServ(context.Background(), nil)
 		assert.Nil(regServ)
 		assert.Equal(ErrServRegNotProvided, err)
 
 		// service is nil
-		b.Reg = f
-		regServ, err = b.ManageServ(nil)
+		b.Reg = AdaptV1(f)
+		regServ, err = b.ManageServ(context.Background(), nil)
 		assert.Nil(regServ)
 		assert.Equal(ErrServNotProvided, err)
 
 		// service has no name
-		regServ, err = b.ManageServ(invalidServData)
+		regServ, err = b.ManageServ(context.Background(), invalidServData)
 		assert.Nil(regServ)
 		assert.Equal(ErrServNameNotProvided, err)
 
 		// service has no namespace name
 		invalidServData.Name = servName
-		regServ, err = b.ManageServ(invalidServData)
+		regServ, err = b.ManageServ(context.Background(), invalidServData)
 		assert.Nil(regServ)
 		assert.Equal(ErrNsNameNotProvided, err)
 
@@ -78,7 +79,7 @@ func TestManageServ(t *testing.T) {
 		defer resetFake()
 		assert := a.New(tt)
 
-		regServ, err := b.ManageServ(&Service{Name: "get-error", NsName: "ns"})
+		regServ, err := b.ManageServ(context.Background(), &Service{Name: "get-error", NsName: "ns"})
 		assert.Nil(regServ)
 		assert.Error(err)
 		assert.NotEqual(ErrServRegNotProvided, err)
@@ -102,11 +103,11 @@ func TestManageServ(t *testing.T) {
 		oneChange := &Service{Name: "one", NsName: nsName, Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val-1"}}
 		twoChange := &Service{Name: "two", NsName: nsName, Metadata: map[string]string{"key": "val-1"}}
 
-		regServ, err := b.ManageServ(oneChange)
+		regServ, err := b.ManageServ(context.Background(), oneChange)
 		assert.Equal(one, regServ)
 		assert.NoError(err)
 
-		regServ, err = b.ManageServ(twoChange)
+		regServ, err = b.ManageServ(context.Background(), twoChange)
 		assert.Equal(two, regServ)
 		assert.NoError(err)
 
@@ -124,7 +125,7 @@ func TestManageServ(t *testing.T) {
 		changeErr := &Service{Name: "update-error", NsName: nsName, Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val-1"}}
 		f.servList[shouldErr.Name] = shouldErr
 
-		regServ, err := b.ManageServ(changeErr)
+		regServ, err := b.ManageServ(context.Background(), changeErr)
 		assert.Nil(regServ)
 		assert.Error(err)
 		assert.Empty(f.updatedServ)
@@ -134,7 +135,7 @@ func TestManageServ(t *testing.T) {
 		okChange := &Service{Name: "update", NsName: nsName, Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val-1"}}
 		f.servList[shouldOk.Name] = shouldOk
 
-		regServ, err = b.ManageServ(okChange)
+		regServ, err = b.ManageServ(context.Background(), okChange)
 		assert.Equal(okChange, regServ)
 		assert.NoError(err)
 
@@ -150,14 +151,14 @@ func TestManageServ(t *testing.T) {
 		// should return nil because an error in creating
 		// (this also happens if someone else creates this)
 		shouldErr := &Service{Name: "create-error", NsName: nsName, Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val"}}
-		regServ, err := b.ManageServ(shouldErr)
+		regServ, err := b.ManageServ(context.Background(), shouldErr)
 		assert.Nil(regServ)
 		assert.Error(err)
 		assert.Empty(f.createdServ)
 
 		// no error so it should return the created
 		shouldOk := &Service{Name: "create", NsName: nsName, Metadata: map[string]string{"key": "val"}}
-		regServ, err = b.ManageServ(shouldOk)
+		regServ, err = b.ManageServ(context.Background(), shouldOk)
 		assert.Equal(shouldOk.Name, regServ.Name)
 		assert.Equal(shouldOk.NsName, regServ.NsName)
 		assert.Equal(map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val"}, regServ.Metadata)
@@ -178,11 +179,11 @@ func TestRemoveServ(t *testing.T) {
 	// prepare
 	nsName, servName := "ns", "serv"
 	var f *fakeServReg
-	b, _ := NewBroker(f, MetadataPair{})
+	b, _ := NewBroker(AdaptV1(f), MetadataPair{})
 
 	resetFake := func() {
 		f = newFakeStruct()
-		b.Reg = f
+		b.Reg = AdaptV1(f)
 	}
 
 	resetFake()
@@ -194,16 +195,16 @@ func TestRemoveServ(t *testing.T) {
 
 		// no service registry provided
 		b.Reg = nil
-		err := b.RemoveServ(nsName, servName, false)
+		err := b.RemoveServ(context.Background(), nsName, servName, false)
 		assert.Equal(ErrServRegNotProvided, err)
 
 		// service name not provided
-		b.Reg = f
-		err = b.RemoveServ(nsName, "", false)
+		b.Reg = AdaptV1(f)
+		err = b.RemoveServ(context.Background(), nsName, "", false)
 		assert.Equal(ErrServNameNotProvided, err)
 
 		// namespace name not provided
-		err = b.RemoveServ("", servName, false)
+		err = b.RemoveServ(context.Background(), "", servName, false)
 		assert.Equal(ErrNsNameNotProvided, err)
 		assert.Empty(f.deletedServ)
 	}
@@ -213,7 +214,7 @@ func TestRemoveServ(t *testing.T) {
 		defer resetFake()
 		assert := a.New(tt)
 
-		err := b.RemoveServ(nsName, "get-error", false)
+		err := b.RemoveServ(context.Background(), nsName, "get-error", false)
 		assert.Error(err)
 		assert.NotEqual(ErrServRegNotProvided, err)
 		assert.NotEqual(ErrServNameNotProvided, err)
@@ -232,9 +233,9 @@ func TestRemoveServ(t *testing.T) {
 		f.servList[one.Name] = one
 		f.servList[two.Name] = two
 
-		err := b.RemoveServ(one.NsName, one.Name, false)
+		err := b.RemoveServ(context.Background(), one.NsName, one.Name, false)
 		assert.Equal(ErrServNotOwnedByOp, err)
-		err = b.RemoveServ(two.NsName, two.Name, false)
+		err = b.RemoveServ(context.Background(), two.NsName, two.Name, false)
 		assert.Equal(ErrServNotOwnedByOp, err)
 		assert.Empty(f.deletedServ)
 	}
@@ -245,19 +246,19 @@ func TestRemoveServ(t *testing.T) {
 		assert := a.New(tt)
 
 		// unknown error
-		err := b.RemoveServ(nsName, "delete-error", false)
+		err := b.RemoveServ(context.Background(), nsName, "delete-error", false)
 		assert.NotEqual(ErrServRegNotProvided, err)
 		assert.NotEqual(ErrNsNameNotProvided, err)
 
 		// when it doesn't exist, we just log but return no error
 		// because it doesn't change anything for us
-		err = b.RemoveServ(nsName, "doesnt-exist", false)
+		err = b.RemoveServ(context.Background(), nsName, "doesnt-exist", false)
 		assert.NoError(err)
 
 		// successful
 		toDel := &Service{Name: "to-del", NsName: nsName, Metadata: map[string]string{b.opMetaPair.Key: b.opMetaPair.Value, "key": "val"}}
 		f.servList[toDel.Name] = toDel
-		err = b.RemoveServ(nsName, "to-del", false)
+		err = b.RemoveServ(context.Background(), nsName, "to-del", false)
 		assert.NoError(err)
 		assert.Len(f.deletedServ, 1)
 	}
@@ -290,7 +291,7 @@ func TestRemoveServ(t *testing.T) {
 
 		// error in listing
 		f.endpList["list-error"] = &Endpoint{}
-		err := b.RemoveServ(servDel.NsName, servDel.Name, false)
+		err := b.RemoveServ(context.Background(), servDel.NsName, servDel.Name, false)
 		assert.Error(err)
 		assert.Empty(f.deletedServ)
 		delete(f.endpList, "list-error")
@@ -300,12 +301,12 @@ func TestRemoveServ(t *testing.T) {
 		f.endpList["two"] = twoOwned
 		f.endpList["three"] = threeNotOwned
 
-		err = b.RemoveServ(servDel.NsName, servDel.Name, false)
+		err = b.RemoveServ(context.Background(), servDel.NsName, servDel.Name, false)
 		assert.Empty(f.deletedEndp)
 		assert.Empty(f.deletedServ)
 		assert.Equal(ErrServNotEmpty, err)
 
-		err = b.RemoveServ(servDel.NsName, servDel.Name, true)
+		err = b.RemoveServ(context.Background(), servDel.NsName, servDel.Name, true)
 		assert.Len(f.deletedEndp, 2)
 		assert.Empty(f.deletedServ)
 		assert.Equal(ErrServNotOwnedEndps, err)
@@ -318,7 +319,7 @@ func TestRemoveServ(t *testing.T) {
 		f.endpList["two"] = twoOwned
 		f.deletedEndp = []string{}
 		f.deletedServ = []string{}
-		err = b.RemoveServ(servDel.NsName, servDel.Name, true)
+		err = b.RemoveServ(context.Background(), servDel.NsName, servDel.Name, true)
 		assert.NoError(err)
 
 		// error occurs in deleting service
@@ -326,7 +327,7 @@ func TestRemoveServ(t *testing.T) {
 		f.servList[shouldErr.Name] = shouldErr
 		f.deletedEndp = []string{}
 		f.deletedServ = []string{}
-		err = b.RemoveServ(shouldErr.NsName, shouldErr.Name, true)
+		err = b.RemoveServ(context.Background(), shouldErr.NsName, shouldErr.Name, true)
 		assert.Error(err)
 	}
 
@@ -336,3 +337,28 @@ func TestRemoveServ(t *testing.T) {
 	testEmptyOwned(t)
 	testNotEmptyOwned(t)
 }
+
+func TestDeregisterServ(t *testing.T) {
+	assert := a.New(t)
+	f := newFakeStruct()
+	b, _ := NewBroker(AdaptV1(f), MetadataPair{Key: defOpKey, Value: defOpVal})
+
+	// the service is not there
+	removed, err := b.DeregisterServ(context.Background(), "ns", "doesnt-exist")
+	assert.NoError(err)
+	assert.False(removed)
+
+	// the service is not owned by the operator
+	f.servList["not-owned"] = &Service{Name: "not-owned", NsName: "ns", Metadata: map[string]string{"key": "val"}}
+	removed, err = b.DeregisterServ(context.Background(), "ns", "not-owned")
+	assert.Equal(ErrServNotOwnedByOp, err)
+	assert.False(removed)
+
+	// the service is removed even if it is not empty
+	f.servList["to-del"] = &Service{Name: "to-del", NsName: "ns", Metadata: map[string]string{defOpKey: defOpVal}}
+	f.endpList["endp"] = &Endpoint{Name: "endp", ServName: "to-del", NsName: "ns", Metadata: map[string]string{defOpKey: defOpVal}}
+	removed, err = b.DeregisterServ(context.Background(), "ns", "to-del")
+	assert.NoError(err)
+	assert.True(removed)
+	assert.Equal([]string{"to-del"}, f.deletedServ)
+}
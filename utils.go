@@ -27,12 +27,16 @@ import (
 	"time"
 
 	sd "cloud.google.com/go/servicedirectory/apiv1"
+	"github.com/CloudNativeSDWAN/cnwan-operator/controllers"
 	"github.com/CloudNativeSDWAN/cnwan-operator/internal/types"
 	"github.com/CloudNativeSDWAN/cnwan-operator/pkg/cluster"
+	"github.com/CloudNativeSDWAN/cnwan-operator/pkg/servregistry/convert"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/servicediscovery"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"google.golang.org/api/option"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func getNetworkCfg(network, subnetwork *string) (netCfg *cluster.NetworkConfiguration, err error) {
@@ -223,3 +227,79 @@ func getEtcdClient(settings *types.EtcdSettings) (*clientv3.Client, error) {
 	// for the secrets containing the client's certificate and and key.
 	return nil, fmt.Errorf("unsupported etcd authentication method")
 }
+
+// getSelector parses the label selector, returning nil if it is empty.
+func getSelector(selector string) (labels.Selector, error) {
+	if selector == "" {
+		return nil, nil
+	}
+
+	return labels.Parse(selector)
+}
+
+func getNodePortOptions(settings *types.NodePortSettings) (*controllers.NodePortOptions, error) {
+	sel, err := labels.Parse(settings.NodeSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &controllers.NodePortOptions{
+		NodeSelector: sel,
+		AddressTypes: []corev1.NodeAddressType{},
+	}
+	for _, addrType := range settings.AddressTypes {
+		opts.AddressTypes = append(opts.AddressTypes, corev1.NodeAddressType(addrType))
+	}
+
+	return opts, nil
+}
+
+// getHostnameOptions returns the options about load balancer hostnames.
+// If the service registry does not accept a hostname as the address of an
+// endpoint, the hostname is always resolved.
+func getHostnameOptions(settings *types.LoadBalancerHostnameSettings, hostnameSupported bool) *controllers.HostnameOptions {
+	if settings == nil {
+		return nil
+	}
+
+	return &controllers.HostnameOptions{
+		Resolve:         settings.Policy != types.LoadBalancerHostnameRegister || !hostnameSupported,
+		RefreshInterval: settings.RefreshInterval,
+	}
+}
+
+// getMetadataRules returns the metadata rules that apply to the type of
+// service registry, in the same order.
+func getMetadataRules(settings []types.MetadataRuleSettings, servregType string) []convert.MetadataRule {
+	rules := []convert.MetadataRule{}
+	for _, rule := range settings {
+		if !appliesTo(rule.Registries, servregType) {
+			continue
+		}
+
+		rules = append(rules, convert.MetadataRule{
+			RenameKeys:    rule.RenameKeys,
+			StripPrefix:   rule.StripPrefix,
+			AddPrefix:     rule.AddPrefix,
+			LowercaseKeys: rule.LowercaseKeys,
+			MapValues:     rule.MapValues,
+			Defaults:      rule.Defaults,
+		})
+	}
+
+	return rules
+}
+
+func appliesTo(registries []string, servregType string) bool {
+	if len(registries) == 0 {
+		return true
+	}
+
+	for _, registry := range registries {
+		if registry == servregType {
+			return true
+		}
+	}
+
+	return false
+}